@@ -0,0 +1,335 @@
+// Deposit address management.
+//
+// A Manager issues deposit addresses to customers and records every issuance, so
+// that deposits can be attributed (see Lookup). The current address of every
+// currency (GetDepositAddresses) is cached and a new one is generated
+// (GenerateNewAddress) when missing or when the rotation policy requires it: one
+// address shared by every customer, one per customer or one per deposit.
+//
+// Currencies with a DepositAddress in GetCurrencies (e.g. XMR, XRP, STR) receive every
+// deposit on that shared address: the address of the account is then a payment id
+// which must be given with the deposit. Issuances of those currencies have both.
+//
+// Issuances are saved to a JSON file after every change.
+package addresses
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:addresses]")
+
+type Rotation int
+
+const (
+	Shared      Rotation = iota // current address of the currency for every issuance
+	PerCustomer                 // a new address for every customer, reused for their deposits
+	PerDeposit                  // a new address for every issuance
+)
+
+func (r Rotation) String() string {
+
+	switch r {
+	case Shared:
+		return "shared"
+	case PerCustomer:
+		return "per customer"
+	case PerDeposit:
+		return "per deposit"
+	default:
+		return fmt.Sprintf("unknown rotation %d", int(r))
+	}
+}
+
+// An Issuance records an address given to a customer.
+type Issuance struct {
+	Currency  string    `json:"currency"`
+	Address   string    `json:"address"`
+	PaymentId string    `json:"paymentId,omitempty"` // for currencies with a shared deposit address
+	Customer  string    `json:"customer"`
+	Reference string    `json:"reference,omitempty"` // e.g. invoice or order of the deposit
+	Time      time.Time `json:"time"`
+}
+
+type Manager struct {
+	client   *tradingapi.Client
+	public   *publicapi.Client
+	path     string
+	rotation Rotation
+
+	mu         sync.Mutex
+	currencies publicapi.Currencies
+	addresses  tradingapi.DepositAddresses // current address (or payment id) by currency
+	issuances  []*Issuance
+}
+
+// NewManager returns a manager issuing addresses of the account of client under
+// rotation and saving its issuances to path, loading the issuances saved by a previous
+// run. public is used to load the currencies.
+func NewManager(client *tradingapi.Client, public *publicapi.Client, path string, rotation Rotation) (*Manager, error) {
+
+	if rotation < Shared || rotation > PerDeposit {
+		return nil, fmt.Errorf("Wrong rotation parameter: %d", rotation)
+	}
+
+	m := Manager{
+		client:   client,
+		public:   public,
+		path:     path,
+		rotation: rotation,
+	}
+
+	content, err := ioutil.ReadFile(path)
+
+	switch {
+	case os.IsNotExist(err):
+		return &m, nil
+	case err != nil:
+		return nil, fmt.Errorf("ioutil.ReadFile: %v", err)
+	}
+
+	if err := json.Unmarshal(content, &m.issuances); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	return &m, nil
+}
+
+// Refresh reloads the currencies and the current deposit addresses.
+func (m *Manager) Refresh() error {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.currencies, m.addresses = nil, nil
+
+	return m.load()
+}
+
+// load must be called with m.mu held.
+func (m *Manager) load() error {
+
+	if m.currencies == nil {
+		currencies, err := m.public.GetCurrencies()
+		if err != nil {
+			return fmt.Errorf("PublicClient.GetCurrencies: %v", err)
+		}
+		m.currencies = currencies
+	}
+
+	if m.addresses == nil {
+		addresses, err := m.client.GetDepositAddresses()
+		if err != nil {
+			return fmt.Errorf("TradingClient.GetDepositAddresses: %v", err)
+		}
+		m.addresses = addresses
+	}
+
+	return nil
+}
+
+// RequiresPaymentId returns whether deposits of currency need a payment id, and the
+// shared deposit address of the currency.
+func (m *Manager) RequiresPaymentId(currency string) (bool, string, error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.load(); err != nil {
+		return false, "", err
+	}
+
+	c, ok := m.currencies[currency]
+	if !ok {
+		return false, "", fmt.Errorf("Wrong currency parameter: %s", currency)
+	}
+
+	return c.DepositAddress != "", c.DepositAddress, nil
+}
+
+// Issue returns an address for a deposit of customer in currency, according to the
+// rotation policy, and records the issuance.
+func (m *Manager) Issue(currency, customer, reference string) (*Issuance, error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+
+	c, ok := m.currencies[currency]
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("Wrong currency parameter: %s", currency)
+	case c.Disabled || c.Delisted || c.Frozen:
+		return nil, fmt.Errorf("%s deposits unavailable (disabled, delisted or frozen)", currency)
+	}
+
+	current := m.addresses[currency]
+
+	if m.rotation == PerCustomer {
+		for _, i := range m.issuances {
+			if i.Currency == currency && i.Customer == customer {
+				current = m.raw(i)
+				break
+			}
+		}
+	}
+
+	generate := false
+	switch {
+	case current == "":
+		generate = true
+	case m.rotation == PerCustomer:
+		generate = m.issuedToOther(currency, current, customer)
+	case m.rotation == PerDeposit:
+		generate = m.issued(currency, current)
+	}
+
+	if generate {
+
+		address, err := m.client.GenerateNewAddress(currency)
+		if err != nil {
+			return nil, fmt.Errorf("TradingClient.GenerateNewAddress: %v", err)
+		}
+
+		logger.Infof("new %s deposit address: %s", currency, address)
+
+		m.addresses[currency] = address
+		current = address
+	}
+
+	i := Issuance{
+		Currency:  currency,
+		Address:   current,
+		Customer:  customer,
+		Reference: reference,
+		Time:      time.Now(),
+	}
+
+	if c.DepositAddress != "" {
+		i.Address, i.PaymentId = c.DepositAddress, current
+	}
+
+	m.issuances = append(m.issuances, &i)
+
+	if err := m.save(); err != nil {
+		m.issuances = m.issuances[:len(m.issuances)-1]
+		return nil, err
+	}
+
+	res := i
+	return &res, nil
+}
+
+// raw returns the address as returned by the API: the payment id for currencies with
+// a shared deposit address.
+func (m *Manager) raw(i *Issuance) string {
+
+	if i.PaymentId != "" {
+		return i.PaymentId
+	}
+
+	return i.Address
+}
+
+// issued must be called with m.mu held.
+func (m *Manager) issued(currency, raw string) bool {
+
+	for _, i := range m.issuances {
+		if i.Currency == currency && m.raw(i) == raw {
+			return true
+		}
+	}
+
+	return false
+}
+
+// issuedToOther must be called with m.mu held.
+func (m *Manager) issuedToOther(currency, raw, customer string) bool {
+
+	for _, i := range m.issuances {
+		if i.Currency == currency && m.raw(i) == raw && i.Customer != customer {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Lookup returns the issuances of an address (and payment id, empty for currencies
+// without), e.g. to attribute a deposit (tradingapi.DepositHistory.Address).
+func (m *Manager) Lookup(currency, address, paymentId string) []*Issuance {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var res []*Issuance
+
+	for _, i := range m.issuances {
+		if i.Currency == currency && i.Address == address && i.PaymentId == paymentId {
+			c := *i
+			res = append(res, &c)
+		}
+	}
+
+	return res
+}
+
+// Issuances returns the issuances of customer, every issuance when customer is empty.
+func (m *Manager) Issuances(customer string) []*Issuance {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var res []*Issuance
+
+	for _, i := range m.issuances {
+		if customer == "" || i.Customer == customer {
+			c := *i
+			res = append(res, &c)
+		}
+	}
+
+	return res
+}
+
+// Addresses returns the cached current deposit addresses by currency (payment ids for
+// currencies with a shared deposit address).
+func (m *Manager) Addresses() (tradingapi.DepositAddresses, error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+
+	res := make(tradingapi.DepositAddresses)
+	for currency, address := range m.addresses {
+		res[currency] = address
+	}
+
+	return res, nil
+}
+
+func (m *Manager) save() error {
+
+	content, err := json.MarshalIndent(m.issuances, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %v", err)
+	}
+
+	return util.WriteFile(m.path, content)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/joemocquant/poloniex-api/addresses"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+var manager *addresses.Manager
+
+func main() {
+
+	tradingClient, err := tradingapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	manager, err = addresses.NewManager(tradingClient, publicapi.NewClient(),
+		"addresses.json", addresses.PerCustomer)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	issue("BTC", "alice")
+
+	// issue("XMR", "bob")
+
+	// printIssuances("alice")
+}
+
+// Issue a deposit address to customer
+func issue(currency, customer string) {
+
+	i, err := manager.Issue(currency, customer, "")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if i.PaymentId != "" {
+		fmt.Printf("deposit %s to %s with payment id %s\n", currency, i.Address, i.PaymentId)
+	} else {
+		fmt.Printf("deposit %s to %s\n", currency, i.Address)
+	}
+}
+
+// Print the addresses issued to customer
+func printIssuances(customer string) {
+
+	for _, i := range manager.Issuances(customer) {
+		fmt.Printf("%s %s %s %s\n", i.Time.Format("2006-01-02 15:04:05"), i.Currency, i.Address, i.PaymentId)
+	}
+}
@@ -0,0 +1,307 @@
+// Triangular arbitrage detection.
+//
+// A Detector builds the currency graph from the market names and evaluates the
+// triangular cycles starting from given currencies (e.g. USDT->BTC->XMR->USDT). Cycles
+// are evaluated on order book depth, paying the taker fee on every leg, and the
+// profitable ones are emitted with their executable size: the largest amount still
+// returning the minimum profit.
+//
+// Order books are either polled for every market (Run), or loaded only for the cycles
+// whose top of book is profitable according to the push ticker (RunTicker).
+// Opportunities are executed as sequential immediate-or-cancel orders when Execute is
+// set.
+package arbitrage
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/pushapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:arbitrage]")
+
+const (
+	defaultDepth    = 20
+	defaultInterval = 10 * time.Second
+	defaultTakerFee = 0.0025
+)
+
+type Params struct {
+	Start     []string           // start currencies, e.g. USDT and BTC
+	Depth     int                // order book depth, 20 when zero
+	MinProfit float64            // minimum profit after fees, relative to the amount
+	MaxAmount map[string]float64 // maximum amount by start currency
+	// Loaded with GetFeeInfo when zero and a trading client is given, 0.25% otherwise
+	TakerFee float64
+	Interval time.Duration // order books polling period of Run, 10 seconds when zero
+	Execute  bool          // execute the opportunities (requires a trading client)
+}
+
+type top struct {
+	bid float64
+	ask float64
+}
+
+type Detector struct {
+	public *publicapi.Client
+	client *tradingapi.Client // nil when not executing nor loading fees
+	params Params
+
+	graph  *Graph
+	cycles []Cycle
+	byPair map[string][]Cycle
+
+	mu        sync.Mutex
+	fee       float64
+	tops      map[string]*top
+	executing bool
+
+	opportunities chan *Opportunity
+}
+
+// NewDetector returns a detector loading market data with public. client is used to
+// load the taker fee and execute opportunities, it may be nil otherwise.
+func NewDetector(public *publicapi.Client, client *tradingapi.Client, params Params) (*Detector, error) {
+
+	switch {
+	case len(params.Start) == 0:
+		return nil, fmt.Errorf("Wrong start parameter: no currency")
+	case params.MinProfit < 0:
+		return nil, fmt.Errorf("Wrong min profit parameter: %v", params.MinProfit)
+	case params.Execute && client == nil:
+		return nil, fmt.Errorf("execution requires a trading client")
+	}
+
+	if params.Depth == 0 {
+		params.Depth = defaultDepth
+	}
+	if params.Interval == 0 {
+		params.Interval = defaultInterval
+	}
+
+	fee := params.TakerFee
+	if fee == 0 && client != nil {
+		info, err := client.GetFeeInfo()
+		if err != nil {
+			return nil, fmt.Errorf("TradingClient.GetFeeInfo: %v", err)
+		}
+		fee = info.TakerFee
+	}
+	if fee == 0 {
+		fee = defaultTakerFee
+	}
+
+	ticks, err := public.GetTickers()
+	if err != nil {
+		return nil, fmt.Errorf("PublicClient.GetTickers: %v", err)
+	}
+
+	var pairs []string
+	tops := make(map[string]*top)
+
+	for pair, tick := range ticks {
+		if tick.IsFrozen {
+			continue
+		}
+		pairs = append(pairs, pair)
+		tops[pair] = &top{tick.HighestBid, tick.LowestAsk}
+	}
+
+	graph, err := NewGraph(pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	d := Detector{
+		public:        public,
+		client:        client,
+		params:        params,
+		graph:         graph,
+		cycles:        graph.Triangles(params.Start...),
+		byPair:        make(map[string][]Cycle),
+		fee:           fee,
+		tops:          tops,
+		opportunities: make(chan *Opportunity, 100),
+	}
+
+	for _, c := range d.cycles {
+		for _, e := range c {
+			d.byPair[e.CurrencyPair] = append(d.byPair[e.CurrencyPair], c)
+		}
+	}
+
+	logger.Infof("%d cycles from %v, taker fee %v", len(d.cycles), params.Start, fee)
+
+	return &d, nil
+}
+
+// Opportunities returns the channel on which opportunities are emitted (after their
+// execution when Execute is set). Opportunities are dropped when it is full.
+func (d *Detector) Opportunities() <-chan *Opportunity {
+	return d.opportunities
+}
+
+func (d *Detector) Graph() *Graph {
+	return d.graph
+}
+
+func (d *Detector) Cycles() []Cycle {
+	return d.cycles
+}
+
+// Scan loads the order books of every market and evaluates every cycle.
+func (d *Detector) Scan() ([]*Opportunity, error) {
+
+	books, err := d.public.GetOrderBooks(d.params.Depth)
+	if err != nil {
+		return nil, fmt.Errorf("PublicClient.GetOrderBooks: %v", err)
+	}
+
+	d.mu.Lock()
+	for pair, book := range books {
+		if t, ok := d.tops[pair]; ok && len(book.Bids) > 0 && len(book.Asks) > 0 {
+			t.bid, t.ask = book.Bids[0].Rate, book.Asks[0].Rate
+		}
+	}
+	d.mu.Unlock()
+
+	return d.evaluate(d.cycles, books), nil
+}
+
+// Run scans every interval until done is closed.
+func (d *Detector) Run(done <-chan struct{}) {
+
+	ticker := time.NewTicker(d.params.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Scan(); err != nil {
+			logger.WithField("error", err).Error("Detector.Scan")
+		}
+
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+	}
+}
+
+// RunTicker updates the top of book of the markets from the push ticker until done is
+// closed. The order books of a cycle are only loaded when its top of book return
+// exceeds the minimum profit.
+func (d *Detector) RunTicker(ticker pushapi.Ticker, done <-chan struct{}) {
+
+	for {
+		select {
+		case tick := <-ticker:
+			if tick == nil {
+				continue
+			}
+			if err := d.tick(tick); err != nil {
+				logger.WithField("error", err).Error("Detector.tick")
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+func (d *Detector) tick(tick *pushapi.Tick) error {
+
+	d.mu.Lock()
+
+	t, ok := d.tops[tick.CurrencyPair]
+	if !ok {
+		d.mu.Unlock()
+		return nil
+	}
+	t.bid, t.ask = tick.HighestBid, tick.LowestAsk
+
+	var promising []Cycle
+	for _, c := range d.byPair[tick.CurrencyPair] {
+		if topReturn(c, d.tops, d.fee) > 1+d.params.MinProfit {
+			promising = append(promising, c)
+		}
+	}
+
+	d.mu.Unlock()
+
+	if len(promising) == 0 {
+		return nil
+	}
+
+	books := make(publicapi.OrderBooks)
+
+	for _, c := range promising {
+		for _, e := range c {
+
+			if _, ok := books[e.CurrencyPair]; ok {
+				continue
+			}
+
+			book, err := d.public.GetOrderBook(e.CurrencyPair, d.params.Depth)
+			if err != nil {
+				return fmt.Errorf("PublicClient.GetOrderBook: %v", err)
+			}
+			books[e.CurrencyPair] = book
+		}
+	}
+
+	d.evaluate(promising, books)
+
+	return nil
+}
+
+// evaluate evaluates cycles in books, then emits and executes the opportunities.
+func (d *Detector) evaluate(cycles []Cycle, books publicapi.OrderBooks) []*Opportunity {
+
+	d.mu.Lock()
+	fee := d.fee
+	d.mu.Unlock()
+
+	var res []*Opportunity
+	executed := false
+
+	for _, c := range cycles {
+
+		frozen := false
+		for _, e := range c {
+			if book, ok := books[e.CurrencyPair]; ok && book.IsFrozen {
+				frozen = true
+			}
+		}
+		if frozen {
+			continue
+		}
+
+		o := evaluate(c, books, fee, d.params.MinProfit, d.params.MaxAmount[c.Start()])
+		if o == nil {
+			continue
+		}
+
+		logger.Infof("%s: %.8f %s -> %.8f (%.4f%%)",
+			c, o.Amount, c.Start(), o.Return, o.Ratio*100)
+
+		// The books are stale after an execution
+		if d.params.Execute && !executed {
+			d.execute(o)
+			executed = true
+		}
+
+		res = append(res, o)
+
+		select {
+		case d.opportunities <- o:
+		default:
+			logger.Warn("opportunities channel full, opportunity dropped")
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,245 @@
+package arbitrage
+
+import (
+	"math"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+// Search iterations of the executable size
+const iterations = 60
+
+// A Leg is one order of an opportunity.
+type Leg struct {
+	*Edge
+	In     float64 // amount of the From currency spent
+	Out    float64 // amount of the To currency received, net of fees
+	Amount float64 // order amount (in the quote currency of the market)
+	Rate   float64 // worst rate of the book levels taken, used as order limit
+}
+
+type Opportunity struct {
+	Cycle  Cycle
+	Amount float64 // executable size, in the start currency
+	Return float64 // amount of the start currency received back, net of fees
+	Profit float64 // Return - Amount
+	Ratio  float64 // Profit / Amount
+	Legs   []*Leg
+	Time   time.Time
+
+	// Set when the opportunity was executed
+	Executed bool
+	Received float64 // amount of the start currency actually received
+	Error    string
+}
+
+// convert walks the book of e for in units of e.From, paying fee on the amount
+// received. It returns false when the book is not deep enough.
+func convert(e *Edge, book *publicapi.OrderBook, in, fee float64) (*Leg, bool) {
+
+	leg := Leg{Edge: e, In: in}
+	left := in
+
+	if e.Type == "buy" {
+
+		// in is base currency, asks quantities are quote currency
+		for _, o := range book.Asks {
+			if left <= 0 {
+				break
+			}
+			quantity := math.Min(o.Quantity, left/o.Rate)
+			leg.Amount += quantity
+			leg.Rate = o.Rate
+			left -= quantity * o.Rate
+		}
+
+		leg.Out = leg.Amount * (1 - fee)
+
+	} else {
+
+		// in is quote currency, sold to the bids
+		for _, o := range book.Bids {
+			if left <= 0 {
+				break
+			}
+			quantity := math.Min(o.Quantity, left)
+			leg.Amount += quantity
+			leg.Out += quantity * o.Rate
+			leg.Rate = o.Rate
+			left -= quantity
+		}
+
+		leg.Out *= 1 - fee
+	}
+
+	return &leg, left <= in*1e-12
+}
+
+// run converts amount through the cycle.
+func run(c Cycle, books publicapi.OrderBooks, amount, fee float64) ([]*Leg, bool) {
+
+	legs := make([]*Leg, 0, len(c))
+	in := amount
+
+	for _, e := range c {
+
+		book, ok := books[e.CurrencyPair]
+		if !ok {
+			return nil, false
+		}
+
+		leg, ok := convert(e, book, in, fee)
+		if !ok {
+			return nil, false
+		}
+
+		legs = append(legs, leg)
+		in = leg.Out
+	}
+
+	return legs, true
+}
+
+// capacity returns the amount of the From currency the book of e can absorb.
+func capacity(e *Edge, book *publicapi.OrderBook) float64 {
+
+	res := 0.0
+
+	if e.Type == "buy" {
+		for _, o := range book.Asks {
+			res += o.Rate * o.Quantity
+		}
+	} else {
+		for _, o := range book.Bids {
+			res += o.Quantity
+		}
+	}
+
+	return res
+}
+
+// evaluate returns the opportunity of cycle c in books, nil when the cycle does not
+// return at least minProfit (relative) after fees. The executable size is the
+// largest amount, up to maxAmount when positive, still returning minProfit without
+// exceeding the amount of maximum profit: book levels are taken best first, so the
+// marginal return of the cycle only decreases with the amount.
+func evaluate(c Cycle, books publicapi.OrderBooks, fee, minProfit, maxAmount float64) *Opportunity {
+
+	first, ok := books[c[0].CurrencyPair]
+	if !ok {
+		return nil
+	}
+
+	profit := func(amount float64) (float64, bool) {
+		legs, ok := run(c, books, amount, fee)
+		if !ok {
+			return 0, false
+		}
+		return legs[len(legs)-1].Out - amount, true
+	}
+
+	// Largest amount the three books can absorb
+	lo, hi := 0.0, capacity(c[0], first)
+	if maxAmount > 0 {
+		hi = math.Min(hi, maxAmount)
+	}
+	if hi <= 0 {
+		return nil
+	}
+
+	if _, ok := profit(hi); !ok {
+		for i := 0; i < iterations; i++ {
+			mid := (lo + hi) / 2
+			if _, ok := profit(mid); ok {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		hi = lo
+	}
+
+	// Top of book return
+	if p, ok := profit(hi * 1e-6); !ok || p/(hi*1e-6) < minProfit || p <= 0 {
+		return nil
+	}
+
+	// Amount of maximum profit (profit is concave in the amount)
+	lo, best := 0.0, hi
+	for i := 0; i < iterations; i++ {
+		m1, m2 := lo+(best-lo)/3, best-(best-lo)/3
+		p1, _ := profit(m1)
+		p2, _ := profit(m2)
+		if p1 < p2 {
+			lo = m1
+		} else {
+			best = m2
+		}
+	}
+
+	// Largest amount up to best still returning minProfit
+	amount := best
+	if p, _ := profit(best); p/best < minProfit {
+		lo, hi := 0.0, best
+		for i := 0; i < iterations; i++ {
+			mid := (lo + hi) / 2
+			if p, _ := profit(mid); p/mid >= minProfit {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		amount = lo
+	}
+
+	amount = util.Floor(amount)
+	if amount <= 0 {
+		return nil
+	}
+
+	legs, ok := run(c, books, amount, fee)
+	if !ok {
+		return nil
+	}
+
+	o := Opportunity{
+		Cycle:  c,
+		Amount: amount,
+		Return: legs[len(legs)-1].Out,
+		Legs:   legs,
+		Time:   time.Now(),
+	}
+	o.Profit = o.Return - o.Amount
+	o.Ratio = o.Profit / o.Amount
+
+	if o.Profit <= 0 || o.Ratio < minProfit {
+		return nil
+	}
+
+	return &o
+}
+
+// topReturn returns the return of one unit through the cycle at the best rates,
+// 0 when a rate is missing.
+func topReturn(c Cycle, tops map[string]*top, fee float64) float64 {
+
+	res := 1.0
+
+	for _, e := range c {
+
+		t, ok := tops[e.CurrencyPair]
+		if !ok || t.bid <= 0 || t.ask <= 0 {
+			return 0
+		}
+
+		if e.Type == "buy" {
+			res *= (1 - fee) / t.ask
+		} else {
+			res *= t.bid * (1 - fee)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/joemocquant/poloniex-api/arbitrage"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/pushapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+func main() {
+
+	publicClient := publicapi.NewClient()
+
+	scan(publicClient)
+
+	// watchTicker(publicClient)
+
+	// execute(publicClient)
+}
+
+// Evaluate once the cycles starting from USDT and BTC
+func scan(publicClient *publicapi.Client) {
+
+	params := arbitrage.Params{
+		Start:     []string{"USDT", "BTC"},
+		MinProfit: 0.001,
+	}
+
+	detector, err := arbitrage.NewDetector(publicClient, nil, params)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opportunities, err := detector.Scan()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%d cycles, %d opportunities\n", len(detector.Cycles()), len(opportunities))
+
+	for _, o := range opportunities {
+		printOpportunity(o)
+	}
+}
+
+// Load order books only for the cycles made profitable by pushed ticks
+func watchTicker(publicClient *publicapi.Client) {
+
+	params := arbitrage.Params{
+		Start:     []string{"USDT"},
+		MinProfit: 0.001,
+	}
+
+	detector, err := arbitrage.NewDetector(publicClient, nil, params)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pushClient, err := pushapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ticker, err := pushClient.SubscribeTicker()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go detector.RunTicker(ticker, nil)
+
+	for o := range detector.Opportunities() {
+		printOpportunity(o)
+	}
+}
+
+// Poll every market and execute the opportunities of at most 100 USDT
+func execute(publicClient *publicapi.Client) {
+
+	tradingClient, err := tradingapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	params := arbitrage.Params{
+		Start:     []string{"USDT"},
+		MinProfit: 0.002,
+		MaxAmount: map[string]float64{"USDT": 100},
+		Execute:   true,
+	}
+
+	detector, err := arbitrage.NewDetector(publicClient, tradingClient, params)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go detector.Run(nil)
+
+	for o := range detector.Opportunities() {
+		printOpportunity(o)
+		fmt.Printf("  received %.8f %s %s\n", o.Received, o.Cycle.Start(), o.Error)
+	}
+}
+
+func printOpportunity(o *arbitrage.Opportunity) {
+
+	fmt.Printf("%s: %.8f -> %.8f (%.3f%%)\n", o.Cycle, o.Amount, o.Return, o.Ratio*100)
+
+	for _, l := range o.Legs {
+		fmt.Printf("  %s %.8f %s up to %.8f\n", l.Type, l.Amount, l.CurrencyPair, l.Rate)
+	}
+}
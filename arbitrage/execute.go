@@ -0,0 +1,93 @@
+package arbitrage
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+)
+
+// execute sends the legs of o as immediate-or-cancel orders, each one spending what
+// the previous one actually received. The legs are limited to the worst rate of the
+// evaluated book levels, so a leg partially filled (the book changed) stops the
+// execution with the intermediate currency left on the account.
+func (d *Detector) execute(o *Opportunity) {
+
+	d.mu.Lock()
+	if d.executing {
+		d.mu.Unlock()
+		return
+	}
+	d.executing = true
+	fee := d.fee
+	d.mu.Unlock()
+
+	defer func() {
+		d.mu.Lock()
+		d.executing = false
+		d.mu.Unlock()
+	}()
+
+	o.Executed = true
+	in := o.Amount
+
+	for i, leg := range o.Legs {
+
+		out, err := d.executeLeg(leg, in, fee)
+		if err != nil {
+			o.Error = fmt.Sprintf("leg %d (%s %s): %v", i+1, leg.Type, leg.CurrencyPair, err)
+			logger.WithField("error", err).Errorf("%s: execution stopped with %.8f %s",
+				o.Cycle, in, leg.From)
+			return
+		}
+
+		in = out
+	}
+
+	o.Received = in
+
+	logger.Infof("%s: executed %.8f %s -> %.8f", o.Cycle, o.Amount, o.Cycle.Start(), o.Received)
+}
+
+// executeLeg spends in units of leg.From and returns the amount of leg.To received.
+func (d *Detector) executeLeg(leg *Leg, in, fee float64) (float64, error) {
+
+	var amount float64
+	place := d.client.SellImmediateOrCancel
+
+	if leg.Type == "buy" {
+		// Amount scaled from the evaluation to what is actually available. The order
+		// limit is the worst rate taken: the amount is capped so that its total at
+		// that rate does not exceed in, even if the order fills at the limit.
+		amount = util.Floor(math.Min(leg.Amount*in/leg.In, in/leg.Rate))
+		place = d.client.BuyImmediateOrCancel
+	} else {
+		amount = util.Floor(in)
+	}
+
+	if amount <= 0 {
+		return 0, fmt.Errorf("nothing to %s", leg.Type)
+	}
+
+	res, err := place(leg.CurrencyPair, leg.Rate, amount)
+	if err != nil {
+		return 0, err
+	}
+
+	filled, out := 0.0, 0.0
+
+	for _, t := range res.ResultingTrades {
+		filled += t.Amount
+		if leg.Type == "buy" {
+			out += t.Amount * (1 - fee)
+		} else {
+			out += t.Total * (1 - fee)
+		}
+	}
+
+	if filled < amount-util.Epsilon {
+		return out, fmt.Errorf("%.8f of %.8f filled at %.8f", filled, amount, leg.Rate)
+	}
+
+	return util.Floor(out), nil
+}
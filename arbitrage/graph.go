@@ -0,0 +1,101 @@
+package arbitrage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+)
+
+// An Edge converts From into To on the market CurrencyPair: a buy when From is the
+// base currency (e.g. BTC to XMR on BTC_XMR), a sell otherwise.
+type Edge struct {
+	From         string
+	To           string
+	CurrencyPair string
+	Type         string // order sent: buy or sell
+}
+
+// A Cycle is a sequence of edges ending at the currency it starts from.
+type Cycle []*Edge
+
+func (c Cycle) Start() string {
+	return c[0].From
+}
+
+func (c Cycle) String() string {
+
+	currencies := []string{c.Start()}
+	for _, e := range c {
+		currencies = append(currencies, e.To)
+	}
+
+	return strings.Join(currencies, "->")
+}
+
+// Graph of the currencies linked by markets.
+type Graph struct {
+	edges map[string][]*Edge // by From currency
+}
+
+// NewGraph builds the currency graph of currencyPairs (e.g. the keys of GetTickers).
+func NewGraph(currencyPairs []string) (*Graph, error) {
+
+	g := Graph{edges: make(map[string][]*Edge)}
+
+	for _, pair := range currencyPairs {
+
+		base, quote, ok := util.SplitPair(pair)
+		if !ok {
+			return nil, fmt.Errorf("wrong currency pair: %s", pair)
+		}
+
+		g.edges[base] = append(g.edges[base], &Edge{base, quote, pair, "buy"})
+		g.edges[quote] = append(g.edges[quote], &Edge{quote, base, pair, "sell"})
+	}
+
+	for _, edges := range g.edges {
+		sort.Slice(edges, func(i, j int) bool { return edges[i].To < edges[j].To })
+	}
+
+	return &g, nil
+}
+
+// Currencies returns the currencies of the graph, sorted.
+func (g *Graph) Currencies() []string {
+
+	res := make([]string, 0, len(g.edges))
+	for c := range g.edges {
+		res = append(res, c)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// Triangles returns the cycles of three edges starting from each start currency (both
+// directions of every triangle are returned, they are distinct opportunities).
+func (g *Graph) Triangles(start ...string) []Cycle {
+
+	var res []Cycle
+
+	for _, a := range start {
+		for _, ab := range g.edges[a] {
+			for _, bc := range g.edges[ab.To] {
+
+				if bc.To == a {
+					continue
+				}
+
+				for _, ca := range g.edges[bc.To] {
+					if ca.To == a {
+						res = append(res, Cycle{ab, bc, ca})
+					}
+				}
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,252 @@
+// Tamper-evident audit log of the trading API.
+//
+// A Log is a tradingapi.AuditSink writing every command sent by a client to a JSON
+// lines file, once before sending it and once with its response. Each line holds a
+// sequence number, the hash of the previous line and its own hash: SHA-256 of the
+// sequence number, the previous hash and the entry as written. Modifying, inserting
+// or removing a line breaks the chain, which Verify detects. Truncating the end of
+// the file keeps a valid chain: keep the Head of the log elsewhere (e.g. printed
+// daily) to detect it.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+// Previous hash of the first line
+var genesis = hex.EncodeToString(make([]byte, sha256.Size))
+
+// A line of the log file.
+type line struct {
+	Seq   int64           `json:"seq"`
+	Prev  string          `json:"prev"`
+	Entry json.RawMessage `json:"entry"`
+	Hash  string          `json:"hash"`
+}
+
+// ErrBroken is returned by Verify when the chain is broken at Line (starting at 1).
+type ErrBroken struct {
+	Line   int
+	Reason string
+}
+
+func (e *ErrBroken) Error() string {
+	return fmt.Sprintf("audit log broken at line %d: %s", e.Line, e.Reason)
+}
+
+type Log struct {
+	mu   sync.Mutex
+	file *os.File
+	seq  int64
+	head string // hash of the last line
+}
+
+// Open opens the log at path for appending, creating it when missing. The existing
+// lines are verified first: a broken log is not appended to.
+func Open(path string) (*Log, error) {
+
+	l := Log{head: genesis}
+
+	f, err := os.Open(path)
+
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("os.Open: %v", err)
+	default:
+		seq, head, err := verify(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		l.seq, l.head = seq, head
+	}
+
+	l.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("os.OpenFile: %v", err)
+	}
+
+	return &l, nil
+}
+
+// Record appends e to the log and syncs the file.
+func (l *Log) Record(e *tradingapi.AuditEntry) error {
+
+	entry, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %v", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return fmt.Errorf("audit log closed")
+	}
+
+	ln := line{
+		Seq:   l.seq + 1,
+		Prev:  l.head,
+		Entry: entry,
+	}
+	ln.Hash = hash(ln.Seq, ln.Prev, ln.Entry)
+
+	content, err := json.Marshal(&ln)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %v", err)
+	}
+
+	if _, err := l.file.Write(append(content, '\n')); err != nil {
+		return fmt.Errorf("os.File.Write: %v", err)
+	}
+
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("os.File.Sync: %v", err)
+	}
+
+	l.seq, l.head = ln.Seq, ln.Hash
+
+	return nil
+}
+
+// Head returns the sequence number and the hash of the last line.
+func (l *Log) Head() (int64, string) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.seq, l.head
+}
+
+func (l *Log) Close() error {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	err := l.file.Close()
+	l.file = nil
+
+	return err
+}
+
+// Verify checks the chain of the log at path and returns the sequence number and the
+// hash of its last line.
+func Verify(path string) (int64, string, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", fmt.Errorf("os.Open: %v", err)
+	}
+	defer f.Close()
+
+	return verify(f)
+}
+
+// Entries returns the entries of the log at path, after verifying it.
+func Entries(path string) ([]*tradingapi.AuditEntry, error) {
+
+	if _, _, err := Verify(path); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("os.Open: %v", err)
+	}
+	defer f.Close()
+
+	var res []*tradingapi.AuditEntry
+
+	err = scan(f, func(n int, ln *line) error {
+		var e tradingapi.AuditEntry
+		if err := json.Unmarshal(ln.Entry, &e); err != nil {
+			return &ErrBroken{n, "entry: " + err.Error()}
+		}
+		res = append(res, &e)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func verify(r io.Reader) (int64, string, error) {
+
+	seq, head := int64(0), genesis
+
+	err := scan(r, func(n int, ln *line) error {
+
+		switch {
+		case ln.Seq != seq+1:
+			return &ErrBroken{n, fmt.Sprintf("sequence %d after %d", ln.Seq, seq)}
+		case ln.Prev != head:
+			return &ErrBroken{n, "previous hash mismatch"}
+		case hash(ln.Seq, ln.Prev, ln.Entry) != ln.Hash:
+			return &ErrBroken{n, "hash mismatch"}
+		}
+
+		seq, head = ln.Seq, ln.Hash
+		return nil
+	})
+
+	if err != nil {
+		return 0, "", err
+	}
+
+	return seq, head, nil
+}
+
+// scan calls fn with every line of r.
+func scan(r io.Reader, fn func(n int, ln *line) error) error {
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024) // response bodies may be large
+
+	n := 0
+	for scanner.Scan() {
+
+		n++
+
+		var ln line
+		if err := json.Unmarshal(scanner.Bytes(), &ln); err != nil {
+			return &ErrBroken{n, err.Error()}
+		}
+
+		if err := fn(n, &ln); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("bufio.Scanner.Scan: %v", err)
+	}
+
+	return nil
+}
+
+func hash(seq int64, prev string, entry []byte) string {
+
+	h := sha256.New()
+	h.Write([]byte(strconv.FormatInt(seq, 10) + "\n" + prev + "\n"))
+	h.Write(entry)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/joemocquant/poloniex-api/audit"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+func main() {
+
+	client, err := tradingapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	auditLog, err := audit.Open("trading_audit.jsonl")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer auditLog.Close()
+
+	client.SetAuditSink(auditLog)
+
+	if _, err := client.GetBalances(); err != nil {
+		log.Fatal(err)
+	}
+
+	seq, head := auditLog.Head()
+	fmt.Printf("%d records, head %s\n", seq, head)
+
+	// verify("trading_audit.jsonl")
+}
+
+// Verify the chain of a log and print its entries
+func verify(path string) {
+
+	entries, err := audit.Entries(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, e := range entries {
+		fmt.Printf("%s %-8s %-24s %d %v %s\n", e.Time.Format("2006-01-02 15:04:05"),
+			e.Phase, e.Command, e.StatusCode, e.Latency, e.Error)
+	}
+}
@@ -0,0 +1,307 @@
+// Local candlestick aggregation.
+//
+// Poloniex only serves candlesticks for six fixed periods (see publicapi.GetChartData)
+// and only by polling. An Aggregator builds candlesticks of any period locally from the
+// trades pushed by pushapi.SubscribeMarket, emitting the candle in progress on every
+// trade (partial update) and once more when its period is over (closed candle).
+//
+// GetChartData serves historical candlesticks of any period (including calendar days,
+// weeks and months in a given time zone) by resampling the chart data of the largest
+// supported period fitting in it.
+package candles
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/pushapi"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:candles]")
+
+// Number of closed candles kept in memory by an aggregator
+const maxHistory = 10000
+
+type Candle struct {
+	CurrencyPair string
+	Period       time.Duration
+	Closed       bool // false while the candle period is still in progress
+	*publicapi.CandleStick
+}
+
+type Candles chan *Candle
+
+type Aggregator struct {
+	currencyPair string
+	period       int64 // seconds
+
+	mu          sync.Mutex
+	history     publicapi.ChartData // closed candles
+	current     *publicapi.CandleStick
+	lastTradeId int64
+
+	candles Candles
+}
+
+// NewAggregator returns an aggregator building candlesticks of the given period
+// (whole seconds, at least one second) for currencyPair.
+func NewAggregator(currencyPair string, period time.Duration) (*Aggregator, error) {
+
+	if period < time.Second || period%time.Second != 0 {
+		return nil, fmt.Errorf("Wrong period parameter: %s", period)
+	}
+
+	a := Aggregator{
+		currencyPair: currencyPair,
+		period:       int64(period / time.Second),
+		candles:      make(Candles, 100),
+	}
+
+	return &a, nil
+}
+
+// Candles returns the channel on which partial and closed candles are emitted.
+// It must be consumed while the aggregator is running.
+func (a *Aggregator) Candles() Candles {
+	return a.candles
+}
+
+// ChartData returns a copy of the closed candles followed by the candle in progress.
+func (a *Aggregator) ChartData() publicapi.ChartData {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	res := make(publicapi.ChartData, 0, len(a.history)+1)
+	for _, cs := range a.history {
+		c := *cs
+		res = append(res, &c)
+	}
+
+	if a.current != nil {
+		c := *a.current
+		res = append(res, &c)
+	}
+
+	return res
+}
+
+// Run consumes market updates until updater is unsubscribed (nil update received),
+// closing candles at the end of each period even when no trade happens.
+func (a *Aggregator) Run(updater pushapi.MarketUpdater) {
+
+	timer := time.NewTimer(a.untilNextBoundary(time.Now()))
+	defer timer.Stop()
+
+	for {
+		select {
+		case updates := <-updater:
+			if updates == nil {
+				return
+			}
+			a.applyMarketUpdates(updates)
+
+		case now := <-timer.C:
+			a.Flush(now)
+			timer.Reset(a.untilNextBoundary(time.Now()))
+		}
+	}
+}
+
+// AddTrade applies a single trade. Trades already applied (tradeId lower than or
+// equal to the last one seen) and trades older than the candle in progress are ignored.
+func (a *Aggregator) AddTrade(tradeId, date int64, rate, amount, total float64) {
+
+	a.mu.Lock()
+	closed, changed := a.addTrade(tradeId, date, rate, amount, total)
+	a.mu.Unlock()
+
+	a.emit(closed, true)
+
+	if changed {
+		a.emitCurrent()
+	}
+}
+
+// Flush closes every candle whose period ended before now.
+func (a *Aggregator) Flush(now time.Time) {
+
+	a.mu.Lock()
+	closed := a.closeUntil(a.bucket(now.Unix()))
+	a.mu.Unlock()
+
+	a.emit(closed, true)
+}
+
+func (a *Aggregator) applyMarketUpdates(updates *pushapi.MarketUpdates) {
+
+	var trades []*pushapi.NewTrade
+
+	for _, update := range updates.Updates {
+		if trade, ok := update.Data.(*pushapi.NewTrade); ok {
+			trades = append(trades, trade)
+		}
+	}
+
+	if len(trades) == 0 {
+		return
+	}
+
+	sort.Slice(trades, func(i, j int) bool {
+		return trades[i].TradeId < trades[j].TradeId
+	})
+
+	var closed publicapi.ChartData
+	changed := false
+
+	a.mu.Lock()
+	for _, t := range trades {
+		c, ok := a.addTrade(t.TradeId, t.Date, t.Rate, t.Amount, t.Total)
+		closed = append(closed, c...)
+		changed = changed || ok
+	}
+	a.mu.Unlock()
+
+	a.emit(closed, true)
+
+	if changed {
+		a.emitCurrent()
+	}
+}
+
+// addTrade returns the candles closed by the trade and whether it was applied.
+// It must be called with a.mu held.
+func (a *Aggregator) addTrade(tradeId, date int64, rate, amount, total float64) (publicapi.ChartData, bool) {
+
+	if tradeId != 0 && tradeId <= a.lastTradeId {
+		return nil, false
+	}
+
+	start := a.bucket(date)
+
+	if a.current != nil && start < a.current.Date {
+		logger.Debugf("%s: trade %d older than current candle, ignored",
+			a.currencyPair, tradeId)
+		return nil, false
+	}
+
+	closed := a.closeUntil(start)
+
+	if a.current == nil {
+		a.current = &publicapi.CandleStick{
+			Date:  start,
+			Open:  rate,
+			High:  rate,
+			Low:   rate,
+			Close: rate,
+		}
+	}
+
+	cs := a.current
+	if cs.Volume == 0 && cs.QuoteVolume == 0 {
+		// First trade of a gap filled candle
+		cs.Open, cs.High, cs.Low = rate, rate, rate
+	}
+
+	if rate > cs.High {
+		cs.High = rate
+	}
+	if rate < cs.Low {
+		cs.Low = rate
+	}
+	cs.Close = rate
+	cs.Volume += total
+	cs.QuoteVolume += amount
+
+	if cs.QuoteVolume != 0 {
+		cs.WeighedtAverage = cs.Volume / cs.QuoteVolume
+	}
+
+	if tradeId != 0 {
+		a.lastTradeId = tradeId
+	}
+
+	return closed, true
+}
+
+// closeUntil closes the candle in progress if it started before start, filling the
+// periods without trades with flat candles. It must be called with a.mu held.
+func (a *Aggregator) closeUntil(start int64) publicapi.ChartData {
+
+	if a.current == nil || a.current.Date >= start {
+		return nil
+	}
+
+	var closed publicapi.ChartData
+
+	for a.current.Date < start {
+
+		closed = append(closed, a.current)
+		a.history = append(a.history, a.current)
+		if len(a.history) > maxHistory {
+			a.history = a.history[len(a.history)-maxHistory:]
+		}
+
+		last := a.current.Close
+		a.current = &publicapi.CandleStick{
+			Date:            a.current.Date + a.period,
+			Open:            last,
+			High:            last,
+			Low:             last,
+			Close:           last,
+			WeighedtAverage: last,
+		}
+	}
+
+	return closed
+}
+
+func (a *Aggregator) emitCurrent() {
+
+	a.mu.Lock()
+	if a.current == nil {
+		a.mu.Unlock()
+		return
+	}
+	cs := *a.current
+	a.mu.Unlock()
+
+	a.emit(publicapi.ChartData{&cs}, false)
+}
+
+func (a *Aggregator) emit(data publicapi.ChartData, closed bool) {
+
+	for _, cs := range data {
+		c := *cs
+		a.candles <- &Candle{
+			CurrencyPair: a.currencyPair,
+			Period:       time.Duration(a.period) * time.Second,
+			Closed:       closed,
+			CandleStick:  &c,
+		}
+	}
+}
+
+// bucket returns the start (Unix timestamp) of the period containing date.
+func (a *Aggregator) bucket(date int64) int64 {
+	return date - mod(date, a.period)
+}
+
+func (a *Aggregator) untilNextBoundary(now time.Time) time.Duration {
+
+	next := time.Unix(a.bucket(now.Unix())+a.period, 0)
+	return next.Sub(now)
+}
+
+func mod(a, b int64) int64 {
+
+	m := a % b
+	if m < 0 {
+		m += b
+	}
+	return m
+}
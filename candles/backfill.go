@@ -0,0 +1,116 @@
+package candles
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+// Periods (in seconds) served by publicapi.GetChartData
+var chartDataPeriods = []int64{300, 900, 1800, 7200, 14400, 86400}
+
+// Time range requested per returnTradeHistory call (the API returns at most 50,000 trades)
+const tradeHistoryWindow = time.Hour
+
+// Backfill loads the candles from start until now before live trades are applied.
+// Closed candles come from GetChartData when the aggregator period is supported,
+// from GetTradeHistory otherwise. The candle in progress is always rebuilt from
+// trades so that trades pushed afterwards are not counted twice.
+func (a *Aggregator) Backfill(client *publicapi.Client, start time.Time) error {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.current != nil || len(a.history) > 0 {
+		return errors.New("backfill: aggregator already holds candles")
+	}
+
+	now := time.Now()
+	from := a.bucket(start.Unix())
+	currentStart := a.bucket(now.Unix())
+
+	tradesFrom := from
+
+	if from < currentStart && isChartDataPeriod(a.period) {
+
+		chartData, err := client.GetChartData(a.currencyPair, time.Unix(from, 0),
+			time.Unix(currentStart-1, 0), int(a.period))
+
+		if err != nil {
+			return fmt.Errorf("PublicClient.GetChartData: %v", err)
+		}
+
+		for _, cs := range chartData {
+
+			if cs.Date == 0 || cs.Date >= currentStart {
+				continue // empty range or candle in progress
+			}
+
+			if a.current != nil {
+				a.history = append(a.history, a.current)
+			}
+			c := *cs
+			a.current = &c
+		}
+
+		tradesFrom = currentStart
+	}
+
+	trades, err := fetchTrades(client, a.currencyPair, time.Unix(tradesFrom, 0), now)
+	if err != nil {
+		return fmt.Errorf("fetchTrades: %v", err)
+	}
+
+	for _, t := range trades {
+		a.addTrade(t.TradeId, t.Date, t.Rate, t.Amount, t.Total)
+	}
+
+	a.closeUntil(currentStart)
+
+	return nil
+}
+
+// fetchTrades returns the trades between start and end sorted by trade id.
+func fetchTrades(client *publicapi.Client, currencyPair string, start, end time.Time) (publicapi.TradeHistory, error) {
+
+	var res publicapi.TradeHistory
+
+	for from := start; from.Before(end); from = from.Add(tradeHistoryWindow) {
+
+		to := from.Add(tradeHistoryWindow)
+		if to.After(end) {
+			to = end
+		}
+
+		trades, err := client.GetTradeHistory(currencyPair, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("PublicClient.GetTradeHistory: %v", err)
+		}
+
+		if len(trades) >= 50000 {
+			logger.Warnf("%s: trade history truncated between %s and %s",
+				currencyPair, from, to)
+		}
+
+		res = append(res, trades...)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].TradeId < res[j].TradeId
+	})
+
+	return res, nil
+}
+
+func isChartDataPeriod(period int64) bool {
+
+	for _, p := range chartDataPeriods {
+		if p == period {
+			return true
+		}
+	}
+	return false
+}
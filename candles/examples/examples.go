@@ -0,0 +1,94 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	poloniex "github.com/joemocquant/poloniex-api"
+	"github.com/joemocquant/poloniex-api/candles"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/pushapi"
+)
+
+var (
+	publicClient *publicapi.Client
+	pushClient   *pushapi.Client
+)
+
+func main() {
+
+	var err error
+	publicClient = publicapi.NewClient()
+	pushClient, err = pushapi.NewClient()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	printLiveCandles()
+
+	// printWeeklyChartData()
+
+	// printMonthlyChartData()
+}
+
+// Print BTC_ETH 1min candlesticks (backfilled the last hour) as they are built
+func printLiveCandles() {
+
+	aggregator, err := candles.NewAggregator("BTC_ETH", time.Minute)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := aggregator.Backfill(publicClient, time.Now().Add(-time.Hour)); err != nil {
+		log.Fatal(err)
+	}
+
+	poloniex.PrettyPrintJson(aggregator.ChartData())
+
+	marketUpdater, err := pushClient.SubscribeMarket("BTC_ETH")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go aggregator.Run(marketUpdater)
+
+	for candle := range aggregator.Candles() {
+		poloniex.PrettyPrintJson(candle)
+	}
+}
+
+// Print BTC_ETH weekly candlesticks (weeks starting on Monday, Paris time) the last 10 weeks
+func printWeeklyChartData() {
+
+	loc, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	end := time.Now()
+	start := end.AddDate(0, 0, -70)
+	res, err := candles.GetChartData(publicClient, "BTC_ETH", start, end,
+		candles.Weeks(1, loc, time.Monday))
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	poloniex.PrettyPrintJson(res)
+}
+
+// Print BTC_ETH monthly candlesticks (UTC) the last year
+func printMonthlyChartData() {
+
+	end := time.Now()
+	start := end.AddDate(-1, 0, 0)
+	res, err := candles.GetChartData(publicClient, "BTC_ETH", start, end,
+		candles.Months(1, time.UTC))
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	poloniex.PrettyPrintJson(res)
+}
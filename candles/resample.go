@@ -0,0 +1,270 @@
+package candles
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+type Unit int
+
+const (
+	Fixed Unit = iota // fixed length periods, aligned on the Unix epoch
+	Day               // calendar days
+	Week              // calendar weeks
+	Month             // calendar months
+)
+
+// Period describes the candlestick period of a resampled chart. Day, Week and Month
+// periods are aligned on midnight in Location (UTC when nil), so their length in
+// seconds varies with daylight saving time and month length.
+type Period struct {
+	Unit      Unit
+	Length    time.Duration // Fixed only
+	Count     int           // number of days, weeks or months
+	Location  *time.Location
+	WeekStart time.Weekday // Week only
+}
+
+// Every returns a fixed length period (e.g. time.Hour, 12 * time.Hour).
+func Every(length time.Duration) Period {
+	return Period{Unit: Fixed, Length: length}
+}
+
+// Days returns a period of count calendar days starting at midnight in loc.
+func Days(count int, loc *time.Location) Period {
+	return Period{Unit: Day, Count: count, Location: loc}
+}
+
+// Weeks returns a period of count calendar weeks starting on weekStart at midnight in loc.
+func Weeks(count int, loc *time.Location, weekStart time.Weekday) Period {
+	return Period{Unit: Week, Count: count, Location: loc, WeekStart: weekStart}
+}
+
+// Months returns a period of count calendar months starting on the first day of
+// the month at midnight in loc.
+func Months(count int, loc *time.Location) Period {
+	return Period{Unit: Month, Count: count, Location: loc}
+}
+
+func (p Period) String() string {
+
+	switch p.Unit {
+	case Fixed:
+		return p.Length.String()
+	case Day:
+		return fmt.Sprintf("%dd (%s)", p.Count, p.location())
+	case Week:
+		return fmt.Sprintf("%dw from %s (%s)", p.Count, p.WeekStart, p.location())
+	case Month:
+		return fmt.Sprintf("%dM (%s)", p.Count, p.location())
+	default:
+		return fmt.Sprintf("unknown unit %d", p.Unit)
+	}
+}
+
+func (p Period) validate() error {
+
+	switch p.Unit {
+	case Fixed:
+		if p.Length < time.Second || p.Length%time.Second != 0 {
+			return fmt.Errorf("Wrong period length: %s", p.Length)
+		}
+	case Day, Week, Month:
+		if p.Count < 1 {
+			return fmt.Errorf("Wrong period count: %d", p.Count)
+		}
+	default:
+		return fmt.Errorf("Wrong period unit: %d", p.Unit)
+	}
+	return nil
+}
+
+// Start returns the start of the period containing t.
+func (p Period) Start(t time.Time) time.Time {
+
+	loc := p.location()
+
+	switch p.Unit {
+	case Day:
+		day := civilDay(t.In(loc))
+		return dayStart(day-mod(day, int64(p.Count)), loc)
+
+	case Week:
+		// 1970-01-01 was a Thursday
+		first := mod(int64(p.WeekStart)-int64(time.Thursday), 7)
+		day := civilDay(t.In(loc))
+		return dayStart(day-mod(day-first, 7*int64(p.Count)), loc)
+
+	case Month:
+		t = t.In(loc)
+		month := int64(t.Year())*12 + int64(t.Month()) - 1
+		month -= mod(month, int64(p.Count))
+		return time.Date(int(month/12), time.Month(month%12+1), 1, 0, 0, 0, 0, loc)
+
+	default:
+		length := int64(p.Length / time.Second)
+		date := t.Unix()
+		return time.Unix(date-mod(date, length), 0)
+	}
+}
+
+// Next returns the start of the period following the one starting at start.
+func (p Period) Next(start time.Time) time.Time {
+
+	loc := p.location()
+	start = start.In(loc)
+
+	switch p.Unit {
+	case Day:
+		return time.Date(start.Year(), start.Month(), start.Day()+p.Count, 0, 0, 0, 0, loc)
+	case Week:
+		return time.Date(start.Year(), start.Month(), start.Day()+7*p.Count, 0, 0, 0, 0, loc)
+	case Month:
+		return time.Date(start.Year(), start.Month()+time.Month(p.Count), 1, 0, 0, 0, 0, loc)
+	default:
+		return start.Add(p.Length)
+	}
+}
+
+func (p Period) location() *time.Location {
+
+	if p.Location == nil {
+		return time.UTC
+	}
+	return p.Location
+}
+
+// BasePeriod returns the largest period supported by publicapi.GetChartData whose
+// candles fit exactly in every period between start and end.
+func BasePeriod(p Period, start, end time.Time) (int, error) {
+
+	if err := p.validate(); err != nil {
+		return 0, err
+	}
+
+	for i := len(chartDataPeriods) - 1; i >= 0; i-- {
+
+		base := chartDataPeriods[i]
+
+		if p.Unit == Fixed {
+			if int64(p.Length/time.Second)%base == 0 {
+				return int(base), nil
+			}
+			continue
+		}
+
+		aligned := true
+		for t := p.Start(start); !t.After(end); t = p.Next(t) {
+			if mod(t.Unix(), base) != 0 {
+				aligned = false
+				break
+			}
+		}
+
+		if aligned {
+			return int(base), nil
+		}
+	}
+
+	return 0, fmt.Errorf("no chart data period fits in %s", p)
+}
+
+// Resample aggregates candlesticks into candlesticks of period p. Each resulting
+// candle opens at the first open and closes at the last close of the candles it
+// groups; high and low are the extremes, volumes are summed and the weighted
+// average is recomputed from the summed volumes.
+func Resample(data publicapi.ChartData, p Period) (publicapi.ChartData, error) {
+
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	sorted := make(publicapi.ChartData, 0, len(data))
+	for _, cs := range data {
+		if cs != nil && cs.Date != 0 {
+			sorted = append(sorted, cs)
+		}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date < sorted[j].Date
+	})
+
+	var res publicapi.ChartData
+	var current *publicapi.CandleStick
+
+	for _, cs := range sorted {
+
+		start := p.Start(time.Unix(cs.Date, 0)).Unix()
+
+		if current == nil || current.Date != start {
+
+			current = &publicapi.CandleStick{
+				Date: start,
+				High: cs.High,
+				Low:  cs.Low,
+				Open: cs.Open,
+			}
+			res = append(res, current)
+		}
+
+		if cs.High > current.High {
+			current.High = cs.High
+		}
+		if cs.Low < current.Low {
+			current.Low = cs.Low
+		}
+		current.Close = cs.Close
+		current.Volume += cs.Volume
+		current.QuoteVolume += cs.QuoteVolume
+
+		if current.QuoteVolume != 0 {
+			current.WeighedtAverage = current.Volume / current.QuoteVolume
+		} else {
+			current.WeighedtAverage = current.Close
+		}
+	}
+
+	return res, nil
+}
+
+// GetChartData returns the candlesticks of period p between start and end, fetching
+// the chart data of the base period (see BasePeriod) and resampling it.
+func GetChartData(client *publicapi.Client, currencyPair string, start, end time.Time, p Period) (publicapi.ChartData, error) {
+
+	if end.Before(start) {
+		return nil, errors.New("GetChartData: end before start")
+	}
+
+	base, err := BasePeriod(p, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("BasePeriod: %v", err)
+	}
+
+	data, err := client.GetChartData(currencyPair, p.Start(start), end, base)
+	if err != nil {
+		return nil, fmt.Errorf("PublicClient.GetChartData: %v", err)
+	}
+
+	res, err := Resample(data, p)
+	if err != nil {
+		return nil, fmt.Errorf("Resample: %v", err)
+	}
+
+	return res, nil
+}
+
+// civilDay returns the number of days between 1970-01-01 and the date of t.
+func civilDay(t time.Time) int64 {
+
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return date.Unix() / 86400
+}
+
+func dayStart(day int64, loc *time.Location) time.Time {
+	return time.Date(1970, time.January, 1+int(day), 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joemocquant/poloniex-api/credentials"
+	"github.com/joemocquant/poloniex-api/guard"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+// Configuration file (~/.poloniex/config.json by default, or $POLONIEX_CONFIG):
+//
+//	{
+//	  "default_profile": "main",
+//	  "profiles": {
+//	    "main": {
+//	      "api_key": "...",
+//	      "api_secret": "...",
+//	      "output": "table"
+//	    },
+//	    "readonly": { ... },
+//	    "secure": {
+//	      "keystore": "~/.poloniex/main.keystore"
+//	    },
+//	    "external": {
+//	      "credentials_command": ["pass", "show", "poloniex"],
+//	      "withdrawals": {
+//	        "whitelist": {"BTC": [{"address": "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"}]},
+//	        "max_amount": {"BTC": 0.5},
+//	        "max_daily": {"BTC": 1},
+//	        "audit": "~/.poloniex/withdrawals.log"
+//	      }
+//	    }
+//	  }
+//	}
+//
+// The profile is chosen with -profile, then $POLONIEX_PROFILE, then default_profile.
+// $POLONIEX_API_KEY and $POLONIEX_API_SECRET override the profile credentials.
+//
+// Rather than api_key and api_secret in cleartext, a profile may give an encrypted
+// keystore (see the keystore command), whose passphrase is read from
+// $POLONIEX_PASSPHRASE or prompted, or a command printing the credentials (see
+// credentials.Command).
+//
+// The withdraw command checks withdrawals against the withdrawals policy of the
+// profile (see package guard): only whitelisted destinations are allowed, and every
+// attempt is appended to the audit file (~/.poloniex/withdrawals.log by default).
+type configuration struct {
+	DefaultProfile string              `json:"default_profile"`
+	Profiles       map[string]*profile `json:"profiles"`
+}
+
+type profile struct {
+	ApiKey    string `json:"api_key"`
+	ApiSecret string `json:"api_secret"`
+	Output    string `json:"output"`
+
+	Keystore           string   `json:"keystore"`
+	CredentialsCommand []string `json:"credentials_command"`
+
+	Withdrawals withdrawalPolicy `json:"withdrawals"`
+}
+
+type withdrawalPolicy struct {
+	Whitelist     map[string][]destination `json:"whitelist"`
+	MaxAmount     map[string]float64       `json:"max_amount"`
+	MaxDaily      map[string]float64       `json:"max_daily"`
+	StrictFormats bool                     `json:"strict_formats"`
+	Audit         string                   `json:"audit"`
+}
+
+type destination struct {
+	Address   string `json:"address"`
+	PaymentId string `json:"payment_id"` // any payment id when empty
+}
+
+func (ctx *context) loadProfile() (*profile, error) {
+
+	path := ctx.configPath
+	if path == "" {
+		path = os.Getenv("POLONIEX_CONFIG")
+	}
+	if path == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, ".poloniex", "config.json")
+		}
+	}
+
+	conf := configuration{}
+
+	content, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(content, &conf); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal %s: %v", path, err)
+		}
+	case os.IsNotExist(err) && ctx.configPath == "":
+		// No configuration file, environment only
+	default:
+		return nil, fmt.Errorf("ioutil.ReadFile: %v", err)
+	}
+
+	name := ctx.profileName
+	if name == "" {
+		name = os.Getenv("POLONIEX_PROFILE")
+	}
+	if name == "" {
+		name = conf.DefaultProfile
+	}
+
+	res := profile{}
+
+	if name != "" {
+		p, ok := conf.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("profile %q not found in %s", name, path)
+		}
+		res = *p
+	}
+
+	if v := os.Getenv("POLONIEX_API_KEY"); v != "" {
+		res.ApiKey = v
+	}
+	if v := os.Getenv("POLONIEX_API_SECRET"); v != "" {
+		res.ApiSecret = v
+	}
+
+	return &res, nil
+}
+
+// outputFormat returns the output format from -o, then from the profile.
+func (ctx *context) outputFormat() (string, error) {
+
+	format := ctx.output
+
+	if format == "" {
+		if p, err := ctx.loadProfile(); err == nil {
+			format = p.Output
+		}
+	}
+
+	switch format {
+	case "":
+		return "table", nil
+	case "table", "json", "csv":
+		return format, nil
+	default:
+		return "", fmt.Errorf("wrong output format: %s", format)
+	}
+}
+
+func (ctx *context) tradingClient() (*tradingapi.Client, error) {
+
+	p, err := ctx.loadProfile()
+	if err != nil {
+		return nil, err
+	}
+
+	var provider tradingapi.CredentialProvider
+
+	switch {
+	case os.Getenv("POLONIEX_API_KEY") != "" && os.Getenv("POLONIEX_API_SECRET") != "":
+		provider = &credentials.Env{}
+
+	case len(p.CredentialsCommand) > 0:
+		provider = &credentials.Command{Name: p.CredentialsCommand[0], Args: p.CredentialsCommand[1:]}
+
+	case p.Keystore != "":
+		provider = &credentials.Keystore{Path: expandHome(p.Keystore), Passphrase: passphrase}
+
+	default:
+		client, err := tradingapi.NewClientWithCredentials(p.ApiKey, p.ApiSecret)
+		if err != nil {
+			return nil, fmt.Errorf("tradingapi.NewClientWithCredentials: %v", err)
+		}
+		return client, nil
+	}
+
+	client, err := tradingapi.NewClientWithProvider(provider)
+	if err != nil {
+		return nil, fmt.Errorf("tradingapi.NewClientWithProvider: %v", err)
+	}
+
+	return client, nil
+}
+
+// withdrawalClient returns a trading client checking withdrawals against the policy of
+// the profile, asking for confirmation once the checks passed, and its audit to be
+// closed.
+func (ctx *context) withdrawalClient(confirm func(r *guard.Request) error) (*guard.Client, *guard.JSONAudit, error) {
+
+	p, err := ctx.loadProfile()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := ctx.tradingClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Currencies are upper case in the policy, as in the API
+	maxAmount, err := upperKeys(p.Withdrawals.MaxAmount, "max_amount")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	maxDaily, err := upperKeys(p.Withdrawals.MaxDaily, "max_daily")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	policy := guard.Policy{
+		Whitelist:     make(map[string][]guard.Destination),
+		MaxAmount:     maxAmount,
+		MaxDaily:      maxDaily,
+		StrictFormats: p.Withdrawals.StrictFormats,
+		Approver:      confirm,
+	}
+
+	for currency, destinations := range p.Withdrawals.Whitelist {
+		currency = strings.ToUpper(currency)
+		for _, d := range destinations {
+			policy.Whitelist[currency] = append(policy.Whitelist[currency],
+				guard.Destination{Address: d.Address, PaymentId: d.PaymentId})
+		}
+	}
+
+	path := expandHome(p.Withdrawals.Audit)
+	if path == "" {
+		path = expandHome("~/.poloniex/withdrawals.log")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, nil, fmt.Errorf("os.MkdirAll: %v", err)
+	}
+
+	audit, err := guard.NewFileAudit(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("guard.NewFileAudit: %v", err)
+	}
+
+	guarded, err := guard.NewClient(client, policy, audit)
+	if err != nil {
+		audit.Close()
+		return nil, nil, fmt.Errorf("guard.NewClient: %v", err)
+	}
+
+	return guarded, audit, nil
+}
+
+// passphrase returns the keystore passphrase from $POLONIEX_PASSPHRASE, or prompts
+// for it.
+func passphrase() ([]byte, error) {
+
+	if os.Getenv("POLONIEX_PASSPHRASE") != "" {
+		return credentials.EnvPassphrase("POLONIEX_PASSPHRASE")()
+	}
+
+	return credentials.TerminalPassphrase("Keystore passphrase: ")()
+}
+
+// expandHome replaces a leading ~ of path with the home directory.
+func expandHome(path string) string {
+
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+
+	return path
+}
+
+// upperKeys returns caps with upper case currencies. A currency given twice (e.g. btc
+// and BTC) is an error rather than one of the caps being ignored.
+func upperKeys(caps map[string]float64, name string) (map[string]float64, error) {
+
+	res := make(map[string]float64, len(caps))
+
+	for currency, v := range caps {
+		currency = strings.ToUpper(currency)
+		if _, ok := res[currency]; ok {
+			return nil, fmt.Errorf("wrong withdrawals %s: %s given twice", name, currency)
+		}
+		res[currency] = v
+	}
+
+	return res, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/joemocquant/poloniex-api/credentials"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+// runKeystore encrypts the API credentials from $POLONIEX_API_KEY and
+// $POLONIEX_API_SECRET, or prompted, to a keystore file.
+func runKeystore(ctx *context, args []string) error {
+
+	force := ctx.flags.Bool("force", false, "replace an existing keystore")
+
+	positional, err := ctx.parse(args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	path := expandHome(positional[0])
+
+	if _, err := os.Stat(path); err == nil && !*force {
+		return fmt.Errorf("%s exists (use -force to replace it)", path)
+	}
+
+	c, err := (&credentials.Env{}).Credentials()
+	if err != nil {
+
+		c = &tradingapi.Credentials{}
+
+		fmt.Fprint(os.Stderr, "API key: ")
+		key, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("reading API key: %v", err)
+		}
+		c.ApiKey = strings.TrimSpace(key)
+
+		secret, err := credentials.TerminalPassphrase("API secret: ")()
+		if err != nil {
+			return err
+		}
+		c.ApiSecret = strings.TrimSpace(string(secret))
+	}
+
+	pass, err := passphrase()
+	if err != nil {
+		return err
+	}
+
+	if os.Getenv("POLONIEX_PASSPHRASE") == "" {
+
+		confirm, err := credentials.TerminalPassphrase("Confirm passphrase: ")()
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(pass, confirm) {
+			return fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	if err := credentials.WriteKeystore(path, c, pass); err != nil {
+		return fmt.Errorf("credentials.WriteKeystore: %v", err)
+	}
+
+	fmt.Printf("keystore written to %s\n", path)
+
+	return nil
+}
@@ -0,0 +1,173 @@
+// Command poloniex is a command line client of the public, trading and push APIs.
+//
+// Usage:
+//
+//	poloniex <command> [arguments] [flags]
+//
+// Public commands:
+//
+//	ticker [PAIR...]                       tickers (all markets by default)
+//	book PAIR [-depth 20]                  order book
+//	candles PAIR [-period 1h] [-since 24h] [-tz UTC]
+//	                                       candlesticks of any period (5m, 1h, 1d, 1w, 1M...)
+//
+// Trading commands (API credentials required, see config.go):
+//
+//	balances [-all]                        complete balances (non-zero only by default)
+//	orders [PAIR]                          open orders (all markets by default)
+//	buy PAIR RATE AMOUNT [-post-only|-ioc|-fok]
+//	sell PAIR RATE AMOUNT [-post-only|-ioc|-fok]
+//	cancel ORDER_NUMBER
+//	move ORDER_NUMBER RATE [-amount AMOUNT] [-post-only|-ioc]
+//	withdraw CURRENCY AMOUNT ADDRESS [-payment-id ID]
+//
+// Credentials:
+//
+//	keystore PATH                          encrypt API credentials to a keystore file
+//
+// Push commands (until interrupted):
+//
+//	stream ticker|market PAIR|trollbox
+//
+// Common flags:
+//
+//	-o table|json|csv   output format (default from the profile, table otherwise)
+//	-profile NAME       configuration profile
+//	-config PATH        configuration file (default ~/.poloniex/config.json)
+//
+// Commands placing, moving or cancelling orders and withdrawals ask for a
+// confirmation unless -yes is given, and only print what they would do with -dry-run.
+// Withdrawals are checked against the withdrawals policy of the profile first.
+// Buy and sell orders are validated against the market rules unless -no-validate is given.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+type command struct {
+	run   func(ctx *context, args []string) error
+	usage string
+}
+
+var commands = map[string]*command{
+	"ticker":   {runTicker, "ticker [PAIR...]"},
+	"book":     {runBook, "book PAIR [-depth 20]"},
+	"candles":  {runCandles, "candles PAIR [-period 1h] [-since 24h] [-tz UTC]"},
+	"balances": {runBalances, "balances [-all]"},
+	"orders":   {runOrders, "orders [PAIR]"},
+	"buy":      {runBuy, "buy PAIR RATE AMOUNT [-post-only|-ioc|-fok]"},
+	"sell":     {runSell, "sell PAIR RATE AMOUNT [-post-only|-ioc|-fok]"},
+	"cancel":   {runCancel, "cancel ORDER_NUMBER"},
+	"move":     {runMove, "move ORDER_NUMBER RATE [-amount AMOUNT] [-post-only|-ioc]"},
+	"withdraw": {runWithdraw, "withdraw CURRENCY AMOUNT ADDRESS [-payment-id ID]"},
+	"stream":   {runStream, "stream ticker|market PAIR|trollbox"},
+	"keystore": {runKeystore, "keystore PATH"},
+}
+
+func main() {
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "poloniex: unknown command %q\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	ctx := newContext(name, cmd.usage)
+
+	if err := cmd.run(ctx, os.Args[2:]); err == flag.ErrHelp {
+		os.Exit(2)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "poloniex %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+
+	fmt.Fprintln(os.Stderr, "Usage: poloniex <command> [arguments] [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+
+	fmt.Fprintln(os.Stderr, "\nCommon flags: -o table|json|csv, -profile NAME, -config PATH")
+}
+
+// context holds the flags common to every command.
+type context struct {
+	flags *flag.FlagSet
+
+	output      string
+	profileName string
+	configPath  string
+	yes         bool
+	dryRun      bool
+}
+
+func newContext(name, usage string) *context {
+
+	ctx := context{flags: flag.NewFlagSet(name, flag.ContinueOnError)}
+
+	ctx.flags.StringVar(&ctx.output, "o", "", "output format: table, json or csv")
+	ctx.flags.StringVar(&ctx.profileName, "profile", "", "configuration profile")
+	ctx.flags.StringVar(&ctx.configPath, "config", "", "configuration file")
+	ctx.flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: poloniex %s\n", usage)
+		ctx.flags.PrintDefaults()
+	}
+
+	return &ctx
+}
+
+// moneyFlags adds the -yes and -dry-run flags of the commands moving funds.
+func (ctx *context) moneyFlags() {
+
+	ctx.flags.BoolVar(&ctx.yes, "yes", false, "do not ask for confirmation")
+	ctx.flags.BoolVar(&ctx.dryRun, "dry-run", false, "print what would be done and exit")
+}
+
+// parse parses flags placed anywhere among the arguments and checks the number of
+// positional arguments.
+func (ctx *context) parse(args []string, minArgs, maxArgs int) ([]string, error) {
+
+	var positional []string
+
+	for {
+		if err := ctx.flags.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = ctx.flags.Args()
+		if len(args) == 0 {
+			break
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if len(positional) < minArgs || (maxArgs >= 0 && len(positional) > maxArgs) {
+		ctx.flags.Usage()
+		return nil, fmt.Errorf("wrong number of arguments")
+	}
+
+	return positional, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// table is the tabular form of a command result, printed as an aligned table or
+// CSV. The JSON output prints the API result itself.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (t *table) add(values ...interface{}) {
+
+	row := make([]string, len(values))
+	for i, v := range values {
+		row[i] = format(v)
+	}
+	t.rows = append(t.rows, row)
+}
+
+func (ctx *context) print(result interface{}, t *table) error {
+
+	format, err := ctx.outputFormat()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write(t.header)
+		w.WriteAll(t.rows)
+		return w.Error()
+
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(t.header, "\t")))
+		for _, row := range t.rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	}
+}
+
+func format(v interface{}) string {
+
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// confirm asks the user to confirm an action, unless -yes was given. Messages go to
+// stderr so that the output stays parsable.
+func (ctx *context) confirm(action string) (bool, error) {
+
+	if ctx.dryRun {
+		fmt.Fprintf(os.Stderr, "dry run: %s\n", action)
+		return false, nil
+	}
+
+	if ctx.yes {
+		return true, nil
+	}
+
+	fmt.Fprintf(os.Stderr, "%s? [y/N] ", action)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("reading confirmation: %v", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		fmt.Fprintln(os.Stderr, "aborted")
+		return false, nil
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/candles"
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+func runTicker(ctx *context, args []string) error {
+
+	pairs, err := ctx.parse(args, 0, -1)
+	if err != nil {
+		return err
+	}
+
+	ticks, err := publicapi.NewClient().GetTickers()
+	if err != nil {
+		return fmt.Errorf("PublicClient.GetTickers: %v", err)
+	}
+
+	if len(pairs) > 0 {
+		selected := make(publicapi.Ticks, len(pairs))
+		for _, pair := range pairs {
+			pair = strings.ToUpper(pair)
+			tick, ok := ticks[pair]
+			if !ok {
+				return fmt.Errorf("unknown currency pair: %s", pair)
+			}
+			selected[pair] = tick
+		}
+		ticks = selected
+	}
+
+	t := table{header: []string{"pair", "last", "lowestAsk", "highestBid",
+		"percentChange", "baseVolume", "quoteVolume", "high24hr", "low24hr", "isFrozen"}}
+
+	for _, pair := range sortedPairs(ticks) {
+		tick := ticks[pair]
+		t.add(pair, tick.Last, tick.LowestAsk, tick.HighestBid, tick.PercentChange,
+			tick.BaseVolume, tick.QuoteVolume, tick.High24hr, tick.Low24hr, tick.IsFrozen)
+	}
+
+	return ctx.print(ticks, &t)
+}
+
+func runBook(ctx *context, args []string) error {
+
+	depth := ctx.flags.Int("depth", 20, "order book depth")
+
+	positional, err := ctx.parse(args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	pair := strings.ToUpper(positional[0])
+
+	book, err := publicapi.NewClient().GetOrderBook(pair, *depth)
+	if err != nil {
+		return fmt.Errorf("PublicClient.GetOrderBook: %v", err)
+	}
+
+	t := table{header: []string{"side", "rate", "quantity", "total"}}
+
+	for i := len(book.Asks) - 1; i >= 0; i-- {
+		o := book.Asks[i]
+		t.add("ask", o.Rate, o.Quantity, o.Rate*o.Quantity)
+	}
+	for _, o := range book.Bids {
+		t.add("bid", o.Rate, o.Quantity, o.Rate*o.Quantity)
+	}
+
+	return ctx.print(book, &t)
+}
+
+func runCandles(ctx *context, args []string) error {
+
+	period := ctx.flags.String("period", "1h", "candlestick period: 5m, 4h, 1d, 1w, 1M...")
+	since := ctx.flags.Duration("since", 24*time.Hour, "history length")
+	tz := ctx.flags.String("tz", "UTC", "time zone of day, week and month periods")
+
+	positional, err := ctx.parse(args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		return fmt.Errorf("time.LoadLocation: %v", err)
+	}
+
+	p, err := parsePeriod(*period, loc)
+	if err != nil {
+		return err
+	}
+
+	pair := strings.ToUpper(positional[0])
+	end := time.Now()
+
+	data, err := candles.GetChartData(publicapi.NewClient(), pair, end.Add(-*since), end, p)
+	if err != nil {
+		return fmt.Errorf("candles.GetChartData: %v", err)
+	}
+
+	t := table{header: []string{"date", "open", "high", "low", "close",
+		"volume", "quoteVolume", "weightedAverage"}}
+
+	for _, cs := range data {
+		t.add(time.Unix(cs.Date, 0).In(loc), cs.Open, cs.High, cs.Low, cs.Close,
+			cs.Volume, cs.QuoteVolume, cs.WeighedtAverage)
+	}
+
+	return ctx.print(data, &t)
+}
+
+// parsePeriod parses a time.Duration (e.g. 15m, 4h) or a number of calendar
+// days, weeks (starting on Monday) or months (e.g. 1d, 2w, 1M).
+func parsePeriod(s string, loc *time.Location) (candles.Period, error) {
+
+	if n := len(s) - 1; n > 0 {
+		if count, err := strconv.Atoi(s[:n]); err == nil {
+			switch s[n] {
+			case 'd':
+				return candles.Days(count, loc), nil
+			case 'w':
+				return candles.Weeks(count, loc, time.Monday), nil
+			case 'M':
+				return candles.Months(count, loc), nil
+			}
+		}
+	}
+
+	length, err := time.ParseDuration(s)
+	if err != nil {
+		return candles.Period{}, fmt.Errorf("wrong period: %s", s)
+	}
+
+	return candles.Every(length), nil
+}
+
+func sortedPairs(ticks publicapi.Ticks) []string {
+
+	pairs := make([]string, 0, len(ticks))
+	for pair := range ticks {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+
+	return pairs
+}
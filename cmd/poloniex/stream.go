@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/pushapi"
+)
+
+// runStream prints push API messages until interrupted. Table output prints one
+// line per message, JSON output one JSON object per line.
+func runStream(ctx *context, args []string) error {
+
+	positional, err := ctx.parse(args, 1, 2)
+	if err != nil {
+		return err
+	}
+
+	format, err := ctx.outputFormat()
+	if err != nil {
+		return err
+	}
+
+	client, err := pushapi.NewClient()
+	if err != nil {
+		return fmt.Errorf("pushapi.NewClient: %v", err)
+	}
+	defer client.Close()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	out := newStreamWriter(format)
+
+	switch topic := positional[0]; topic {
+	case "ticker":
+		ticker, err := client.SubscribeTicker()
+		if err != nil {
+			return fmt.Errorf("PushClient.SubscribeTicker: %v", err)
+		}
+		defer unsubscribe(client.UnsubscribeTicker, func(stop <-chan struct{}) {
+			for {
+				select {
+				case <-ticker:
+				case <-stop:
+					return
+				}
+			}
+		})
+
+		out.header("pair", "last", "lowestAsk", "highestBid", "percentChange",
+			"baseVolume", "quoteVolume", "high24hr", "low24hr", "isFrozen")
+
+		for {
+			select {
+			case tick := <-ticker:
+				if tick == nil {
+					return nil
+				}
+				out.write(tick, tick.CurrencyPair, tick.Last, tick.LowestAsk, tick.HighestBid,
+					tick.PercentChange, tick.BaseVolume, tick.QuoteVolume, tick.High24hr,
+					tick.Low24hr, tick.IsFrozen)
+			case <-interrupt:
+				signal.Stop(interrupt) // a second interrupt kills the process
+				return nil
+			}
+		}
+
+	case "market":
+		if len(positional) != 2 {
+			ctx.flags.Usage()
+			return fmt.Errorf("missing currency pair")
+		}
+		pair := strings.ToUpper(positional[1])
+
+		updater, err := client.SubscribeMarket(pair)
+		if err != nil {
+			return fmt.Errorf("PushClient.SubscribeMarket: %v", err)
+		}
+		defer unsubscribe(func() error { return client.UnsubscribeMarket(pair) },
+			func(stop <-chan struct{}) {
+				for {
+					select {
+					case <-updater:
+					case <-stop:
+						return
+					}
+				}
+			})
+
+		out.header("seq", "update", "type", "rate", "amount", "total", "tradeId", "date")
+
+		for {
+			select {
+			case updates := <-updater:
+				if updates == nil {
+					return nil
+				}
+				for _, u := range updates.Updates {
+					switch d := u.Data.(type) {
+					case *pushapi.OrderBookModify:
+						out.write(u, updates.Sequence, u.TypeUpdate, d.TypeOrder, d.Rate,
+							d.Amount, "", "", "")
+					case *pushapi.OrderBookRemove:
+						out.write(u, updates.Sequence, u.TypeUpdate, d.TypeOrder, d.Rate,
+							"", "", "", "")
+					case *pushapi.NewTrade:
+						out.write(u, updates.Sequence, u.TypeUpdate, d.TypeOrder, d.Rate,
+							d.Amount, d.Total, d.TradeId, time.Unix(d.Date, 0))
+					}
+				}
+			case <-interrupt:
+				signal.Stop(interrupt) // a second interrupt kills the process
+				return nil
+			}
+		}
+
+	case "trollbox":
+		trollbox, err := client.SubscribeTrollbox()
+		if err != nil {
+			return fmt.Errorf("PushClient.SubscribeTrollbox: %v", err)
+		}
+		defer unsubscribe(client.UnsubscribeTrollbox, func(stop <-chan struct{}) {
+			for {
+				select {
+				case <-trollbox:
+				case <-stop:
+					return
+				}
+			}
+		})
+
+		out.header("messageNumber", "username", "reputation", "message")
+
+		for {
+			select {
+			case msg := <-trollbox:
+				if msg == nil {
+					return nil
+				}
+				out.write(msg, msg.MessageNumber, msg.Username, msg.Reputation, msg.Message)
+			case <-interrupt:
+				signal.Stop(interrupt) // a second interrupt kills the process
+				return nil
+			}
+		}
+
+	default:
+		ctx.flags.Usage()
+		return fmt.Errorf("unknown topic: %s", topic)
+	}
+}
+
+// unsubscribe calls fn while drain reads the subscription channel until stop is
+// closed: the push client sends a nil message when unsubscribing, which blocks
+// with no reader.
+func unsubscribe(fn func() error, drain func(stop <-chan struct{})) {
+
+	stop := make(chan struct{})
+	go drain(stop)
+
+	if err := fn(); err != nil {
+		fmt.Fprintf(os.Stderr, "unsubscribe: %v\n", err)
+	}
+
+	close(stop)
+}
+
+type streamWriter struct {
+	format string
+	enc    *json.Encoder
+	csv    *csv.Writer
+}
+
+func newStreamWriter(format string) *streamWriter {
+
+	return &streamWriter{
+		format: format,
+		enc:    json.NewEncoder(os.Stdout),
+		csv:    csv.NewWriter(os.Stdout),
+	}
+}
+
+func (w *streamWriter) header(columns ...string) {
+
+	switch w.format {
+	case "json":
+	case "csv":
+		w.csvLine(columns)
+	default:
+		fmt.Println(strings.ToUpper(strings.Join(columns, " ")))
+	}
+}
+
+func (w *streamWriter) write(msg interface{}, values ...interface{}) {
+
+	row := make([]string, len(values))
+	for i, v := range values {
+		row[i] = format(v)
+	}
+
+	switch w.format {
+	case "json":
+		w.enc.Encode(msg)
+	case "csv":
+		w.csvLine(row)
+	default:
+		fmt.Println(strings.Join(row, " "))
+	}
+}
+
+func (w *streamWriter) csvLine(row []string) {
+
+	w.csv.Write(row)
+	w.csv.Flush()
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/guard"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/joemocquant/poloniex-api/validation"
+)
+
+func runBalances(ctx *context, args []string) error {
+
+	all := ctx.flags.Bool("all", false, "include zero balances")
+
+	if _, err := ctx.parse(args, 0, 0); err != nil {
+		return err
+	}
+
+	client, err := ctx.tradingClient()
+	if err != nil {
+		return err
+	}
+
+	balances, err := client.GetCompleteBalances()
+	if err != nil {
+		return fmt.Errorf("TradingClient.GetCompleteBalances: %v", err)
+	}
+
+	if !*all {
+		for currency, b := range balances {
+			if b.Available == 0 && b.OnOrders == 0 {
+				delete(balances, currency)
+			}
+		}
+	}
+
+	currencies := make([]string, 0, len(balances))
+	for currency := range balances {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	t := table{header: []string{"currency", "available", "onOrders", "btcValue"}}
+
+	for _, currency := range currencies {
+		b := balances[currency]
+		t.add(currency, b.Available, b.OnOrders, b.BtcValue)
+	}
+
+	return ctx.print(balances, &t)
+}
+
+func runOrders(ctx *context, args []string) error {
+
+	positional, err := ctx.parse(args, 0, 1)
+	if err != nil {
+		return err
+	}
+
+	client, err := ctx.tradingClient()
+	if err != nil {
+		return err
+	}
+
+	var orders tradingapi.AllOpenOrders
+
+	if len(positional) == 1 {
+		pair := strings.ToUpper(positional[0])
+		res, err := client.GetOpenOrders(pair)
+		if err != nil {
+			return fmt.Errorf("TradingClient.GetOpenOrders: %v", err)
+		}
+		orders = tradingapi.AllOpenOrders{pair: res}
+	} else {
+		if orders, err = client.GetAllOpenOrders(); err != nil {
+			return fmt.Errorf("TradingClient.GetAllOpenOrders: %v", err)
+		}
+	}
+
+	pairs := make([]string, 0, len(orders))
+	for pair, o := range orders {
+		if o != nil && len(*o) > 0 {
+			pairs = append(pairs, pair)
+		}
+	}
+	sort.Strings(pairs)
+
+	t := table{header: []string{"pair", "orderNumber", "type", "rate",
+		"startingAmount", "amount", "total", "date"}}
+
+	for _, pair := range pairs {
+		for _, o := range *orders[pair] {
+			t.add(pair, o.OrderNumber, o.Type, o.Rate, o.StartingAmount, o.Amount,
+				o.Total, time.Unix(o.Date, 0))
+		}
+	}
+
+	return ctx.print(orders, &t)
+}
+
+func runBuy(ctx *context, args []string) error {
+	return placeOrder(ctx, args, "buy")
+}
+
+func runSell(ctx *context, args []string) error {
+	return placeOrder(ctx, args, "sell")
+}
+
+func placeOrder(ctx *context, args []string, side string) error {
+
+	postOnly := ctx.flags.Bool("post-only", false, "post-only order")
+	ioc := ctx.flags.Bool("ioc", false, "immediate-or-cancel order")
+	fok := ctx.flags.Bool("fok", false, "fill-or-kill order")
+	noValidate := ctx.flags.Bool("no-validate", false, "skip client-side order validation")
+	ctx.moneyFlags()
+
+	positional, err := ctx.parse(args, 3, 3)
+	if err != nil {
+		return err
+	}
+
+	pair := strings.ToUpper(positional[0])
+
+	rate, amount, err := parseRateAmount(positional[1], positional[2])
+	if err != nil {
+		return err
+	}
+
+	options := 0
+	for _, set := range []bool{*postOnly, *ioc, *fok} {
+		if set {
+			options++
+		}
+	}
+	if options > 1 {
+		return errors.New("-post-only, -ioc and -fok are mutually exclusive")
+	}
+
+	client, err := ctx.tradingClient()
+	if err != nil {
+		return err
+	}
+
+	type order func(string, float64, float64) (*tradingapi.BuyOrSellOrder, error)
+
+	var place order
+	var kind string
+
+	switch side {
+	case "buy":
+		place, kind = client.Buy, "limit"
+		switch {
+		case *postOnly:
+			place, kind = client.BuyPostOnly, "post-only"
+		case *ioc:
+			place, kind = client.BuyImmediateOrCancel, "immediate-or-cancel"
+		case *fok:
+			place, kind = client.BuyFillOrKill, "fill-or-kill"
+		}
+	default:
+		place, kind = client.Sell, "limit"
+		switch {
+		case *postOnly:
+			place, kind = client.SellPostOnly, "post-only"
+		case *ioc:
+			place, kind = client.SellImmediateOrCancel, "immediate-or-cancel"
+		case *fok:
+			place, kind = client.SellFillOrKill, "fill-or-kill"
+		}
+	}
+
+	if !*noValidate {
+		validator := validation.NewValidator(publicapi.NewClient(), validation.DefaultRules)
+		if err := validator.Validate(pair, rate, amount); err != nil {
+			return fmt.Errorf("Validator.Validate: %v", err)
+		}
+	}
+
+	action := fmt.Sprintf("%s %s %s %s at %s (total %s)", kind, side, format(amount),
+		pair, format(rate), format(rate*amount))
+
+	if ok, err := ctx.confirm(action); !ok || err != nil {
+		return err
+	}
+
+	res, err := place(pair, rate, amount)
+	if err != nil {
+		return fmt.Errorf("TradingClient.%s: %v", strings.Title(side), err)
+	}
+
+	t := table{header: []string{"orderNumber", "tradeId", "type", "rate", "amount",
+		"total", "date", "amountUnfilled"}}
+
+	if len(res.ResultingTrades) == 0 {
+		t.add(res.OrderNumber, "", "", "", "", "", "", res.AmountUnfilled)
+	}
+	for _, trade := range res.ResultingTrades {
+		t.add(res.OrderNumber, trade.TradeId, trade.TypeOrder, trade.Rate, trade.Amount,
+			trade.Total, time.Unix(trade.Date, 0), res.AmountUnfilled)
+	}
+
+	return ctx.print(res, &t)
+}
+
+func runCancel(ctx *context, args []string) error {
+
+	ctx.moneyFlags()
+
+	positional, err := ctx.parse(args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	orderNumber, err := strconv.ParseInt(positional[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("wrong order number: %s", positional[0])
+	}
+
+	client, err := ctx.tradingClient()
+	if err != nil {
+		return err
+	}
+
+	if ok, err := ctx.confirm(fmt.Sprintf("cancel order %d", orderNumber)); !ok || err != nil {
+		return err
+	}
+
+	res, err := client.CancelOrder(orderNumber)
+	if err != nil {
+		return fmt.Errorf("TradingClient.CancelOrder: %v", err)
+	}
+
+	t := table{header: []string{"orderNumber", "success", "amount", "message"}}
+	t.add(orderNumber, res.Success, res.Amount, res.Message)
+
+	return ctx.print(res, &t)
+}
+
+func runMove(ctx *context, args []string) error {
+
+	amountFlag := ctx.flags.String("amount", "", "new amount (unchanged by default)")
+	postOnly := ctx.flags.Bool("post-only", false, "post-only order")
+	ioc := ctx.flags.Bool("ioc", false, "immediate-or-cancel order")
+	ctx.moneyFlags()
+
+	positional, err := ctx.parse(args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	if *postOnly && *ioc {
+		return errors.New("-post-only and -ioc are mutually exclusive")
+	}
+
+	orderNumber, err := strconv.ParseInt(positional[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("wrong order number: %s", positional[0])
+	}
+
+	client, err := ctx.tradingClient()
+	if err != nil {
+		return err
+	}
+
+	// The amount of the order is kept unless given
+	pair, order, err := findOpenOrder(client, orderNumber)
+	if err != nil {
+		return err
+	}
+
+	amount := strconv.FormatFloat(order.Amount, 'f', -1, 64)
+	if *amountFlag != "" {
+		amount = *amountFlag
+	}
+
+	rate, newAmount, err := parseRateAmount(positional[1], amount)
+	if err != nil {
+		return err
+	}
+
+	action := fmt.Sprintf("move %s order %d on %s from %s to %s (amount %s)", order.Type,
+		orderNumber, pair, format(order.Rate), format(rate), format(newAmount))
+
+	if ok, err := ctx.confirm(action); !ok || err != nil {
+		return err
+	}
+
+	move := client.MoveOrder
+	switch {
+	case *postOnly:
+		move = client.MoveOrderPostOnly
+	case *ioc:
+		move = client.MoveOrderImmediateOrCancel
+	}
+
+	res, err := move(orderNumber, rate, newAmount)
+	if err != nil {
+		return fmt.Errorf("TradingClient.MoveOrder: %v", err)
+	}
+
+	t := table{header: []string{"orderNumber", "success", "pair", "tradeId", "type",
+		"rate", "amount", "total", "date"}}
+
+	if len(res.ResultingTrades) == 0 {
+		t.add(res.OrderNumber, res.Success, pair, "", "", "", "", "", "")
+	}
+	for tradePair, trades := range res.ResultingTrades {
+		for _, trade := range trades {
+			t.add(res.OrderNumber, res.Success, tradePair, trade.TradeId, trade.TypeOrder,
+				trade.Rate, trade.Amount, trade.Total, time.Unix(trade.Date, 0))
+		}
+	}
+
+	return ctx.print(res, &t)
+}
+
+func runWithdraw(ctx *context, args []string) error {
+
+	paymentId := ctx.flags.String("payment-id", "", "payment id (XMR, XRP...)")
+	ctx.moneyFlags()
+
+	positional, err := ctx.parse(args, 3, 3)
+	if err != nil {
+		return err
+	}
+
+	currency := strings.ToUpper(positional[0])
+	address := positional[2]
+
+	amount, err := strconv.ParseFloat(positional[1], 64)
+	if err != nil || amount <= 0 {
+		return fmt.Errorf("wrong amount: %s", positional[1])
+	}
+
+	action := fmt.Sprintf("withdraw %s %s to %s", format(amount), currency, address)
+	if *paymentId != "" {
+		action += fmt.Sprintf(" (payment id %s)", *paymentId)
+	}
+
+	// Confirmation is the approval of the request, asked once the policy checks passed
+	confirm := func(r *guard.Request) error {
+		ok, err := ctx.confirm(action)
+		switch {
+		case err != nil:
+			return err
+		case !ok && ctx.dryRun:
+			return errors.New("dry run")
+		case !ok:
+			return errors.New("aborted")
+		}
+		return nil
+	}
+
+	client, audit, err := ctx.withdrawalClient(confirm)
+	if err != nil {
+		return err
+	}
+	defer audit.Close()
+
+	var res *tradingapi.Withdrawal
+
+	if *paymentId != "" {
+		res, err = client.WithdrawWithPaymentId(currency, amount, address, *paymentId)
+	} else {
+		res, err = client.Withdraw(currency, amount, address)
+	}
+
+	// Only a dry run rejects the request without reading a confirmation
+	if _, rejected := err.(*guard.ErrRejected); rejected && ctx.dryRun {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("GuardClient.Withdraw: %v", err)
+	}
+
+	t := table{header: []string{"response"}}
+	t.add(res.Response)
+
+	return ctx.print(res, &t)
+}
+
+func findOpenOrder(client *tradingapi.Client, orderNumber int64) (string, *tradingapi.OpenOrder, error) {
+
+	orders, err := client.GetAllOpenOrders()
+	if err != nil {
+		return "", nil, fmt.Errorf("TradingClient.GetAllOpenOrders: %v", err)
+	}
+
+	for pair, o := range orders {
+		if o == nil {
+			continue
+		}
+		for _, order := range *o {
+			if order.OrderNumber == orderNumber {
+				return pair, order, nil
+			}
+		}
+	}
+
+	return "", nil, fmt.Errorf("order %d not found in open orders", orderNumber)
+}
+
+func parseRateAmount(rate, amount string) (float64, float64, error) {
+
+	r, err := strconv.ParseFloat(rate, 64)
+	if err != nil || r <= 0 {
+		return 0, 0, fmt.Errorf("wrong rate: %s", rate)
+	}
+
+	a, err := strconv.ParseFloat(amount, 64)
+	if err != nil || a <= 0 {
+		return 0, 0, fmt.Errorf("wrong amount: %s", amount)
+	}
+
+	return r, a, nil
+}
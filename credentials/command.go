@@ -0,0 +1,83 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+const defaultCommandTimeout = time.Minute
+
+// Command returns the credentials printed by the program Name run with Args (no
+// shell is involved). The program must print either a JSON object with "api_key"
+// and "api_secret", or the key and the secret on their first two lines. Its
+// standard input and error are those of the process, so it may prompt the user.
+type Command struct {
+	Name    string
+	Args    []string
+	Timeout time.Duration // 1 minute by default
+}
+
+func (c *Command) Credentials() (*tradingapi.Credentials, error) {
+
+	if c.Name == "" {
+		return nil, fmt.Errorf("no credentials command")
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultCommandTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("exec.Cmd.Run %s: %v", c.Name, err)
+	}
+
+	out := stdout.Bytes()
+	defer zero(out)
+
+	return parseCredentials(out)
+}
+
+func parseCredentials(out []byte) (*tradingapi.Credentials, error) {
+
+	var res tradingapi.Credentials
+
+	if trimmed := bytes.TrimSpace(out); len(trimmed) > 0 && trimmed[0] == '{' {
+
+		if err := json.Unmarshal(trimmed, &res); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %v", err)
+		}
+
+	} else {
+
+		lines := strings.Split(strings.Replace(string(out), "\r\n", "\n", -1), "\n")
+		if len(lines) >= 2 {
+			res.ApiKey = strings.TrimSpace(lines[0])
+			res.ApiSecret = strings.TrimSpace(lines[1])
+		}
+	}
+
+	if res.ApiKey == "" || res.ApiSecret == "" {
+		return nil, fmt.Errorf("credentials command output: missing key or secret")
+	}
+
+	return &res, nil
+}
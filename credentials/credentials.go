@@ -0,0 +1,54 @@
+// API credential providers.
+//
+// Providers implement tradingapi.CredentialProvider, to be used with
+// tradingapi.NewClientWithProvider:
+//
+// Env reads the credentials from environment variables.
+//
+// Keystore decrypts a keystore file written by WriteKeystore: the credentials are
+// encrypted with AES-256-GCM under a key derived from a passphrase with scrypt.
+//
+// Command runs an external program (e.g. a password manager) printing the
+// credentials, without shell so that it behaves the same on every OS.
+package credentials
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+const (
+	defaultKeyVar    = "POLONIEX_API_KEY"
+	defaultSecretVar = "POLONIEX_API_SECRET"
+)
+
+// Env returns the credentials from the environment variables KeyVar and SecretVar,
+// POLONIEX_API_KEY and POLONIEX_API_SECRET by default.
+type Env struct {
+	KeyVar    string
+	SecretVar string
+}
+
+func (e *Env) Credentials() (*tradingapi.Credentials, error) {
+
+	keyVar, secretVar := e.KeyVar, e.SecretVar
+	if keyVar == "" {
+		keyVar = defaultKeyVar
+	}
+	if secretVar == "" {
+		secretVar = defaultSecretVar
+	}
+
+	c := tradingapi.Credentials{
+		ApiKey:    os.Getenv(keyVar),
+		ApiSecret: os.Getenv(secretVar),
+	}
+
+	if c.ApiKey == "" || c.ApiSecret == "" {
+		return nil, fmt.Errorf("%s and %s must be set", keyVar, secretVar)
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joemocquant/poloniex-api/credentials"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+func main() {
+
+	// Encrypt the credentials of the environment to a keystore
+	writeKeystore("poloniex.keystore")
+
+	// Client using the keystore, passphrase prompted
+	keystore := credentials.Keystore{
+		Path:       "poloniex.keystore",
+		Passphrase: credentials.TerminalPassphrase("Keystore passphrase: "),
+	}
+	printBalances(&keystore)
+
+	// Client using the environment
+	// printBalances(&credentials.Env{})
+
+	// Client using a password manager
+	// printBalances(&credentials.Command{Name: "pass", Args: []string{"show", "poloniex"}})
+}
+
+func writeKeystore(path string) {
+
+	c, err := (&credentials.Env{}).Credentials()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	passphrase, err := credentials.TerminalPassphrase("New keystore passphrase: ")()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := credentials.WriteKeystore(path, c, passphrase); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "keystore written to %s\n", path)
+}
+
+func printBalances(provider tradingapi.CredentialProvider) {
+
+	client, err := tradingapi.NewClientWithProvider(provider)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	balances, err := client.GetBalances()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for currency, balance := range balances {
+		if balance > 0 {
+			fmt.Printf("%s: %.8f\n", currency, balance)
+		}
+	}
+}
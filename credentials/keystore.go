@@ -0,0 +1,240 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// scrypt parameters of new keystores (about 100ms on current hardware), and maximum
+// parameters of the keystores read
+const (
+	keystoreVersion = 1
+	scryptN         = 1 << 15
+	scryptR         = 8
+	scryptP         = 1
+	keyLen          = 32 // AES-256
+	saltLen         = 16
+)
+
+// A Passphrase returns the passphrase of a keystore.
+type Passphrase func() ([]byte, error)
+
+// EnvPassphrase returns the passphrase from the environment variable name.
+func EnvPassphrase(name string) Passphrase {
+
+	return func() ([]byte, error) {
+
+		v := os.Getenv(name)
+		if v == "" {
+			return nil, fmt.Errorf("%s not set", name)
+		}
+
+		return []byte(v), nil
+	}
+}
+
+// TerminalPassphrase prompts for the passphrase on the terminal (standard input),
+// without echo.
+func TerminalPassphrase(prompt string) Passphrase {
+
+	return func() ([]byte, error) {
+
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			return nil, fmt.Errorf("standard input is not a terminal")
+		}
+
+		fmt.Fprint(os.Stderr, prompt)
+		passphrase, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+
+		if err != nil {
+			return nil, fmt.Errorf("term.ReadPassword: %v", err)
+		}
+
+		return passphrase, nil
+	}
+}
+
+// Keystore returns the credentials of the keystore file at Path, decrypted with the
+// key derived from Passphrase.
+type Keystore struct {
+	Path       string
+	Passphrase Passphrase
+}
+
+// keystoreFile is the content of a keystore file. The header (every field but
+// Ciphertext) is authenticated as additional data.
+type keystoreFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext,omitempty"`
+}
+
+func (k *Keystore) Credentials() (*tradingapi.Credentials, error) {
+
+	content, err := ioutil.ReadFile(k.Path)
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.ReadFile: %v", err)
+	}
+
+	var f keystoreFile
+	if err := json.Unmarshal(content, &f); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	if f.Version != keystoreVersion || f.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported keystore version %d (%s)", f.Version, f.KDF)
+	}
+
+	// The parameters are only authenticated after the key derivation: a tampered file
+	// must not make it allocate gigabytes
+	if f.N > scryptN || f.R > scryptR || f.P > scryptP {
+		return nil, fmt.Errorf("unsupported scrypt parameters N=%d r=%d p=%d", f.N, f.R, f.P)
+	}
+
+	if k.Passphrase == nil {
+		return nil, fmt.Errorf("no passphrase for keystore %s", k.Path)
+	}
+
+	passphrase, err := k.Passphrase()
+	if err != nil {
+		return nil, fmt.Errorf("passphrase: %v", err)
+	}
+	defer zero(passphrase)
+
+	aead, err := newAEAD(passphrase, &f)
+	if err != nil {
+		return nil, err
+	}
+
+	ad, err := f.header()
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := aead.Open(nil, f.Nonce, f.Ciphertext, ad)
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase or corrupted keystore %s", k.Path)
+	}
+	defer zero(plaintext)
+
+	var c tradingapi.Credentials
+	if err := json.Unmarshal(plaintext, &c); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	return &c, nil
+}
+
+// WriteKeystore encrypts c with a key derived from passphrase and writes the keystore
+// to path, readable by the user only. An existing keystore is replaced.
+func WriteKeystore(path string, c *tradingapi.Credentials, passphrase []byte) error {
+
+	if c.ApiKey == "" || c.ApiSecret == "" {
+		return fmt.Errorf("Wrong credentials parameter: empty key or secret")
+	}
+
+	if len(passphrase) == 0 {
+		return fmt.Errorf("Wrong passphrase parameter: empty")
+	}
+
+	f := keystoreFile{
+		Version: keystoreVersion,
+		KDF:     "scrypt",
+		N:       scryptN,
+		R:       scryptR,
+		P:       scryptP,
+		Salt:    make([]byte, saltLen),
+	}
+
+	if _, err := rand.Read(f.Salt); err != nil {
+		return fmt.Errorf("rand.Read: %v", err)
+	}
+
+	aead, err := newAEAD(passphrase, &f)
+	if err != nil {
+		return err
+	}
+
+	f.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return fmt.Errorf("rand.Read: %v", err)
+	}
+
+	plaintext, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %v", err)
+	}
+	defer zero(plaintext)
+
+	ad, err := f.header()
+	if err != nil {
+		return err
+	}
+
+	f.Ciphertext = aead.Seal(nil, f.Nonce, plaintext, ad)
+
+	content, err := json.MarshalIndent(&f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %v", err)
+	}
+
+	return util.WriteFile(path, content)
+}
+
+func newAEAD(passphrase []byte, f *keystoreFile) (cipher.AEAD, error) {
+
+	key, err := scrypt.Key(passphrase, f.Salt, f.N, f.R, f.P, keyLen)
+	if err != nil {
+		return nil, fmt.Errorf("scrypt.Key: %v", err)
+	}
+	defer zero(key)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("aes.NewCipher: %v", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("cipher.NewGCM: %v", err)
+	}
+
+	return aead, nil
+}
+
+// header returns the authenticated header of the keystore.
+func (f *keystoreFile) header() ([]byte, error) {
+
+	h := *f
+	h.Ciphertext = nil
+
+	ad, err := json.Marshal(&h)
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal: %v", err)
+	}
+
+	return ad, nil
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
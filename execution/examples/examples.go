@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	poloniex "github.com/joemocquant/poloniex-api"
+	"github.com/joemocquant/poloniex-api/execution"
+	"github.com/joemocquant/poloniex-api/orderbook"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/pushapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+var (
+	tradingClient *tradingapi.Client
+	publicClient  *publicapi.Client
+)
+
+func main() {
+
+	var err error
+	tradingClient, err = tradingapi.NewClient()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	publicClient = publicapi.NewClient()
+
+	twapBuy()
+
+	// vwapSell()
+
+	// icebergBuy()
+
+	// peggedSell()
+}
+
+// Buy 1 ETH over 10 minutes with 10 post-only children, paused during one minute
+func twapBuy() {
+
+	params := execution.Params{
+		CurrencyPair: "BTC_ETH",
+		Type:         "buy",
+		Amount:       1,
+		LimitRate:    0.035,
+		Duration:     10 * time.Minute,
+		Slices:       10,
+		Style:        execution.PostOnly,
+	}
+
+	e, err := execution.NewTWAP(tradingClient, publicClient, params)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go printProgress(e)
+
+	go func() {
+		time.Sleep(3 * time.Minute)
+		e.Pause()
+		time.Sleep(time.Minute)
+		e.Resume()
+	}()
+
+	report, err := e.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	poloniex.PrettyPrintJson(report)
+}
+
+// Sell 1 ETH over one hour following the volume profile of the last 7 days, with
+// immediate-or-cancel children 0.1% below the best bid
+func vwapSell() {
+
+	params := execution.Params{
+		CurrencyPair: "BTC_ETH",
+		Type:         "sell",
+		Amount:       1,
+		Duration:     time.Hour,
+		Slices:       12,
+		Style:        execution.ImmediateOrCancel,
+		Offset:       0.001,
+	}
+
+	e, err := execution.NewVWAP(tradingClient, publicClient, params, 7)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go printProgress(e)
+
+	report, err := e.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	poloniex.PrettyPrintJson(report)
+}
+
+func printProgress(e *execution.Execution) {
+
+	for p := range e.Progress() {
+		fmt.Printf("%s: slice %d/%d, filled %.8f at %.8f, remaining %.8f\n",
+			p.State, p.Slice, p.Slices, p.Filled, p.AverageRate, p.Remaining)
+	}
+}
+
+// Buy 5 ETH at 0.03 showing 0.5 ETH at a time
+func icebergBuy() {
+
+	book := runBook("BTC_ETH")
+
+	params := execution.IcebergParams{
+		CurrencyPair: "BTC_ETH",
+		Type:         "buy",
+		Amount:       5,
+		Visible:      0.5,
+		Rate:         0.03,
+	}
+
+	iceberg, err := execution.NewIceberg(tradingClient, book, params)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(time.Hour)
+		iceberg.Cancel()
+	}()
+
+	report, err := iceberg.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	poloniex.PrettyPrintJson(report)
+}
+
+// Sell 1 ETH one satoshi above the best ask, never below 0.03
+func peggedSell() {
+
+	book := runBook("BTC_ETH")
+
+	params := execution.PeggedParams{
+		CurrencyPair: "BTC_ETH",
+		Type:         "sell",
+		Amount:       1,
+		Peg:          execution.PegPrimary,
+		Offset:       -0.00000001,
+		LimitRate:    0.03,
+		MoveInterval: 5 * time.Second,
+	}
+
+	pegged, err := execution.NewPegged(tradingClient, book, params)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	report, err := pegged.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	poloniex.PrettyPrintJson(report)
+}
+
+// runBook returns the live order book of currencyPair
+func runBook(currencyPair string) *orderbook.Book {
+
+	pushClient, err := pushapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	updater, err := pushClient.SubscribeMarket(currencyPair)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	book := orderbook.NewBook(publicClient, currencyPair)
+	if err := book.Sync(); err != nil {
+		log.Fatal(err)
+	}
+
+	go func() {
+		for updates := range updater {
+			if updates == nil {
+				return
+			}
+			if err := book.Apply(updates); err != nil {
+				log.Println(err)
+			}
+		}
+	}()
+
+	return book
+}
@@ -0,0 +1,588 @@
+// Execution algorithms.
+//
+// A large order placed at once moves the market. An Execution slices a parent order
+// into child orders sent over a time window, either in equal parts (TWAP) or in
+// proportion to the volume usually traded at the same time of day (VWAP, from the
+// chart data of the previous days). Children are post-only orders at the best rate of
+// their side, replaced at every slice, or immediate-or-cancel orders crossing the
+// spread. The amount a child did not fill is carried over to the next slices.
+//
+// Executions can be paused (the resting child is cancelled), resumed and cancelled.
+// Progress is reported after every slice and a fill report aggregates the trades of
+// every child.
+//
+// Iceberg and Pegged orders follow the live order book (see the orderbook package):
+// icebergs show a fixed visible amount replenished after fills, pegged orders track
+// the best bid, best ask or mid rate with MoveOrderPostOnly.
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	poloniex "github.com/joemocquant/poloniex-api"
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:execution]")
+
+// Minimum total (base currency) of an order on Poloniex
+const defaultMinTotal = 0.0001
+
+type Style int
+
+const (
+	PostOnly          Style = iota // rests at the best rate of its side
+	ImmediateOrCancel              // crosses the spread, unfilled part cancelled
+)
+
+type State int
+
+const (
+	Pending State = iota
+	Running
+	Paused
+	Cancelled
+	Completed
+)
+
+func (s State) String() string {
+
+	switch s {
+	case Pending:
+		return "pending"
+	case Running:
+		return "running"
+	case Paused:
+		return "paused"
+	case Cancelled:
+		return "cancelled"
+	case Completed:
+		return "completed"
+	default:
+		return fmt.Sprintf("unknown state %d", int(s))
+	}
+}
+
+type Params struct {
+	CurrencyPair string
+	Type         string        // buy or sell
+	Amount       float64       // parent order amount
+	LimitRate    float64       // highest buy or lowest sell rate, 0 for none
+	Start        time.Time     // now when zero
+	Duration     time.Duration // execution window
+	Slices       int
+	Style        Style
+	Offset       float64 // ImmediateOrCancel only: rate beyond the best opposite rate, e.g. 0.001
+	MinTotal     float64 // children below this total are skipped, 0.0001 when zero
+}
+
+type Trade struct {
+	OrderNumber int64
+	TradeId     int64
+	Rate        float64
+	Amount      float64
+	Total       float64
+	Date        int64 // Unix timestamp
+}
+
+type Progress struct {
+	State       State
+	Slice       int // slices started
+	Slices      int
+	Filled      float64
+	Remaining   float64
+	AverageRate float64
+}
+
+type Report struct {
+	CurrencyPair string
+	Type         string
+	Amount       float64 // requested
+	Filled       float64
+	Total        float64 // base currency
+	AverageRate  float64
+	Children     int
+	Trades       []*Trade
+	State        State
+	Started      time.Time
+	Finished     time.Time
+}
+
+type Execution struct {
+	client  *tradingapi.Client
+	public  *publicapi.Client
+	params  Params
+	weights []float64
+
+	mu       sync.Mutex
+	state    State
+	slice    int
+	filled   float64
+	total    float64
+	children int
+	trades   []*Trade
+	tradeIds map[int64]bool
+	resting  int64 // post-only child on the book
+	started  time.Time
+	finished time.Time
+
+	wake     chan struct{}
+	progress chan *Progress
+	closed   bool // progress is closed
+}
+
+// NewTWAP returns an execution sending equal children at regular intervals.
+func NewTWAP(client *tradingapi.Client, public *publicapi.Client, params Params) (*Execution, error) {
+
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	return newExecution(client, public, params, twapWeights(params.Slices)), nil
+}
+
+// NewVWAP returns an execution sending children in proportion to the volume traded
+// during the same slices of the previous days.
+func NewVWAP(client *tradingapi.Client, public *publicapi.Client, params Params, days int) (*Execution, error) {
+
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	if days < 1 {
+		return nil, fmt.Errorf("Wrong days parameter: %d", days)
+	}
+
+	if params.Start.IsZero() {
+		params.Start = time.Now()
+	}
+
+	sliceDuration := params.Duration / time.Duration(params.Slices)
+
+	weights, err := vwapWeights(public, params.CurrencyPair, params.Start, sliceDuration,
+		params.Slices, days)
+
+	if err != nil {
+		return nil, fmt.Errorf("vwapWeights: %v", err)
+	}
+
+	return newExecution(client, public, params, weights), nil
+}
+
+func newExecution(client *tradingapi.Client, public *publicapi.Client, params Params, weights []float64) *Execution {
+
+	if params.MinTotal == 0 {
+		params.MinTotal = defaultMinTotal
+	}
+
+	e := Execution{
+		client:   client,
+		public:   public,
+		params:   params,
+		weights:  weights,
+		tradeIds: make(map[int64]bool),
+		wake:     make(chan struct{}, 1),
+		progress: make(chan *Progress, 100),
+	}
+
+	return &e
+}
+
+func (p *Params) validate() error {
+
+	switch {
+	case p.Type != "buy" && p.Type != "sell":
+		return fmt.Errorf("Wrong type parameter: %s", p.Type)
+	case p.Amount <= 0:
+		return fmt.Errorf("Wrong amount parameter: %v", p.Amount)
+	case p.Slices < 1:
+		return fmt.Errorf("Wrong slices parameter: %d", p.Slices)
+	case p.Duration < time.Duration(p.Slices)*time.Second:
+		return fmt.Errorf("Wrong duration parameter: %s", p.Duration)
+	}
+
+	return nil
+}
+
+// Progress returns the channel on which progress is reported after every slice and
+// state change. It is closed when the execution ends. Reports are dropped when
+// the channel is full.
+func (e *Execution) Progress() <-chan *Progress {
+	return e.progress
+}
+
+// Pause cancels the resting child and stops sending children until Resume.
+func (e *Execution) Pause() {
+	e.setState(Running, Paused)
+}
+
+func (e *Execution) Resume() {
+	e.setState(Paused, Running)
+}
+
+// Cancel stops the execution, cancelling the resting child.
+func (e *Execution) Cancel() {
+
+	e.mu.Lock()
+	if e.state == Pending || e.state == Running || e.state == Paused {
+		e.state = Cancelled
+	}
+	e.mu.Unlock()
+
+	e.signal()
+}
+
+func (e *Execution) setState(from, to State) {
+
+	e.mu.Lock()
+	changed := e.state == from
+	if changed {
+		e.state = to
+	}
+	e.mu.Unlock()
+
+	if changed {
+		e.signal()
+		e.emitProgress()
+	}
+}
+
+func (e *Execution) signal() {
+
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run executes the parent order until the end of the execution window or until
+// cancelled, and returns the fill report.
+func (e *Execution) Run() (*Report, error) {
+
+	e.mu.Lock()
+	if e.state != Pending {
+		e.mu.Unlock()
+		return nil, errors.New("execution already run or cancelled")
+	}
+	e.state = Running
+	e.started = time.Now()
+	e.mu.Unlock()
+
+	defer e.closeProgress()
+
+	start := e.params.Start
+	if start.IsZero() {
+		start = e.started
+	}
+
+	sliceDuration := e.params.Duration / time.Duration(e.params.Slices)
+	target := 0.0
+
+	for i, weight := range e.weights {
+
+		target += weight * e.params.Amount
+		if i == len(e.weights)-1 {
+			target = e.params.Amount
+		}
+
+		if !e.wait(start.Add(time.Duration(i) * sliceDuration)) {
+			break
+		}
+
+		e.mu.Lock()
+		e.slice = i + 1
+		e.mu.Unlock()
+
+		// A child which could not be cancelled may still fill: no new child is
+		// placed beside it, its unfilled amount is carried over to the next slice
+		if err := e.finishChild(); err != nil {
+			logger.WithField("error", err).Errorf("slice %d: finishChild", i+1)
+		} else if err := e.placeChild(target); err != nil {
+			logger.WithField("error", err).Errorf("slice %d: placeChild", i+1)
+		}
+
+		e.emitProgress()
+	}
+
+	// The last post-only child rests until the end of the window
+	e.wait(start.Add(e.params.Duration))
+
+	if err := e.finishChild(); err != nil {
+		logger.WithField("error", err).Errorf("finishChild: child %d may still be on the book",
+			e.restingChild())
+	}
+
+	e.mu.Lock()
+	if e.state != Cancelled {
+		e.state = Completed
+	}
+	e.finished = time.Now()
+	e.mu.Unlock()
+
+	e.emitProgress()
+
+	return e.Report(), nil
+}
+
+// wait waits until t, while paused, cancelling the resting child on pause. It
+// returns false when the execution is cancelled.
+func (e *Execution) wait(t time.Time) bool {
+
+	for {
+		e.mu.Lock()
+		state, resting := e.state, e.resting
+		e.mu.Unlock()
+
+		if state == Cancelled {
+			return false
+		}
+
+		if state == Paused && resting != 0 {
+			if err := e.finishChild(); err != nil {
+				logger.WithField("error", err).Error("finishChild")
+			}
+		}
+
+		d := time.Until(t)
+		if state == Running && d <= 0 {
+			return true
+		}
+
+		if d <= 0 {
+			d = time.Minute // paused, wait for a state change
+		}
+
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-e.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// placeChild sends a child order for the amount not yet filled of target.
+func (e *Execution) placeChild(target float64) error {
+
+	e.mu.Lock()
+	amount := util.Floor(target - e.filled)
+	resting := e.resting
+	e.mu.Unlock()
+
+	if resting != 0 {
+		return fmt.Errorf("child %d still resting", resting)
+	}
+
+	if amount <= 0 {
+		return nil
+	}
+
+	book, err := e.public.GetOrderBook(e.params.CurrencyPair, 1)
+	if err != nil {
+		return fmt.Errorf("PublicClient.GetOrderBook: %v", err)
+	}
+
+	if len(book.Asks) == 0 || len(book.Bids) == 0 {
+		return errors.New("empty order book")
+	}
+
+	bid, ask := book.Bids[0].Rate, book.Asks[0].Rate
+	p := e.params
+
+	var rate float64
+	var place func(string, float64, float64) (*tradingapi.BuyOrSellOrder, error)
+
+	// Immediate or cancel rates are rounded towards execution
+	switch {
+	case p.Type == "buy" && p.Style == PostOnly:
+		rate, place = bid, e.client.BuyPostOnly
+	case p.Type == "buy":
+		rate, place = util.Ceil(ask*(1+p.Offset)), e.client.BuyImmediateOrCancel
+	case p.Style == PostOnly:
+		rate, place = ask, e.client.SellPostOnly
+	default:
+		rate, place = util.Floor(bid*(1-p.Offset)), e.client.SellImmediateOrCancel
+	}
+
+	if p.LimitRate > 0 {
+		if p.Type == "buy" && rate > p.LimitRate {
+			rate = p.LimitRate
+		}
+		if p.Type == "sell" && rate < p.LimitRate {
+			rate = p.LimitRate
+		}
+	}
+
+	if rate*amount < p.MinTotal {
+		logger.Debugf("%s: child of %.8f at %.8f below minimum total, skipped",
+			p.CurrencyPair, amount, rate)
+		return nil
+	}
+
+	res, err := place(p.CurrencyPair, rate, amount)
+	if err != nil {
+		return fmt.Errorf("place %s %.8f at %.8f: %v", p.Type, amount, rate, err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.children++
+
+	for i := range res.ResultingTrades {
+		e.addTrade(res.OrderNumber, &res.ResultingTrades[i])
+	}
+
+	if p.Style == PostOnly {
+		e.resting = res.OrderNumber
+	}
+
+	return nil
+}
+
+// finishChild cancels the resting child and collects its trades.
+func (e *Execution) finishChild() error {
+
+	e.mu.Lock()
+	orderNumber := e.resting
+	e.mu.Unlock()
+
+	if orderNumber == 0 {
+		return nil
+	}
+
+	// Fails when the child is already filled or cancelled. On any other error the
+	// child is kept resting, cancelled again by the next call.
+	if _, err := e.client.CancelOrder(orderNumber); err != nil && !util.IsOrderClosed(err) {
+		return fmt.Errorf("TradingClient.CancelOrder: %v", err)
+	}
+
+	trades, err := e.client.GetTradesFromOrder(orderNumber)
+	if err != nil && !util.IsNoTrades(err) {
+		return fmt.Errorf("TradingClient.GetTradesFromOrder: %v", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, t := range trades {
+		e.addTrade(orderNumber, &poloniex.ResultingTrade{
+			Amount:  t.Amount,
+			Date:    t.Date,
+			Rate:    t.Rate,
+			Total:   t.Total,
+			TradeId: t.TradeId,
+		})
+	}
+
+	e.resting = 0
+
+	return nil
+}
+
+func (e *Execution) restingChild() int64 {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.resting
+}
+
+// addTrade must be called with e.mu held.
+func (e *Execution) addTrade(orderNumber int64, t *poloniex.ResultingTrade) {
+
+	if e.tradeIds[t.TradeId] {
+		return
+	}
+	e.tradeIds[t.TradeId] = true
+
+	e.trades = append(e.trades, &Trade{
+		OrderNumber: orderNumber,
+		TradeId:     t.TradeId,
+		Rate:        t.Rate,
+		Amount:      t.Amount,
+		Total:       t.Total,
+		Date:        t.Date,
+	})
+
+	e.filled += t.Amount
+	e.total += t.Total
+}
+
+func (e *Execution) emitProgress() {
+
+	// Held while sending, so that Pause or Resume can not send once Run closed the
+	// channel
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return
+	}
+
+	p := Progress{
+		State:       e.state,
+		Slice:       e.slice,
+		Slices:      len(e.weights),
+		Filled:      e.filled,
+		Remaining:   math.Max(e.params.Amount-e.filled, 0),
+		AverageRate: e.averageRate(),
+	}
+
+	select {
+	case e.progress <- &p:
+	default:
+	}
+}
+
+func (e *Execution) closeProgress() {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.closed = true
+	close(e.progress)
+}
+
+// Report returns the fill report of the execution so far.
+func (e *Execution) Report() *Report {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	r := Report{
+		CurrencyPair: e.params.CurrencyPair,
+		Type:         e.params.Type,
+		Amount:       e.params.Amount,
+		Filled:       e.filled,
+		Total:        e.total,
+		AverageRate:  e.averageRate(),
+		Children:     e.children,
+		State:        e.state,
+		Started:      e.started,
+		Finished:     e.finished,
+	}
+
+	for _, t := range e.trades {
+		trade := *t
+		r.Trades = append(r.Trades, &trade)
+	}
+
+	return &r
+}
+
+// averageRate must be called with e.mu held.
+func (e *Execution) averageRate() float64 {
+
+	if e.filled == 0 {
+		return 0
+	}
+	return e.total / e.filled
+}
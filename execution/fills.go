@@ -0,0 +1,128 @@
+package execution
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	poloniex "github.com/joemocquant/poloniex-api"
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/pushapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+// fills aggregates the trades of the children of iceberg and pegged orders.
+type fills struct {
+	mu       sync.Mutex
+	filled   float64
+	total    float64
+	children int
+	trades   []*Trade
+	tradeIds map[int64]bool
+	started  time.Time
+}
+
+func (f *fills) add(orderNumber int64, t *poloniex.ResultingTrade) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.tradeIds == nil {
+		f.tradeIds = make(map[int64]bool)
+	}
+
+	if f.tradeIds[t.TradeId] {
+		return
+	}
+	f.tradeIds[t.TradeId] = true
+
+	f.trades = append(f.trades, &Trade{
+		OrderNumber: orderNumber,
+		TradeId:     t.TradeId,
+		Rate:        t.Rate,
+		Amount:      t.Amount,
+		Total:       t.Total,
+		Date:        t.Date,
+	})
+
+	f.filled += t.Amount
+	f.total += t.Total
+}
+
+// collect adds the trades of a child order.
+func (f *fills) collect(client *tradingapi.Client, orderNumber int64) error {
+
+	trades, err := client.GetTradesFromOrder(orderNumber)
+	if err != nil {
+		if util.IsNoTrades(err) {
+			return nil
+		}
+		return fmt.Errorf("TradingClient.GetTradesFromOrder: %v", err)
+	}
+
+	for _, t := range trades {
+		f.add(orderNumber, &poloniex.ResultingTrade{
+			Amount:  t.Amount,
+			Date:    t.Date,
+			Rate:    t.Rate,
+			Total:   t.Total,
+			TradeId: t.TradeId,
+		})
+	}
+
+	return nil
+}
+
+func (f *fills) placed() {
+
+	f.mu.Lock()
+	f.children++
+	f.mu.Unlock()
+}
+
+func (f *fills) filledAmount() float64 {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.filled
+}
+
+func (f *fills) newReport(currencyPair, typeOrder string, amount float64, state State) *Report {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r := Report{
+		CurrencyPair: currencyPair,
+		Type:         typeOrder,
+		Amount:       amount,
+		Filled:       f.filled,
+		Total:        f.total,
+		Children:     f.children,
+		State:        state,
+		Started:      f.started,
+		Finished:     time.Now(),
+	}
+
+	if f.filled > 0 {
+		r.AverageRate = f.total / f.filled
+	}
+
+	for _, t := range f.trades {
+		trade := *t
+		r.Trades = append(r.Trades, &trade)
+	}
+
+	return &r
+}
+
+// touches reports whether a pushed trade may have filled a resting order of type
+// typeOrder at rate (trades are typed by their taker side).
+func touches(t *pushapi.NewTrade, typeOrder string, rate float64) bool {
+
+	if typeOrder == "buy" {
+		return t.TypeOrder == "sell" && t.Rate <= rate+util.Epsilon
+	}
+	return t.TypeOrder == "buy" && t.Rate >= rate-util.Epsilon
+}
@@ -0,0 +1,233 @@
+package execution
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/orderbook"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+// Open orders check period when no trade is pushed at the order rate
+const defaultPollInterval = 30 * time.Second
+
+type IcebergParams struct {
+	CurrencyPair string
+	Type         string  // buy or sell
+	Amount       float64 // total amount
+	Visible      float64 // amount shown on the book
+	Rate         float64
+	PollInterval time.Duration // 30 seconds when zero
+}
+
+// An Iceberg shows a post-only child of the visible amount at a fixed rate and
+// places the next one when it is filled. Children are only checked (GetOpenOrders)
+// when the live order book pushes a trade at the iceberg rate, or every poll interval.
+type Iceberg struct {
+	client *tradingapi.Client
+	book   *orderbook.Book
+	params IcebergParams
+	fills
+
+	mu    sync.Mutex
+	state State
+	child int64
+
+	cancel chan struct{}
+}
+
+// NewIceberg returns an iceberg order sent with client, watching book (the running
+// order book of the market).
+func NewIceberg(client *tradingapi.Client, book *orderbook.Book, params IcebergParams) (*Iceberg, error) {
+
+	switch {
+	case params.Type != "buy" && params.Type != "sell":
+		return nil, fmt.Errorf("Wrong type parameter: %s", params.Type)
+	case params.Amount <= 0:
+		return nil, fmt.Errorf("Wrong amount parameter: %v", params.Amount)
+	case params.Visible <= 0 || params.Visible > params.Amount:
+		return nil, fmt.Errorf("Wrong visible parameter: %v", params.Visible)
+	case params.Rate <= 0:
+		return nil, fmt.Errorf("Wrong rate parameter: %v", params.Rate)
+	case book.CurrencyPair() != params.CurrencyPair:
+		return nil, fmt.Errorf("order book of %s, not %s", book.CurrencyPair(), params.CurrencyPair)
+	}
+
+	if params.PollInterval == 0 {
+		params.PollInterval = defaultPollInterval
+	}
+
+	i := Iceberg{
+		client: client,
+		book:   book,
+		params: params,
+		cancel: make(chan struct{}),
+	}
+
+	return &i, nil
+}
+
+// Cancel cancels the visible child and stops the iceberg.
+func (i *Iceberg) Cancel() {
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.state == Pending || i.state == Running {
+		i.state = Cancelled
+		close(i.cancel)
+	}
+}
+
+// Run shows children until the amount is filled or the iceberg is cancelled, and
+// returns the fill report.
+func (i *Iceberg) Run() (*Report, error) {
+
+	i.mu.Lock()
+	if i.state != Pending {
+		i.mu.Unlock()
+		return nil, fmt.Errorf("iceberg already run or cancelled")
+	}
+	i.state = Running
+	i.started = time.Now()
+	i.mu.Unlock()
+
+	updates := i.book.Updates()
+	defer i.book.StopUpdates(updates)
+
+	ticker := time.NewTicker(i.params.PollInterval)
+	defer ticker.Stop()
+
+	if err := i.replenish(); err != nil {
+		logger.WithField("error", err).Error("Iceberg.replenish")
+	}
+
+	for !i.done() {
+
+		select {
+		case u := <-updates:
+			touched := false
+			for _, t := range u.Trades {
+				touched = touched || touches(t, i.params.Type, i.params.Rate)
+			}
+			if !touched {
+				continue
+			}
+
+		case <-ticker.C:
+
+		case <-i.cancel:
+			if err := i.cancelChild(); err != nil {
+				return i.report(), err
+			}
+			return i.report(), nil
+		}
+
+		if err := i.check(); err != nil {
+			logger.WithField("error", err).Error("Iceberg.check")
+		}
+	}
+
+	i.mu.Lock()
+	i.state = Completed
+	i.mu.Unlock()
+
+	return i.report(), nil
+}
+
+func (i *Iceberg) report() *Report {
+
+	i.mu.Lock()
+	state := i.state
+	i.mu.Unlock()
+
+	return i.newReport(i.params.CurrencyPair, i.params.Type, i.params.Amount, state)
+}
+
+func (i *Iceberg) done() bool {
+	return i.filledAmount() >= i.params.Amount-util.Epsilon
+}
+
+// check replenishes the iceberg when the visible child left the book.
+func (i *Iceberg) check() error {
+
+	i.mu.Lock()
+	child := i.child
+	i.mu.Unlock()
+
+	if child != 0 {
+
+		open, err := i.client.GetOpenOrders(i.params.CurrencyPair)
+		if err != nil {
+			return fmt.Errorf("TradingClient.GetOpenOrders: %v", err)
+		}
+
+		for _, o := range *open {
+			if o.OrderNumber == child {
+				return nil // still on the book
+			}
+		}
+
+		if err := i.collect(i.client, child); err != nil {
+			return err
+		}
+
+		i.mu.Lock()
+		i.child = 0
+		i.mu.Unlock()
+	}
+
+	return i.replenish()
+}
+
+// replenish shows a new child for the visible amount (or the remaining amount).
+func (i *Iceberg) replenish() error {
+
+	amount := util.Floor(math.Min(i.params.Visible, i.params.Amount-i.filledAmount()))
+	if amount <= 0 {
+		return nil
+	}
+
+	place := i.client.BuyPostOnly
+	if i.params.Type == "sell" {
+		place = i.client.SellPostOnly
+	}
+
+	res, err := place(i.params.CurrencyPair, i.params.Rate, amount)
+	if err != nil {
+		// e.g. the market crossed the rate, retried at the next check
+		return fmt.Errorf("place %s %.8f at %.8f: %v", i.params.Type, amount, i.params.Rate, err)
+	}
+
+	i.placed()
+
+	i.mu.Lock()
+	i.child = res.OrderNumber
+	i.mu.Unlock()
+
+	return nil
+}
+
+func (i *Iceberg) cancelChild() error {
+
+	i.mu.Lock()
+	child := i.child
+	i.mu.Unlock()
+
+	if child == 0 {
+		return nil
+	}
+
+	if _, err := i.client.CancelOrder(child); err != nil && !util.IsOrderClosed(err) {
+		return fmt.Errorf("TradingClient.CancelOrder %d: %v", child, err)
+	}
+
+	i.mu.Lock()
+	i.child = 0
+	i.mu.Unlock()
+
+	return i.collect(i.client, child)
+}
@@ -0,0 +1,390 @@
+package execution
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/orderbook"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+// Smallest rate increment
+const tick = 1e-8
+
+// Minimum delay between two moves of a pegged order when zero in PeggedParams
+const defaultMoveInterval = 2 * time.Second
+
+type Peg int
+
+const (
+	PegPrimary  Peg = iota // best rate of the order side (best bid for a buy)
+	PegOpposite            // best rate of the other side (best ask for a buy)
+	PegMid                 // halfway between best bid and best ask
+)
+
+type PeggedParams struct {
+	CurrencyPair string
+	Type         string // buy or sell
+	Amount       float64
+	Peg          Peg
+	// Added to the reference rate for a buy, subtracted for a sell: positive offsets
+	// are more aggressive
+	Offset       float64
+	LimitRate    float64       // highest buy or lowest sell rate, 0 for none
+	MoveInterval time.Duration // minimum delay between two moves, 2 seconds when zero
+	PollInterval time.Duration // open orders check period, 30 seconds when zero
+}
+
+// A Pegged order is a post-only order following a reference rate of the live order
+// book. It is moved (MoveOrderPostOnly) when the reference changes, at most once per
+// move interval, and never crosses the spread. The order itself is not taken into
+// account when it is the only order at the best rate of its side.
+type Pegged struct {
+	client *tradingapi.Client
+	book   *orderbook.Book
+	params PeggedParams
+	fills
+
+	mu        sync.Mutex
+	state     State
+	order     int64   // current order number
+	previous  []int64 // order numbers before moves
+	rate      float64
+	remaining float64 // amount of the current order still on the book
+	lastMove  time.Time
+
+	cancel chan struct{}
+}
+
+// NewPegged returns a pegged order sent with client, following book (the running
+// order book of the market).
+func NewPegged(client *tradingapi.Client, book *orderbook.Book, params PeggedParams) (*Pegged, error) {
+
+	switch {
+	case params.Type != "buy" && params.Type != "sell":
+		return nil, fmt.Errorf("Wrong type parameter: %s", params.Type)
+	case params.Amount <= 0:
+		return nil, fmt.Errorf("Wrong amount parameter: %v", params.Amount)
+	case params.Peg < PegPrimary || params.Peg > PegMid:
+		return nil, fmt.Errorf("Wrong peg parameter: %d", params.Peg)
+	case book.CurrencyPair() != params.CurrencyPair:
+		return nil, fmt.Errorf("order book of %s, not %s", book.CurrencyPair(), params.CurrencyPair)
+	}
+
+	if params.MoveInterval == 0 {
+		params.MoveInterval = defaultMoveInterval
+	}
+	if params.PollInterval == 0 {
+		params.PollInterval = defaultPollInterval
+	}
+
+	p := Pegged{
+		client: client,
+		book:   book,
+		params: params,
+		cancel: make(chan struct{}),
+	}
+
+	return &p, nil
+}
+
+// Cancel cancels the order and stops following the book.
+func (p *Pegged) Cancel() {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state == Pending || p.state == Running {
+		p.state = Cancelled
+		close(p.cancel)
+	}
+}
+
+// Run follows the book until the amount is filled or the order is cancelled, and
+// returns the fill report.
+func (p *Pegged) Run() (*Report, error) {
+
+	p.mu.Lock()
+	if p.state != Pending {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("pegged order already run or cancelled")
+	}
+	p.state = Running
+	p.started = time.Now()
+	p.remaining = p.params.Amount
+	p.mu.Unlock()
+
+	updates := p.book.Updates()
+	defer p.book.StopUpdates(updates)
+
+	// Moves delayed by the move interval are retried on this ticker
+	reprice := time.NewTicker(p.params.MoveInterval)
+	defer reprice.Stop()
+
+	poll := time.NewTicker(p.params.PollInterval)
+	defer poll.Stop()
+
+	for !p.done() {
+
+		var err error
+
+		select {
+		case u := <-updates:
+			touched := false
+			for _, t := range u.Trades {
+				touched = touched || touches(t, p.params.Type, p.currentRate())
+			}
+			if touched {
+				err = p.check()
+			}
+
+		case <-poll.C:
+			err = p.check()
+
+		case <-reprice.C:
+
+		case <-p.cancel:
+			err := p.cancelOrder()
+			return p.report(), err
+		}
+
+		if err != nil {
+			logger.WithField("error", err).Error("Pegged.check")
+		}
+
+		if !p.done() {
+			if err := p.follow(); err != nil {
+				logger.WithField("error", err).Error("Pegged.follow")
+			}
+		}
+	}
+
+	p.mu.Lock()
+	p.state = Completed
+	p.mu.Unlock()
+
+	return p.report(), nil
+}
+
+func (p *Pegged) report() *Report {
+
+	p.mu.Lock()
+	state := p.state
+	p.mu.Unlock()
+
+	return p.newReport(p.params.CurrencyPair, p.params.Type, p.params.Amount, state)
+}
+
+func (p *Pegged) done() bool {
+	return p.filledAmount() >= p.params.Amount-util.Epsilon
+}
+
+func (p *Pegged) currentRate() float64 {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.rate
+}
+
+// check updates the remaining amount from the open orders. When the order left the
+// book, its trades and those of the order numbers before moves are collected.
+func (p *Pegged) check() error {
+
+	p.mu.Lock()
+	order := p.order
+	numbers := append(append([]int64(nil), p.previous...), p.order)
+	p.mu.Unlock()
+
+	if order == 0 {
+		return nil
+	}
+
+	open, err := p.client.GetOpenOrders(p.params.CurrencyPair)
+	if err != nil {
+		return fmt.Errorf("TradingClient.GetOpenOrders: %v", err)
+	}
+
+	for _, o := range *open {
+		if o.OrderNumber == order {
+			p.mu.Lock()
+			p.remaining = o.Amount
+			p.mu.Unlock()
+			return nil
+		}
+	}
+
+	for _, number := range numbers {
+		if err := p.collect(p.client, number); err != nil {
+			return err
+		}
+	}
+
+	filled := p.filledAmount()
+
+	p.mu.Lock()
+	p.order, p.previous = 0, append(p.previous, order)
+	p.remaining = util.Floor(p.params.Amount - filled)
+	p.mu.Unlock()
+
+	return nil
+}
+
+// target returns the rate the order should have according to the book.
+func (p *Pegged) target() (float64, error) {
+
+	p.mu.Lock()
+	own, ownAmount := p.rate, p.remaining
+	if p.order == 0 {
+		own = 0
+	}
+	p.mu.Unlock()
+
+	bids, asks := p.book.Bids(2), p.book.Asks(2)
+	if len(bids) == 0 || len(asks) == 0 {
+		return 0, fmt.Errorf("%s: empty order book side", p.params.CurrencyPair)
+	}
+
+	// Best rates without the order itself
+	bid, ask := bids[0].Rate, asks[0].Rate
+	if p.params.Type == "buy" && bid == own && bids[0].Amount <= ownAmount+util.Epsilon && len(bids) > 1 {
+		bid = bids[1].Rate
+	}
+	if p.params.Type == "sell" && ask == own && asks[0].Amount <= ownAmount+util.Epsilon && len(asks) > 1 {
+		ask = asks[1].Rate
+	}
+
+	var rate float64
+
+	switch {
+	case p.params.Peg == PegMid:
+		rate = (bid + ask) / 2
+	case (p.params.Peg == PegPrimary) == (p.params.Type == "buy"):
+		rate = bid
+	default:
+		rate = ask
+	}
+
+	// Post-only: never cross the spread
+	if p.params.Type == "buy" {
+		rate = math.Min(util.Floor(rate+p.params.Offset), util.Floor(ask-tick))
+		if p.params.LimitRate > 0 {
+			rate = math.Min(rate, p.params.LimitRate)
+		}
+	} else {
+		rate = math.Max(util.Ceil(rate-p.params.Offset), util.Ceil(bid+tick))
+		if p.params.LimitRate > 0 {
+			rate = math.Max(rate, p.params.LimitRate)
+		}
+	}
+
+	if rate <= 0 {
+		return 0, fmt.Errorf("wrong target rate: %v", rate)
+	}
+
+	return rate, nil
+}
+
+// follow places the order or moves it to the target rate.
+func (p *Pegged) follow() error {
+
+	rate, err := p.target()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	order, current, remaining, lastMove := p.order, p.rate, p.remaining, p.lastMove
+	p.mu.Unlock()
+
+	if remaining <= 0 {
+		return nil
+	}
+
+	if order == 0 {
+
+		place := p.client.BuyPostOnly
+		if p.params.Type == "sell" {
+			place = p.client.SellPostOnly
+		}
+
+		res, err := place(p.params.CurrencyPair, rate, remaining)
+		if err != nil {
+			return fmt.Errorf("place %s %.8f at %.8f: %v", p.params.Type, remaining, rate, err)
+		}
+
+		p.placed()
+
+		p.mu.Lock()
+		p.order, p.rate, p.lastMove = res.OrderNumber, rate, time.Now()
+		p.mu.Unlock()
+
+		return nil
+	}
+
+	if math.Abs(rate-current) < tick/2 || time.Since(lastMove) < p.params.MoveInterval {
+		return nil
+	}
+
+	// The order is moved with the amount still on the book: the remaining amount of
+	// the last check would overfill after a partial fill
+	if err := p.check(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	order, remaining = p.order, p.remaining
+	p.mu.Unlock()
+
+	if order == 0 {
+		return nil // left the book, placed again for the unfilled amount
+	}
+
+	res, err := p.client.MoveOrderPostOnly(order, rate, remaining)
+	if err != nil || !res.Success {
+		// Most likely filled meanwhile
+		return p.check()
+	}
+
+	logger.Debugf("%s: order %d moved from %.8f to %.8f (order %d)",
+		p.params.CurrencyPair, order, current, rate, res.OrderNumber)
+
+	p.mu.Lock()
+	p.previous = append(p.previous, order)
+	p.order, p.rate, p.lastMove = res.OrderNumber, rate, time.Now()
+	p.mu.Unlock()
+
+	return nil
+}
+
+func (p *Pegged) cancelOrder() error {
+
+	p.mu.Lock()
+	order := p.order
+	numbers := append(append([]int64(nil), p.previous...), p.order)
+	p.mu.Unlock()
+
+	if order != 0 {
+		if _, err := p.client.CancelOrder(order); err != nil && !util.IsOrderClosed(err) {
+			return fmt.Errorf("TradingClient.CancelOrder %d: %v", order, err)
+		}
+
+		p.mu.Lock()
+		p.order = 0
+		p.mu.Unlock()
+	}
+
+	for _, number := range numbers {
+		if number == 0 {
+			continue
+		}
+		if err := p.collect(p.client, number); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package execution
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+// Chart data period (seconds) of the volume profile
+const profilePeriod = 300
+
+// twapWeights returns equal weights for every slice.
+func twapWeights(slices int) []float64 {
+
+	weights := make([]float64, slices)
+	for i := range weights {
+		weights[i] = 1 / float64(slices)
+	}
+
+	return weights
+}
+
+// vwapWeights returns the weight of every slice of the execution window in the volume
+// traded during the same time of day over the previous days. Equal weights are
+// returned when no volume was traded.
+func vwapWeights(client *publicapi.Client, currencyPair string, start time.Time,
+	sliceDuration time.Duration, slices, days int) ([]float64, error) {
+
+	end := start.Add(time.Duration(slices) * sliceDuration)
+	from := start.Add(-time.Duration(days) * 24 * time.Hour)
+
+	data, err := client.GetChartData(currencyPair, from, end.Add(-24*time.Hour), profilePeriod)
+	if err != nil {
+		return nil, fmt.Errorf("PublicClient.GetChartData: %v", err)
+	}
+
+	weights := make([]float64, slices)
+	total := 0.0
+
+	for _, cs := range data {
+
+		if cs.Date == 0 {
+			continue // empty range
+		}
+
+		date := time.Unix(cs.Date, 0)
+
+		for day := 1; day <= days; day++ {
+
+			offset := date.Add(time.Duration(day) * 24 * time.Hour).Sub(start)
+			if offset < 0 || offset >= end.Sub(start) {
+				continue
+			}
+
+			weights[int(offset/sliceDuration)] += cs.Volume
+			total += cs.Volume
+		}
+	}
+
+	if total == 0 {
+		logger.Warnf("%s: no volume traded in the profile, TWAP weights used", currencyPair)
+		return twapWeights(slices), nil
+	}
+
+	for i := range weights {
+		weights[i] /= total
+	}
+
+	return weights, nil
+}
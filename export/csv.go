@@ -0,0 +1,64 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer, columns []*Column) (*csvEncoder, error) {
+
+	enc := csvEncoder{csv.NewWriter(w)}
+
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.Name
+	}
+
+	if err := enc.w.Write(header); err != nil {
+		return nil, fmt.Errorf("csv.Writer.Write: %v", err)
+	}
+
+	return &enc, nil
+}
+
+func (enc *csvEncoder) write(values []interface{}) error {
+
+	record := make([]string, len(values))
+
+	for i, v := range values {
+		switch v := v.(type) {
+		case int64:
+			record[i] = strconv.FormatInt(v, 10)
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case string:
+			record[i] = v
+		default:
+			return fmt.Errorf("csv: unsupported value type %T", v)
+		}
+	}
+
+	if err := enc.w.Write(record); err != nil {
+		return fmt.Errorf("csv.Writer.Write: %v", err)
+	}
+	return nil
+}
+
+func (enc *csvEncoder) flush() error {
+
+	enc.w.Flush()
+	if err := enc.w.Error(); err != nil {
+		return fmt.Errorf("csv.Writer.Flush: %v", err)
+	}
+	return nil
+}
+
+func (enc *csvEncoder) close() error {
+	return enc.flush()
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/export"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/pushapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+func main() {
+
+	exportChartData()
+
+	// exportTradeHistory()
+
+	// streamTicker()
+}
+
+// Export BTC_ETH 30min candlesticks the last 10 days to CSV with RFC3339 dates
+func exportChartData() {
+
+	client := publicapi.NewClient()
+
+	end := time.Now()
+	start := end.AddDate(0, 0, -10)
+	data, err := client.GetChartData("BTC_ETH", start, end, 1800)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Create("BTC_ETH.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	opts := export.Options{
+		Columns:    []string{"date", "open", "high", "low", "close", "volume"},
+		TimeFormat: time.RFC3339,
+	}
+
+	if err := export.Export(f, export.CSV, data, &opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Export our trade history for all markets the last 30 days to Parquet
+func exportTradeHistory() {
+
+	client, err := tradingapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	end := time.Now()
+	start := end.AddDate(0, 0, -30)
+	res, err := client.GetAllTradeHistory(start, end)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Create("trades.parquet")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	opts := export.Options{TimeFormat: export.UnixMilli}
+
+	if err := export.Export(f, export.Parquet, res, &opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Append the ticker to a CSV file for one minute
+func streamTicker() {
+
+	client, err := pushapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Create("ticker.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	w, err := export.NewWriter(f, export.CSV, export.TickTable, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ticker, err := client.SubscribeTicker()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(time.Minute)
+		client.UnsubscribeTicker()
+	}()
+
+	if err := export.StreamTicker(w, ticker, 5*time.Second); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
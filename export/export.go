@@ -0,0 +1,243 @@
+// Flat file export of market and account data.
+//
+// Data is exported as tables to CSV or Parquet. Every table has a fixed catalogue of
+// columns (see tables.go); Options select the columns exported and their order, and
+// how timestamps are written.
+//
+// Export writes a whole collection at once (ChartData, TradeHistory, ...). A Writer
+// appends rows one at a time and can be flushed, so push feeds can be written
+// continuously (see stream.go).
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+type Format int
+
+const (
+	CSV Format = iota
+	Parquet
+)
+
+// Timestamp formats besides Go time layouts (e.g. time.RFC3339)
+const (
+	Unix      = "unix"   // seconds since epoch
+	UnixMilli = "unixms" // milliseconds since epoch
+)
+
+type Options struct {
+	Columns    []string       // exported columns, in order (all columns when empty)
+	TimeFormat string         // Unix, UnixMilli or a time layout (Unix when empty)
+	Location   *time.Location // location of formatted timestamps (UTC when nil)
+}
+
+type Writer struct {
+	table   *Table
+	columns []*Column
+	opts    Options
+	enc     encoder
+}
+
+type encoder interface {
+	write(values []interface{}) error
+	flush() error
+	close() error
+}
+
+// NewWriter returns a writer of rows of table to w in the given format. The CSV
+// header (or Parquet schema) is derived from the selected columns.
+func NewWriter(w io.Writer, format Format, table *Table, opts *Options) (*Writer, error) {
+
+	res := Writer{table: table}
+	if opts != nil {
+		res.opts = *opts
+	}
+	if res.opts.TimeFormat == "" {
+		res.opts.TimeFormat = Unix
+	}
+	if res.opts.Location == nil {
+		res.opts.Location = time.UTC
+	}
+
+	columns, err := table.selectColumns(res.opts.Columns)
+	if err != nil {
+		return nil, err
+	}
+	res.columns = columns
+
+	switch format {
+	case CSV:
+		res.enc, err = newCSVEncoder(w, columns)
+	case Parquet:
+		res.enc, err = newParquetEncoder(w, columns, res.opts.TimeFormat)
+	default:
+		err = fmt.Errorf("Wrong format: %d", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// Write appends a row. Its type must be the row type of the writer table.
+func (w *Writer) Write(row interface{}) error {
+
+	if reflect.TypeOf(row) != w.table.rowType {
+		return fmt.Errorf("%s table: wrong row type %T", w.table.Name, row)
+	}
+
+	values := make([]interface{}, len(w.columns))
+	for i, c := range w.columns {
+		values[i] = w.value(c, row)
+	}
+
+	return w.enc.write(values)
+}
+
+// Flush writes the buffered rows (a row group for Parquet) to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.enc.flush()
+}
+
+// Close flushes the buffered rows and terminates the file (Parquet footer). The
+// underlying writer is not closed.
+func (w *Writer) Close() error {
+	return w.enc.close()
+}
+
+func (w *Writer) value(c *Column, row interface{}) interface{} {
+
+	v := c.value(row)
+
+	if c.Kind != Time {
+		return v
+	}
+
+	date := v.(int64)
+
+	switch w.opts.TimeFormat {
+	case Unix:
+		return date
+	case UnixMilli:
+		return date * 1000
+	default:
+		return time.Unix(date, 0).In(w.opts.Location).Format(w.opts.TimeFormat)
+	}
+}
+
+// Export writes data, one of publicapi.ChartData, publicapi.TradeHistory,
+// tradingapi.TradeHistory, tradingapi.AllTradeHistory, *tradingapi.DepositsWithdrawals
+// or tradingapi.CompleteBalances, to w in the given format.
+func Export(w io.Writer, format Format, data interface{}, opts *Options) error {
+
+	var table *Table
+	var rows []interface{}
+
+	switch data := data.(type) {
+
+	case publicapi.ChartData:
+		table = ChartDataTable
+		for _, cs := range data {
+			rows = append(rows, cs)
+		}
+
+	case publicapi.TradeHistory:
+		table = PublicTradeTable
+		for _, t := range data {
+			rows = append(rows, t)
+		}
+
+	case tradingapi.TradeHistory:
+		table = TradeTable
+		for _, t := range data {
+			rows = append(rows, &PairTrade{"", t})
+		}
+
+	case tradingapi.AllTradeHistory:
+		table = TradeTable
+		for _, pair := range sortedKeys(data) {
+			for _, t := range data[pair] {
+				rows = append(rows, &PairTrade{pair, t})
+			}
+		}
+
+	case *tradingapi.DepositsWithdrawals:
+		table = TransferTable
+		rows = transfers(data)
+
+	case tradingapi.CompleteBalances:
+		table = BalanceTable
+		for _, currency := range sortedKeys(data) {
+			rows = append(rows, &Balance{currency, data[currency]})
+		}
+
+	default:
+		return fmt.Errorf("Export: unsupported data type %T", data)
+	}
+
+	writer, err := NewWriter(w, format, table, opts)
+	if err != nil {
+		return fmt.Errorf("NewWriter: %v", err)
+	}
+
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("Writer.Write: %v", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("Writer.Close: %v", err)
+	}
+
+	return nil
+}
+
+func (t *Table) selectColumns(names []string) ([]*Column, error) {
+
+	if len(names) == 0 {
+		return t.Columns, nil
+	}
+
+	res := make([]*Column, 0, len(names))
+
+	for _, name := range names {
+
+		c := t.column(name)
+		if c == nil {
+			return nil, fmt.Errorf("%s table: unknown column %q", t.Name, name)
+		}
+		res = append(res, c)
+	}
+
+	if len(res) == 0 {
+		return nil, errors.New("no column selected")
+	}
+
+	return res, nil
+}
+
+// sortedKeys returns the keys of a map with string keys, sorted.
+func sortedKeys(m interface{}) []string {
+
+	keys := reflect.ValueOf(m).MapKeys()
+	res := make([]string, len(keys))
+
+	for i, k := range keys {
+		res[i] = k.String()
+	}
+	sort.Strings(res)
+
+	return res
+}
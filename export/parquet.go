@@ -0,0 +1,79 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Number of goroutines used by the parquet writer to encode columns
+const parquetParallelism = 4
+
+type parquetEncoder struct {
+	w *writer.CSVWriter
+}
+
+func newParquetEncoder(w io.Writer, columns []*Column, timeFormat string) (*parquetEncoder, error) {
+
+	metadata := make([]string, len(columns))
+
+	for i, c := range columns {
+
+		var typ string
+
+		switch c.Kind {
+		case Int:
+			typ = "type=INT64"
+		case Float:
+			typ = "type=DOUBLE"
+		case String:
+			typ = "type=BYTE_ARRAY, convertedtype=UTF8"
+		case Time:
+			switch timeFormat {
+			case Unix:
+				typ = "type=INT64"
+			case UnixMilli:
+				typ = "type=INT64, convertedtype=TIMESTAMP_MILLIS"
+			default:
+				typ = "type=BYTE_ARRAY, convertedtype=UTF8"
+			}
+		}
+
+		metadata[i] = fmt.Sprintf("name=%s, %s", c.Name, typ)
+	}
+
+	pw, err := writer.NewCSVWriterFromWriter(metadata, w, parquetParallelism)
+	if err != nil {
+		return nil, fmt.Errorf("writer.NewCSVWriterFromWriter: %v", err)
+	}
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+
+	return &parquetEncoder{pw}, nil
+}
+
+func (enc *parquetEncoder) write(values []interface{}) error {
+
+	if err := enc.w.Write(values); err != nil {
+		return fmt.Errorf("writer.CSVWriter.Write: %v", err)
+	}
+	return nil
+}
+
+// flush writes the buffered rows as a new row group.
+func (enc *parquetEncoder) flush() error {
+
+	if err := enc.w.Flush(true); err != nil {
+		return fmt.Errorf("writer.CSVWriter.Flush: %v", err)
+	}
+	return nil
+}
+
+func (enc *parquetEncoder) close() error {
+
+	if err := enc.w.WriteStop(); err != nil {
+		return fmt.Errorf("writer.CSVWriter.WriteStop: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package export
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/pushapi"
+)
+
+// StreamTicker appends the ticks received from ticker (see pushapi.SubscribeTicker)
+// to w (a TickTable writer), flushing every flushInterval. It returns when the
+// ticker is unsubscribed; w is then flushed but not closed.
+func StreamTicker(w *Writer, ticker pushapi.Ticker, flushInterval time.Duration) error {
+
+	flush := time.NewTicker(flushInterval)
+	defer flush.Stop()
+
+	for {
+		select {
+		case tick := <-ticker:
+			if tick == nil {
+				return w.Flush()
+			}
+			if err := w.Write(tick); err != nil {
+				return fmt.Errorf("Writer.Write: %v", err)
+			}
+
+		case <-flush.C:
+			if err := w.Flush(); err != nil {
+				return fmt.Errorf("Writer.Flush: %v", err)
+			}
+		}
+	}
+}
+
+// StreamTrades appends the trades received from updater (see pushapi.SubscribeMarket)
+// to w (a NewTradeTable writer), flushing every flushInterval. It returns when the
+// market is unsubscribed; w is then flushed but not closed.
+func StreamTrades(w *Writer, currencyPair string, updater pushapi.MarketUpdater, flushInterval time.Duration) error {
+
+	flush := time.NewTicker(flushInterval)
+	defer flush.Stop()
+
+	for {
+		select {
+		case updates := <-updater:
+			if updates == nil {
+				return w.Flush()
+			}
+
+			for _, update := range updates.Updates {
+
+				trade, ok := update.Data.(*pushapi.NewTrade)
+				if !ok {
+					continue
+				}
+
+				if err := w.Write(&MarketTrade{currencyPair, trade}); err != nil {
+					return fmt.Errorf("Writer.Write: %v", err)
+				}
+			}
+
+		case <-flush.C:
+			if err := w.Flush(); err != nil {
+				return fmt.Errorf("Writer.Flush: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package export
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/pushapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+type Kind int
+
+const (
+	Int Kind = iota
+	Float
+	String
+	Time // Unix timestamp, written according to Options.TimeFormat
+)
+
+type Column struct {
+	Name  string
+	Kind  Kind
+	value func(row interface{}) interface{}
+}
+
+type Table struct {
+	Name    string
+	Columns []*Column
+	rowType reflect.Type
+}
+
+func (t *Table) column(name string) *Column {
+
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// PairTrade is a row of TradeTable (CurrencyPair is empty for a single market history).
+type PairTrade struct {
+	CurrencyPair string
+	*tradingapi.Trade
+}
+
+// Balance is a row of BalanceTable.
+type Balance struct {
+	Currency string
+	*tradingapi.CompleteBalance
+}
+
+// Transfer is a row of TransferTable (a deposit or a withdrawal).
+type Transfer struct {
+	Type             string // "deposit" or "withdrawal"
+	Currency         string
+	Address          string
+	Amount           float64
+	Confirmations    int    // deposit only
+	TxId             string // deposit only
+	WithdrawalNumber int64  // withdrawal only
+	Timestamp        int64
+	Status           string
+	IpAddress        string // withdrawal only
+}
+
+// MarketTrade is a row of NewTradeTable.
+type MarketTrade struct {
+	CurrencyPair string
+	*pushapi.NewTrade
+}
+
+// Rows: *publicapi.CandleStick
+var ChartDataTable = &Table{
+	Name:    "chartData",
+	rowType: reflect.TypeOf(&publicapi.CandleStick{}),
+	Columns: []*Column{
+		{"date", Time, func(r interface{}) interface{} { return r.(*publicapi.CandleStick).Date }},
+		{"open", Float, func(r interface{}) interface{} { return r.(*publicapi.CandleStick).Open }},
+		{"high", Float, func(r interface{}) interface{} { return r.(*publicapi.CandleStick).High }},
+		{"low", Float, func(r interface{}) interface{} { return r.(*publicapi.CandleStick).Low }},
+		{"close", Float, func(r interface{}) interface{} { return r.(*publicapi.CandleStick).Close }},
+		{"volume", Float, func(r interface{}) interface{} { return r.(*publicapi.CandleStick).Volume }},
+		{"quoteVolume", Float, func(r interface{}) interface{} { return r.(*publicapi.CandleStick).QuoteVolume }},
+		{"weightedAverage", Float, func(r interface{}) interface{} { return r.(*publicapi.CandleStick).WeighedtAverage }},
+	},
+}
+
+// Rows: *publicapi.Trade
+var PublicTradeTable = &Table{
+	Name:    "publicTradeHistory",
+	rowType: reflect.TypeOf(&publicapi.Trade{}),
+	Columns: []*Column{
+		{"globalTradeID", Int, func(r interface{}) interface{} { return r.(*publicapi.Trade).GlobalTradeId }},
+		{"tradeID", Int, func(r interface{}) interface{} { return r.(*publicapi.Trade).TradeId }},
+		{"date", Time, func(r interface{}) interface{} { return r.(*publicapi.Trade).Date }},
+		{"type", String, func(r interface{}) interface{} { return r.(*publicapi.Trade).TypeOrder }},
+		{"rate", Float, func(r interface{}) interface{} { return r.(*publicapi.Trade).Rate }},
+		{"amount", Float, func(r interface{}) interface{} { return r.(*publicapi.Trade).Amount }},
+		{"total", Float, func(r interface{}) interface{} { return r.(*publicapi.Trade).Total }},
+	},
+}
+
+// Rows: *PairTrade
+var TradeTable = &Table{
+	Name:    "tradeHistory",
+	rowType: reflect.TypeOf(&PairTrade{}),
+	Columns: []*Column{
+		{"currencyPair", String, func(r interface{}) interface{} { return r.(*PairTrade).CurrencyPair }},
+		{"globalTradeID", Int, func(r interface{}) interface{} { return r.(*PairTrade).GlobalTradeId }},
+		{"tradeID", Int, func(r interface{}) interface{} { return r.(*PairTrade).TradeId }},
+		{"orderNumber", Int, func(r interface{}) interface{} { return r.(*PairTrade).OrderNumber }},
+		{"date", Time, func(r interface{}) interface{} { return r.(*PairTrade).Date }},
+		{"type", String, func(r interface{}) interface{} { return r.(*PairTrade).TypeOrder }},
+		{"category", String, func(r interface{}) interface{} { return r.(*PairTrade).Category }},
+		{"rate", Float, func(r interface{}) interface{} { return r.(*PairTrade).Rate }},
+		{"amount", Float, func(r interface{}) interface{} { return r.(*PairTrade).Amount }},
+		{"total", Float, func(r interface{}) interface{} { return r.(*PairTrade).Total }},
+		{"fee", Float, func(r interface{}) interface{} { return r.(*PairTrade).Fee }},
+	},
+}
+
+// Rows: *Transfer
+var TransferTable = &Table{
+	Name:    "depositsWithdrawals",
+	rowType: reflect.TypeOf(&Transfer{}),
+	Columns: []*Column{
+		{"type", String, func(r interface{}) interface{} { return r.(*Transfer).Type }},
+		{"currency", String, func(r interface{}) interface{} { return r.(*Transfer).Currency }},
+		{"address", String, func(r interface{}) interface{} { return r.(*Transfer).Address }},
+		{"amount", Float, func(r interface{}) interface{} { return r.(*Transfer).Amount }},
+		{"confirmations", Int, func(r interface{}) interface{} { return int64(r.(*Transfer).Confirmations) }},
+		{"txid", String, func(r interface{}) interface{} { return r.(*Transfer).TxId }},
+		{"withdrawalNumber", Int, func(r interface{}) interface{} { return r.(*Transfer).WithdrawalNumber }},
+		{"timestamp", Time, func(r interface{}) interface{} { return r.(*Transfer).Timestamp }},
+		{"status", String, func(r interface{}) interface{} { return r.(*Transfer).Status }},
+		{"ipAddress", String, func(r interface{}) interface{} { return r.(*Transfer).IpAddress }},
+	},
+}
+
+// Rows: *Balance
+var BalanceTable = &Table{
+	Name:    "completeBalances",
+	rowType: reflect.TypeOf(&Balance{}),
+	Columns: []*Column{
+		{"currency", String, func(r interface{}) interface{} { return r.(*Balance).Currency }},
+		{"available", Float, func(r interface{}) interface{} { return r.(*Balance).Available }},
+		{"onOrders", Float, func(r interface{}) interface{} { return r.(*Balance).OnOrders }},
+		{"btcValue", Float, func(r interface{}) interface{} { return r.(*Balance).BtcValue }},
+	},
+}
+
+// Rows: *pushapi.Tick
+var TickTable = &Table{
+	Name:    "ticker",
+	rowType: reflect.TypeOf(&pushapi.Tick{}),
+	Columns: []*Column{
+		{"currencyPair", String, func(r interface{}) interface{} { return r.(*pushapi.Tick).CurrencyPair }},
+		{"last", Float, func(r interface{}) interface{} { return r.(*pushapi.Tick).Last }},
+		{"lowestAsk", Float, func(r interface{}) interface{} { return r.(*pushapi.Tick).LowestAsk }},
+		{"highestBid", Float, func(r interface{}) interface{} { return r.(*pushapi.Tick).HighestBid }},
+		{"percentChange", Float, func(r interface{}) interface{} { return r.(*pushapi.Tick).PercentChange }},
+		{"baseVolume", Float, func(r interface{}) interface{} { return r.(*pushapi.Tick).BaseVolume }},
+		{"quoteVolume", Float, func(r interface{}) interface{} { return r.(*pushapi.Tick).QuoteVolume }},
+		{"high24hr", Float, func(r interface{}) interface{} { return r.(*pushapi.Tick).High24hr }},
+		{"low24hr", Float, func(r interface{}) interface{} { return r.(*pushapi.Tick).Low24hr }},
+	},
+}
+
+// Rows: *MarketTrade
+var NewTradeTable = &Table{
+	Name:    "newTrade",
+	rowType: reflect.TypeOf(&MarketTrade{}),
+	Columns: []*Column{
+		{"currencyPair", String, func(r interface{}) interface{} { return r.(*MarketTrade).CurrencyPair }},
+		{"tradeID", Int, func(r interface{}) interface{} { return r.(*MarketTrade).TradeId }},
+		{"date", Time, func(r interface{}) interface{} { return r.(*MarketTrade).Date }},
+		{"type", String, func(r interface{}) interface{} { return r.(*MarketTrade).TypeOrder }},
+		{"rate", Float, func(r interface{}) interface{} { return r.(*MarketTrade).Rate }},
+		{"amount", Float, func(r interface{}) interface{} { return r.(*MarketTrade).Amount }},
+		{"total", Float, func(r interface{}) interface{} { return r.(*MarketTrade).Total }},
+	},
+}
+
+// transfers returns the deposits and withdrawals rows sorted by timestamp.
+func transfers(dw *tradingapi.DepositsWithdrawals) []interface{} {
+
+	var res []*Transfer
+
+	for _, d := range dw.Deposits {
+		res = append(res, &Transfer{
+			Type:          "deposit",
+			Currency:      d.Currency,
+			Address:       d.Address,
+			Amount:        d.Amount,
+			Confirmations: d.Confirmations,
+			TxId:          d.TxId,
+			Timestamp:     d.Timestamp,
+			Status:        d.Status,
+		})
+	}
+
+	for _, w := range dw.Withdrawals {
+		res = append(res, &Transfer{
+			Type:             "withdrawal",
+			Currency:         w.Currency,
+			Address:          w.Address,
+			Amount:           w.Amount,
+			WithdrawalNumber: w.WithdrawalNumber,
+			Timestamp:        w.Timestamp,
+			Status:           w.Status,
+			IpAddress:        w.IpAddress,
+		})
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Timestamp < res[j].Timestamp
+	})
+
+	rows := make([]interface{}, len(res))
+	for i, t := range res {
+		rows[i] = t
+	}
+	return rows
+}
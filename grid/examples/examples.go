@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/joemocquant/poloniex-api/grid"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+func main() {
+
+	tradingClient, err := tradingapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	publicClient := publicapi.NewClient()
+
+	runGrid(tradingClient, publicClient)
+}
+
+// Grid of 11 levels between 0.03 and 0.04 BTC trading 0.1 ETH per level, until
+// interrupted (the orders are then cancelled)
+func runGrid(tradingClient *tradingapi.Client, publicClient *publicapi.Client) {
+
+	params := grid.Params{
+		CurrencyPair: "BTC_ETH",
+		Lower:        0.03,
+		Upper:        0.04,
+		Levels:       11,
+		Amount:       0.1,
+	}
+
+	bot, err := grid.NewBot(tradingClient, publicClient, params)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := bot.Start(); err != nil {
+		log.Println(err)
+	}
+
+	for _, l := range bot.Levels() {
+		fmt.Printf("%.8f %4s %d\n", l.Rate, l.Type, l.OrderNumber)
+	}
+
+	done := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	go func() {
+		<-interrupt
+		close(done)
+	}()
+
+	bot.Run(done)
+
+	if err := bot.Cancel(); err != nil {
+		log.Println(err)
+	}
+
+	stats := bot.Stats()
+	fmt.Printf("%d fills, %d round trips, profit %.8f BTC (fees %.8f BTC)\n",
+		stats.Fills, stats.RoundTrips, stats.Profit, stats.Fees)
+}
@@ -0,0 +1,444 @@
+// Grid trading bot.
+//
+// A Bot lays out post-only orders on rate levels between a lower and an upper bound:
+// buys below the market rate, sells above it, the level nearest the market rate left
+// empty. When a buy fills, a sell is placed one level above; when a sell fills, a buy
+// is placed one level below. Each such pair of fills is a round trip whose profit
+// (the level spacing times the amount, net of the maker fees from GetFeeInfo) is
+// accumulated in the base currency of the pair (BTC for BTC_XMR).
+//
+// The grid is not persisted: on start, the open orders of the market found at grid
+// rates are adopted, so that a stopped bot can be restarted without duplicating its
+// orders.
+package grid
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:grid]")
+
+// Open orders check period when zero in Params
+const defaultPollInterval = 30 * time.Second
+
+type Params struct {
+	CurrencyPair string
+	Lower        float64 // lowest level rate
+	Upper        float64 // highest level rate
+	Levels       int     // number of levels, bounds included
+	Amount       float64 // order amount of every level
+	Geometric    bool    // constant ratio between levels instead of constant spacing
+	PollInterval time.Duration
+}
+
+type Level struct {
+	Rate        float64
+	Type        string // buy, sell or empty
+	OrderNumber int64  // 0 when empty or not placed yet
+	// Rate of the fill which caused the order, 0 for orders of the initial layout: the
+	// fill of the order completes a round trip when set
+	FillRate float64
+}
+
+// Stats amounts are order totals (rate * amount), in the base currency of the pair:
+// BTC for BTC_XMR. Fees paid in the quote currency on buys are valued at the order
+// rate.
+type Stats struct {
+	Fills      int
+	RoundTrips int
+	Volume     float64 // total of the fills
+	Fees       float64 // maker fees of the fills
+	Profit     float64 // profit of the round trips net of their fees
+}
+
+type Bot struct {
+	client *tradingapi.Client
+	public *publicapi.Client
+	params Params
+
+	mu       sync.Mutex
+	levels   []*Level
+	makerFee float64
+	stats    Stats
+}
+
+// NewBot returns a grid bot sending orders with client. public is used to load the
+// market rate when the grid is laid out.
+func NewBot(client *tradingapi.Client, public *publicapi.Client, params Params) (*Bot, error) {
+
+	switch {
+	case params.CurrencyPair == "":
+		return nil, fmt.Errorf("Wrong currency pair parameter")
+	case params.Lower <= 0 || params.Upper <= params.Lower:
+		return nil, fmt.Errorf("Wrong bounds parameters: %v, %v", params.Lower, params.Upper)
+	case params.Levels < 2:
+		return nil, fmt.Errorf("Wrong levels parameter: %d", params.Levels)
+	case params.Amount <= 0:
+		return nil, fmt.Errorf("Wrong amount parameter: %v", params.Amount)
+	}
+
+	if params.PollInterval == 0 {
+		params.PollInterval = defaultPollInterval
+	}
+
+	b := Bot{
+		client: client,
+		public: public,
+		params: params,
+	}
+
+	ratio := math.Pow(params.Upper/params.Lower, 1/float64(params.Levels-1))
+	step := (params.Upper - params.Lower) / float64(params.Levels-1)
+
+	for i := 0; i < params.Levels; i++ {
+
+		rate := params.Lower + step*float64(i)
+		if params.Geometric {
+			rate = params.Lower * math.Pow(ratio, float64(i))
+		}
+
+		rate = util.Floor(rate)
+		if i > 0 && rate <= b.levels[i-1].Rate {
+			return nil, fmt.Errorf("levels too close: %d levels between %v and %v",
+				params.Levels, params.Lower, params.Upper)
+		}
+
+		b.levels = append(b.levels, &Level{Rate: rate})
+	}
+
+	return &b, nil
+}
+
+// Start loads the maker fee and lays out the grid, adopting the open orders of the
+// market found at grid rates.
+func (b *Bot) Start() error {
+
+	fees, err := b.client.GetFeeInfo()
+	if err != nil {
+		return fmt.Errorf("TradingClient.GetFeeInfo: %v", err)
+	}
+
+	ticks, err := b.public.GetTickers()
+	if err != nil {
+		return fmt.Errorf("PublicClient.GetTickers: %v", err)
+	}
+
+	tick, ok := ticks[b.params.CurrencyPair]
+	if !ok || tick.Last == 0 {
+		return fmt.Errorf("no ticker for %s", b.params.CurrencyPair)
+	}
+
+	open, err := b.client.GetOpenOrders(b.params.CurrencyPair)
+	if err != nil {
+		return fmt.Errorf("TradingClient.GetOpenOrders: %v", err)
+	}
+
+	b.mu.Lock()
+
+	b.makerFee = fees.MakerFee
+	b.reconcile(*open, tick.Last)
+
+	b.mu.Unlock()
+
+	return b.place()
+}
+
+// reconcile adopts the open orders at grid rates and assigns a side to the other
+// levels from the market rate. It must be called with b.mu held.
+func (b *Bot) reconcile(open tradingapi.OpenOrders, rate float64) {
+
+	// Level nearest the market rate, left empty unless an order is found there
+	nearest := 0
+	for i, l := range b.levels {
+		if math.Abs(l.Rate-rate) < math.Abs(b.levels[nearest].Rate-rate) {
+			nearest = i
+		}
+	}
+
+	adopted := make(map[int64]bool)
+
+	for i, l := range b.levels {
+
+		l.Type, l.OrderNumber, l.FillRate = "", 0, 0
+
+		for _, o := range open {
+			if !adopted[o.OrderNumber] && math.Abs(o.Rate-l.Rate) < util.Epsilon {
+				l.Type, l.OrderNumber = o.Type, o.OrderNumber
+				adopted[o.OrderNumber] = true
+				break
+			}
+		}
+
+		switch {
+		case l.OrderNumber != 0:
+			logger.Infof("%s: %s order %d adopted at %.8f",
+				b.params.CurrencyPair, l.Type, l.OrderNumber, l.Rate)
+		case i == nearest:
+		case l.Rate < rate:
+			l.Type = "buy"
+		default:
+			l.Type = "sell"
+		}
+	}
+
+	for _, o := range open {
+		if !adopted[o.OrderNumber] {
+			logger.Warnf("%s: %s order %d at %.8f is not on the grid, ignored",
+				b.params.CurrencyPair, o.Type, o.OrderNumber, o.Rate)
+		}
+	}
+}
+
+// Run checks the grid orders every poll interval until done is closed. Orders are
+// left on the book when it returns: use Cancel to remove them.
+func (b *Bot) Run(done <-chan struct{}) {
+
+	ticker := time.NewTicker(b.params.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := b.Check(); err != nil {
+				logger.WithField("error", err).Error("Bot.Check")
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// Check replaces the orders which left the book on the opposite side, and places the
+// orders which could not be placed before (e.g. post-only orders rejected because the
+// market crossed their rate).
+func (b *Bot) Check() error {
+
+	open, err := b.client.GetOpenOrders(b.params.CurrencyPair)
+	if err != nil {
+		return fmt.Errorf("TradingClient.GetOpenOrders: %v", err)
+	}
+
+	onBook := make(map[int64]bool)
+	for _, o := range *open {
+		onBook[o.OrderNumber] = true
+	}
+
+	b.mu.Lock()
+	var gone []int
+	for i, l := range b.levels {
+		if l.OrderNumber != 0 && !onBook[l.OrderNumber] {
+			gone = append(gone, i)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, i := range gone {
+		if err := b.filled(i); err != nil {
+			return err
+		}
+	}
+
+	return b.place()
+}
+
+// filled handles the order of level i which left the book.
+func (b *Bot) filled(i int) error {
+
+	b.mu.Lock()
+	l := *b.levels[i]
+	b.mu.Unlock()
+
+	// Any error but "order not found" (no trade) keeps the level until the next check:
+	// a filled order taken as cancelled would be placed again
+	trades, err := b.client.GetTradesFromOrder(l.OrderNumber)
+	if err != nil && !util.IsNoTrades(err) {
+		return fmt.Errorf("TradingClient.GetTradesFromOrder: %v", err)
+	}
+
+	amount, total := 0.0, 0.0
+	for _, t := range trades {
+		amount += t.Amount
+		total += t.Total
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	level := b.levels[i]
+
+	if amount < util.Epsilon {
+		// Cancelled outside the bot: placed again at the next check
+		logger.Warnf("%s: %s order %d at %.8f left the book without trade",
+			b.params.CurrencyPair, l.Type, l.OrderNumber, l.Rate)
+		level.OrderNumber = 0
+		return nil
+	}
+
+	if amount < b.params.Amount-util.Epsilon {
+		logger.Warnf("%s: %s order %d at %.8f left the book partially filled (%.8f)",
+			b.params.CurrencyPair, l.Type, l.OrderNumber, l.Rate, amount)
+	}
+
+	fee := total * b.makerFee
+
+	b.stats.Fills++
+	b.stats.Volume += total
+	b.stats.Fees += fee
+
+	if l.FillRate != 0 {
+		// Round trip: the fill which caused this order paid fees on its own total
+		profit := math.Abs(l.Rate-l.FillRate)*amount - fee - l.FillRate*amount*b.makerFee
+		b.stats.RoundTrips++
+		b.stats.Profit += profit
+		logger.Infof("%s: round trip %.8f -> %.8f, profit %.8f",
+			b.params.CurrencyPair, l.FillRate, l.Rate, profit)
+	}
+
+	level.Type, level.OrderNumber, level.FillRate = "", 0, 0
+
+	// Replaced on the opposite side
+	next, typeOrder := i+1, "sell"
+	if l.Type == "sell" {
+		next, typeOrder = i-1, "buy"
+	}
+
+	if next < 0 || next >= len(b.levels) {
+		logger.Warnf("%s: %s filled at the grid bound %.8f", b.params.CurrencyPair, l.Type, l.Rate)
+		return nil
+	}
+
+	if other := b.levels[next]; other.Type == "" {
+		other.Type, other.FillRate = typeOrder, l.Rate
+	} else {
+		logger.Warnf("%s: level %.8f already has a %s order",
+			b.params.CurrencyPair, other.Rate, other.Type)
+	}
+
+	return nil
+}
+
+// place places the orders of the levels with a side but no order.
+func (b *Bot) place() error {
+
+	b.mu.Lock()
+	var pending []int
+	for i, l := range b.levels {
+		if l.Type != "" && l.OrderNumber == 0 {
+			pending = append(pending, i)
+		}
+	}
+	b.mu.Unlock()
+
+	var errs []error
+
+	for _, i := range pending {
+
+		b.mu.Lock()
+		l := *b.levels[i]
+		b.mu.Unlock()
+
+		place := b.client.BuyPostOnly
+		if l.Type == "sell" {
+			place = b.client.SellPostOnly
+		}
+
+		res, err := place(b.params.CurrencyPair, l.Rate, b.params.Amount)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("place %s %.8f at %.8f: %v",
+				l.Type, b.params.Amount, l.Rate, err))
+			continue
+		}
+
+		logger.Debugf("%s: %s order %d placed at %.8f",
+			b.params.CurrencyPair, l.Type, res.OrderNumber, l.Rate)
+
+		b.mu.Lock()
+		b.levels[i].OrderNumber = res.OrderNumber
+		b.mu.Unlock()
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d orders not placed, first error: %v", len(errs), errs[0])
+	}
+
+	return nil
+}
+
+// Cancel cancels the orders of the grid. Run must be stopped first, otherwise the
+// next check places them again.
+func (b *Bot) Cancel() error {
+
+	b.mu.Lock()
+	var numbers []int64
+	for _, l := range b.levels {
+		if l.OrderNumber != 0 {
+			numbers = append(numbers, l.OrderNumber)
+		}
+	}
+	b.mu.Unlock()
+
+	report, err := b.client.CancelWhere(func(currencyPair string, o *tradingapi.OpenOrder) bool {
+
+		if currencyPair != b.params.CurrencyPair {
+			return false
+		}
+
+		for _, number := range numbers {
+			if o.OrderNumber == number {
+				return true
+			}
+		}
+
+		return false
+	})
+
+	if err != nil {
+		return fmt.Errorf("TradingClient.CancelWhere: %v", err)
+	}
+
+	b.mu.Lock()
+	for _, outcome := range report.Outcomes {
+		if outcome.Err != nil {
+			continue
+		}
+		for _, l := range b.levels {
+			if l.OrderNumber == outcome.Order.OrderNumber {
+				l.OrderNumber = 0
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	return report.Err()
+}
+
+// Levels returns a copy of the grid levels, lowest rate first.
+func (b *Bot) Levels() []*Level {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	res := make([]*Level, len(b.levels))
+	for i, l := range b.levels {
+		c := *l
+		res[i] = &c
+	}
+
+	return res
+}
+
+func (b *Bot) Stats() Stats {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.stats
+}
@@ -0,0 +1,62 @@
+package guard
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Address and payment id formats of the main currencies. Currencies missing from
+// AddressFormats are accepted as is unless Policy.StrictFormats is set. Entries may
+// be added or replaced before use.
+var (
+	AddressFormats = map[string]*regexp.Regexp{
+		"BTC":  regexp.MustCompile(`^([13][1-9A-HJ-NP-Za-km-z]{25,34}|bc1[02-9ac-hj-np-z]{11,71})$`),
+		"LTC":  regexp.MustCompile(`^([LM3][1-9A-HJ-NP-Za-km-z]{26,33}|ltc1[02-9ac-hj-np-z]{11,71})$`),
+		"DOGE": regexp.MustCompile(`^[DA9][1-9A-HJ-NP-Za-km-z]{33}$`),
+		"DASH": regexp.MustCompile(`^[X7][1-9A-HJ-NP-Za-km-z]{33}$`),
+		"BCH":  regexp.MustCompile(`^([13][1-9A-HJ-NP-Za-km-z]{25,34}|(bitcoincash:)?[qp][02-9ac-hj-np-z]{41})$`),
+		"ZEC":  regexp.MustCompile(`^t[13][1-9A-HJ-NP-Za-km-z]{33}$`),
+		"ETH":  regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`),
+		"ETC":  regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`),
+		"XMR":  regexp.MustCompile(`^[48][1-9A-HJ-NP-Za-km-z]{94}$`),
+		"XRP":  regexp.MustCompile(`^r[1-9A-HJ-NP-Za-km-z]{24,34}$`),
+		"STR":  regexp.MustCompile(`^G[A-Z2-7]{55}$`),
+	}
+
+	PaymentIdFormats = map[string]*regexp.Regexp{
+		"XMR": regexp.MustCompile(`^([0-9a-fA-F]{16}|[0-9a-fA-F]{64})$`),
+		"XRP": regexp.MustCompile(`^[0-9]{1,10}$`), // destination tag
+		"STR": regexp.MustCompile(`^.{1,28}$`),     // memo
+	}
+)
+
+// ValidateAddress checks address and paymentId (may be empty) against the formats of
+// currency. strict rejects currencies without known address format.
+func ValidateAddress(currency, address, paymentId string, strict bool) error {
+
+	if address == "" || strings.TrimSpace(address) != address {
+		return &ErrAddressFormat{currency, address, "empty or surrounded by spaces"}
+	}
+
+	if format, ok := AddressFormats[currency]; ok {
+		if !format.MatchString(address) {
+			return &ErrAddressFormat{currency, address, "does not match " + format.String()}
+		}
+	} else if strict {
+		return &ErrAddressFormat{currency, address, "no known address format"}
+	}
+
+	if paymentId == "" {
+		return nil
+	}
+
+	if format, ok := PaymentIdFormats[currency]; ok {
+		if !format.MatchString(paymentId) {
+			return &ErrAddressFormat{currency, address, "payment id " + paymentId + " does not match " + format.String()}
+		}
+	} else if strict {
+		return &ErrAddressFormat{currency, address, "no payment id for " + currency}
+	}
+
+	return nil
+}
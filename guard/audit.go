@@ -0,0 +1,98 @@
+package guard
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+type Stage string
+
+const (
+	Requested Stage = "requested" // passed the checks, waiting for approval
+	Denied    Stage = "denied"    // failed a check (whitelist, format, caps)
+	Approved  Stage = "approved"
+	Rejected  Stage = "rejected" // by the approver, a wrong token or on expiry
+	Submitted Stage = "submitted"
+	Failed    Stage = "failed" // error of the withdraw command
+)
+
+// A Record is written to the audit log at every stage of a withdrawal attempt.
+type Record struct {
+	Time      time.Time `json:"time"`
+	Id        int64     `json:"id"`
+	Stage     Stage     `json:"stage"`
+	Currency  string    `json:"currency"`
+	Amount    float64   `json:"amount"`
+	Address   string    `json:"address"`
+	PaymentId string    `json:"paymentId,omitempty"`
+	Reason    string    `json:"reason,omitempty"`   // check, rejection or API error
+	Response  string    `json:"response,omitempty"` // of the withdraw command
+}
+
+// An Audit stores records. A withdrawal is not submitted when its records can not be
+// written.
+type Audit interface {
+	Write(r *Record) error
+}
+
+// JSONAudit writes records to w as JSON lines.
+type JSONAudit struct {
+	mu   sync.Mutex
+	w    io.Writer
+	enc  *json.Encoder
+	file *os.File // synced after every record
+}
+
+func NewJSONAudit(w io.Writer) *JSONAudit {
+	return &JSONAudit{w: w, enc: json.NewEncoder(w)}
+}
+
+// NewFileAudit returns a JSON lines audit appending to the file at path. Every record
+// is synced to disk.
+func NewFileAudit(path string) (*JSONAudit, error) {
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("os.OpenFile: %v", err)
+	}
+
+	a := NewJSONAudit(f)
+	a.file = f
+
+	return a, nil
+}
+
+func (a *JSONAudit) Write(r *Record) error {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.enc.Encode(r); err != nil {
+		return fmt.Errorf("json.Encoder.Encode: %v", err)
+	}
+
+	if a.file != nil {
+		if err := a.file.Sync(); err != nil {
+			return fmt.Errorf("os.File.Sync: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Close closes the underlying writer when it is a io.Closer.
+func (a *JSONAudit) Close() error {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if c, ok := a.w.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
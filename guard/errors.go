@@ -0,0 +1,112 @@
+package guard
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrNotWhitelisted is returned when the address is not in the whitelist of the
+// currency.
+type ErrNotWhitelisted struct {
+	Currency  string
+	Address   string
+	PaymentId string
+}
+
+func (e *ErrNotWhitelisted) Error() string {
+
+	if e.PaymentId != "" {
+		return fmt.Sprintf("%s address %s (payment id %s) not whitelisted", e.Currency, e.Address, e.PaymentId)
+	}
+	return fmt.Sprintf("%s address %s not whitelisted", e.Currency, e.Address)
+}
+
+// ErrAddressFormat is returned when the address or the payment id does not match the
+// format of the currency.
+type ErrAddressFormat struct {
+	Currency string
+	Address  string
+	Reason   string
+}
+
+func (e *ErrAddressFormat) Error() string {
+	return fmt.Sprintf("wrong %s address %s: %s", e.Currency, e.Address, e.Reason)
+}
+
+// ErrMaxAmount is returned when the amount exceeds the per withdrawal cap.
+type ErrMaxAmount struct {
+	Currency string
+	Amount   float64
+	Max      float64
+}
+
+func (e *ErrMaxAmount) Error() string {
+	return fmt.Sprintf("withdrawal of %.8f %s above maximum %.8f", e.Amount, e.Currency, e.Max)
+}
+
+// ErrDailyLimit is returned when the withdrawal would take the amount withdrawn since
+// 00:00 UTC above the daily cap.
+type ErrDailyLimit struct {
+	Currency  string
+	Amount    float64
+	Withdrawn float64 // since 00:00 UTC, before this withdrawal
+	Max       float64
+}
+
+func (e *ErrDailyLimit) Error() string {
+	return fmt.Sprintf("withdrawal of %.8f %s with %.8f already withdrawn today above daily limit %.8f",
+		e.Amount, e.Currency, e.Withdrawn, e.Max)
+}
+
+// ErrApprovalRequired is returned by Withdraw and WithdrawWithPaymentId without
+// approver: the request is pending until Approve is called with its token.
+type ErrApprovalRequired struct {
+	Request *Request
+}
+
+func (e *ErrApprovalRequired) Error() string {
+	return fmt.Sprintf("withdrawal request %d requires approval", e.Request.Id)
+}
+
+// ErrRejected is returned when the approver rejects the request.
+type ErrRejected struct {
+	Id     int64
+	Reason string
+}
+
+func (e *ErrRejected) Error() string {
+	return fmt.Sprintf("withdrawal request %d rejected: %s", e.Id, e.Reason)
+}
+
+// ErrApproval is returned by Approve for an unknown or expired request, or a wrong
+// token.
+type ErrApproval struct {
+	Id     int64
+	Reason string
+}
+
+func (e *ErrApproval) Error() string {
+	return fmt.Sprintf("withdrawal request %d: %s", e.Id, e.Reason)
+}
+
+// ErrExpired is returned by Approve when the approval timeout elapsed.
+type ErrExpired struct {
+	Id      int64
+	Expired time.Time
+}
+
+func (e *ErrExpired) Error() string {
+	return fmt.Sprintf("withdrawal request %d expired at %s", e.Id, e.Expired.Format(time.RFC3339))
+}
+
+// isViolation reports whether err is a check of the policy failing, rather than a
+// failure to run the checks.
+func isViolation(err error) bool {
+
+	switch err.(type) {
+	case *ErrNotWhitelisted, *ErrAddressFormat, *ErrMaxAmount, *ErrDailyLimit:
+		return true
+	default:
+		return false
+	}
+}
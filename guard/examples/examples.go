@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joemocquant/poloniex-api/guard"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+var client *guard.Client
+
+func main() {
+
+	tradingClient, err := tradingapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	audit, err := guard.NewFileAudit("withdrawals.jsonl")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer audit.Close()
+
+	policy := guard.Policy{
+		Whitelist: map[string][]guard.Destination{
+			"BTC": {{Address: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"}},
+		},
+		MaxAmount: map[string]float64{"BTC": 0.5},
+		MaxDaily:  map[string]float64{"BTC": 1},
+	}
+
+	client, err = guard.NewClient(tradingClient, policy, audit)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	withdrawTwoSteps()
+
+	// withdrawNotWhitelisted()
+}
+
+// Request a withdrawal, then approve it with the token typed by the operator
+func withdrawTwoSteps() {
+
+	r, err := client.Request("BTC", 0.01, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "request %d, token %s\napproval token: ", r.Id, r.Token)
+
+	var token string
+	fmt.Scanln(&token)
+
+	res, err := client.Approve(r.Id, token)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(res.Response)
+}
+
+// Try to withdraw to an address missing from the whitelist
+func withdrawNotWhitelisted() {
+
+	_, err := client.Withdraw("BTC", 0.01, "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy")
+
+	switch err := err.(type) {
+	case *guard.ErrNotWhitelisted:
+		fmt.Printf("denied: %s not whitelisted\n", err.Address)
+	default:
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,474 @@
+// Withdrawal safety layer.
+//
+// A Client wraps a trading client and checks every withdrawal before sending it: the
+// destination must be in the whitelist of the currency, the address and payment id
+// must match the format of the currency, and the amount must be within the per
+// withdrawal and daily caps (since 00:00 UTC, from the withdrawal history plus the
+// withdrawals sent by the client that it does not list yet).
+//
+// A withdrawal passing the checks is submitted only once approved: either by the
+// Approver callback of the policy, or in two steps with Request, which returns a
+// random token, then Approve with that token (e.g. handed out of band to an
+// operator). Every attempt is written to an Audit at every stage.
+//
+// The Client has the methods of tradingapi.Client, with Withdraw and
+// WithdrawWithPaymentId replaced by their guarded version.
+package guard
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:guard]")
+
+const defaultApprovalTimeout = 15 * time.Minute
+
+// Maximum difference between the time a withdrawal is sent and its timestamp in the
+// withdrawal history
+const historyMatchWindow = 10 * time.Minute
+
+// A Destination of the whitelist. An empty PaymentId allows any payment id.
+type Destination struct {
+	Address   string
+	PaymentId string
+}
+
+type Policy struct {
+	// Allowed destinations by currency: currencies missing can not be withdrawn
+	Whitelist map[string][]Destination
+	// Caps by currency, missing currencies are not capped
+	MaxAmount map[string]float64 // per withdrawal
+	MaxDaily  map[string]float64 // since 00:00 UTC, pending requests included
+	// Reject currencies without known address format (see AddressFormats)
+	StrictFormats bool
+	// Approves (nil error) or rejects a request. When nil, Withdraw and
+	// WithdrawWithPaymentId return ErrApprovalRequired and Approve must be called.
+	Approver func(r *Request) error
+	// Time for approving a request, 15 minutes by default
+	ApprovalTimeout time.Duration
+}
+
+// A Request is a withdrawal which passed the checks, waiting for approval.
+type Request struct {
+	Id        int64
+	Currency  string
+	Amount    float64
+	Address   string
+	PaymentId string
+	Time      time.Time
+	Expires   time.Time
+	Token     string // to be passed to Approve
+}
+
+// tradingClient is embedded under an unexported name: its methods are promoted, but
+// the unguarded Withdraw and WithdrawWithPaymentId can not be reached through the
+// field from other packages.
+type tradingClient = tradingapi.Client
+
+type Client struct {
+	*tradingClient
+
+	policy Policy
+	audit  Audit
+
+	// Held while a withdrawal is checked and sent, so that concurrent withdrawals are
+	// checked against each other
+	mu        sync.Mutex
+	lastId    int64
+	pending   map[int64]*Request
+	withdrawn []*withdrawn
+}
+
+type withdrawn struct {
+	date     time.Time
+	currency string
+	amount   float64
+}
+
+// NewClient returns a client checking the withdrawals sent with client against policy
+// and writing every attempt to audit.
+func NewClient(client *tradingapi.Client, policy Policy, audit Audit) (*Client, error) {
+
+	if audit == nil {
+		return nil, fmt.Errorf("Wrong audit parameter: nil")
+	}
+
+	if policy.ApprovalTimeout <= 0 {
+		policy.ApprovalTimeout = defaultApprovalTimeout
+	}
+
+	c := Client{
+		tradingClient: client,
+		policy:        policy,
+		audit:         audit,
+		lastId:        time.Now().UnixNano() / int64(time.Millisecond),
+		pending:       make(map[int64]*Request),
+	}
+
+	return &c, nil
+}
+
+func (c *Client) Withdraw(currency string, amount float64, address string) (*tradingapi.Withdrawal, error) {
+	return c.withdraw(currency, amount, address, "")
+}
+
+func (c *Client) WithdrawWithPaymentId(currency string, amount float64, address, paymentId string) (*tradingapi.Withdrawal, error) {
+	return c.withdraw(currency, amount, address, paymentId)
+}
+
+func (c *Client) withdraw(currency string, amount float64, address, paymentId string) (*tradingapi.Withdrawal, error) {
+
+	r, err := c.Request(currency, amount, address, paymentId)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.policy.Approver == nil {
+		return nil, &ErrApprovalRequired{r}
+	}
+
+	if err := c.policy.Approver(r); err != nil {
+		if rejectErr := c.Reject(r.Id, err.Error()); rejectErr != nil {
+			logger.Errorf("Client.Reject: %v", rejectErr)
+		}
+		return nil, &ErrRejected{r.Id, err.Error()}
+	}
+
+	return c.Approve(r.Id, r.Token)
+}
+
+// Request checks a withdrawal and returns it pending approval (see Approve).
+func (c *Client) Request(currency string, amount float64, address, paymentId string) (*Request, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	record := Record{
+		Time:      time.Now(),
+		Currency:  currency,
+		Amount:    amount,
+		Address:   address,
+		PaymentId: paymentId,
+	}
+
+	if err := c.check(currency, amount, address, paymentId, 0); err != nil {
+
+		record.Stage, record.Reason = Denied, err.Error()
+		if auditErr := c.audit.Write(&record); auditErr != nil {
+			logger.Errorf("Audit.Write: %v", auditErr)
+		}
+
+		logger.Warnf("withdrawal of %.8f %s to %s denied: %v", amount, currency, address, err)
+		return nil, err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	c.lastId++
+
+	r := Request{
+		Id:        c.lastId,
+		Currency:  currency,
+		Amount:    amount,
+		Address:   address,
+		PaymentId: paymentId,
+		Time:      record.Time,
+		Expires:   record.Time.Add(c.policy.ApprovalTimeout),
+		Token:     token,
+	}
+
+	record.Id, record.Stage = r.Id, Requested
+	if err := c.audit.Write(&record); err != nil {
+		return nil, fmt.Errorf("Audit.Write: %v", err)
+	}
+
+	c.pending[r.Id] = &r
+
+	logger.Infof("withdrawal request %d: %.8f %s to %s", r.Id, amount, currency, address)
+
+	res := r
+	return &res, nil
+}
+
+// Approve submits the pending request id if token matches. The checks are run again
+// before submission. A wrong token rejects the request.
+func (c *Client) Approve(id int64, token string) (*tradingapi.Withdrawal, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, ok := c.pending[id]
+	if !ok {
+		return nil, &ErrApproval{id, "unknown request"}
+	}
+
+	if subtle.ConstantTimeCompare([]byte(r.Token), []byte(token)) != 1 {
+		c.close(r, Rejected, "wrong approval token")
+		return nil, &ErrApproval{id, "wrong approval token"}
+	}
+
+	if time.Now().After(r.Expires) {
+		c.close(r, Rejected, "expired")
+		return nil, &ErrExpired{id, r.Expires}
+	}
+
+	// A request is only denied by the policy: when the checks can not be run (e.g.
+	// the withdrawal history is unavailable), it stays pending
+	if err := c.check(r.Currency, r.Amount, r.Address, r.PaymentId, id); err != nil {
+		if isViolation(err) {
+			c.close(r, Denied, err.Error())
+		}
+		return nil, err
+	}
+
+	record := c.record(r, Approved, "")
+	if err := c.audit.Write(record); err != nil {
+		// The request stays pending
+		return nil, fmt.Errorf("Audit.Write: %v", err)
+	}
+
+	delete(c.pending, id)
+
+	var res *tradingapi.Withdrawal
+	var err error
+
+	if r.PaymentId == "" {
+		res, err = c.tradingClient.Withdraw(r.Currency, r.Amount, r.Address)
+	} else {
+		res, err = c.tradingClient.WithdrawWithPaymentId(r.Currency, r.Amount, r.Address, r.PaymentId)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("TradingClient.Withdraw: %v", err)
+		c.write(c.record(r, Failed, err.Error()))
+		return nil, err
+	}
+
+	c.withdrawn = append(c.withdrawn, &withdrawn{time.Now(), r.Currency, r.Amount})
+
+	record = c.record(r, Submitted, "")
+	record.Response = res.Response
+	c.write(record)
+
+	logger.Infof("withdrawal request %d submitted: %s", id, res.Response)
+
+	return res, nil
+}
+
+// Reject removes the pending request id.
+func (c *Client) Reject(id int64, reason string) error {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, ok := c.pending[id]
+	if !ok {
+		return &ErrApproval{id, "unknown request"}
+	}
+
+	c.close(r, Rejected, reason)
+
+	return nil
+}
+
+// Pending returns the requests waiting for approval, without their token. Expired
+// requests are removed.
+func (c *Client) Pending() []*Request {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var res []*Request
+	now := time.Now()
+
+	for _, r := range c.pending {
+
+		if now.After(r.Expires) {
+			c.close(r, Rejected, "expired")
+			continue
+		}
+
+		p := *r
+		p.Token = ""
+		res = append(res, &p)
+	}
+
+	return res
+}
+
+// close removes the pending request r and audits stage. It must be called with c.mu
+// held.
+func (c *Client) close(r *Request, stage Stage, reason string) {
+
+	delete(c.pending, r.Id)
+	c.write(c.record(r, stage, reason))
+
+	logger.Warnf("withdrawal request %d %s: %s", r.Id, stage, reason)
+}
+
+func (c *Client) record(r *Request, stage Stage, reason string) *Record {
+
+	return &Record{
+		Time:      time.Now(),
+		Id:        r.Id,
+		Stage:     stage,
+		Currency:  r.Currency,
+		Amount:    r.Amount,
+		Address:   r.Address,
+		PaymentId: r.PaymentId,
+		Reason:    reason,
+	}
+}
+
+// write audits records which can not prevent the withdrawal (after submission or
+// closing a request).
+func (c *Client) write(r *Record) {
+
+	if err := c.audit.Write(r); err != nil {
+		logger.Errorf("Audit.Write: %v", err)
+	}
+}
+
+// check must be called with c.mu held. exclude is the id of the request checked
+// again on approval, not counted in the pending amounts.
+func (c *Client) check(currency string, amount float64, address, paymentId string, exclude int64) error {
+
+	if amount <= 0 {
+		return fmt.Errorf("Wrong amount parameter: %v", amount)
+	}
+
+	if err := ValidateAddress(currency, address, paymentId, c.policy.StrictFormats); err != nil {
+		return err
+	}
+
+	if !c.whitelisted(currency, address, paymentId) {
+		return &ErrNotWhitelisted{currency, address, paymentId}
+	}
+
+	if max, ok := c.policy.MaxAmount[currency]; ok && amount > max {
+		return &ErrMaxAmount{currency, amount, max}
+	}
+
+	if max, ok := c.policy.MaxDaily[currency]; ok {
+
+		withdrawn, err := c.withdrawnToday(currency)
+		if err != nil {
+			return err
+		}
+
+		for id, r := range c.pending {
+			if id != exclude && r.Currency == currency && time.Now().Before(r.Expires) {
+				withdrawn += r.Amount
+			}
+		}
+
+		if withdrawn+amount > max {
+			return &ErrDailyLimit{currency, amount, withdrawn, max}
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) whitelisted(currency, address, paymentId string) bool {
+
+	for _, d := range c.policy.Whitelist[currency] {
+		if d.Address == address && (d.PaymentId == "" || d.PaymentId == paymentId) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withdrawnToday returns the amount of currency withdrawn since 00:00 UTC, from the
+// withdrawal history and the withdrawals sent by the client (see withdrawnTotal).
+func (c *Client) withdrawnToday(currency string) (float64, error) {
+
+	now := time.Now()
+	day := now.UTC().Truncate(24 * time.Hour)
+
+	dw, err := c.tradingClient.GetDepositsWithdrawals(day, now)
+	if err != nil {
+		return 0, fmt.Errorf("TradingClient.GetDepositsWithdrawals: %v", err)
+	}
+
+	i := 0
+	for _, w := range c.withdrawn {
+		if !w.date.Before(day) {
+			c.withdrawn[i] = w
+			i++
+		}
+	}
+	c.withdrawn = c.withdrawn[:i]
+
+	return withdrawnTotal(currency, dw.Withdrawals, c.withdrawn), nil
+}
+
+// withdrawnTotal returns the amount of currency withdrawn in history (failed and
+// cancelled withdrawals excluded) plus the local withdrawals not listed in history yet, in case
+// it lags. A local withdrawal is listed when a withdrawal of history of the same
+// amount is dated within historyMatchWindow of it, each withdrawal of history
+// matching a single local one.
+func withdrawnTotal(currency string, history []*tradingapi.WithdrawalHistory, local []*withdrawn) float64 {
+
+	total := 0.0
+	for _, w := range history {
+		if w.Currency == currency && util.WithdrawalStatusOf(w.Status) != util.WithdrawalFailed {
+			total += w.Amount
+		}
+	}
+
+	matched := make([]bool, len(history))
+
+	for _, l := range local {
+
+		if l.currency != currency {
+			continue
+		}
+
+		listed := false
+		for i, w := range history {
+
+			if matched[i] || w.Currency != currency || math.Abs(w.Amount-l.amount) > util.Epsilon {
+				continue
+			}
+
+			if d := time.Unix(w.Timestamp, 0).Sub(l.date); d < -historyMatchWindow || d > historyMatchWindow {
+				continue
+			}
+
+			matched[i], listed = true, true
+			break
+		}
+
+		if !listed {
+			total += l.amount
+		}
+	}
+
+	return total
+}
+
+func newToken() (string, error) {
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("rand.Read: %v", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
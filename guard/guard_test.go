@@ -0,0 +1,75 @@
+package guard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+func TestWithdrawnTotal(t *testing.T) {
+
+	now := time.Now()
+
+	earlier := &tradingapi.WithdrawalHistory{
+		Currency:  "BTC",
+		Amount:    0.8,
+		Timestamp: now.Add(-time.Hour).Unix(),
+		Status:    "COMPLETE: 36e4",
+	}
+
+	tests := []struct {
+		name    string
+		history []*tradingapi.WithdrawalHistory
+		local   []*withdrawn
+		want    float64
+	}{
+		{
+			// 0.8 BTC in history plus 0.5 BTC sent: the cap of 1 BTC is reached
+			name:    "local withdrawal not in history yet",
+			history: []*tradingapi.WithdrawalHistory{earlier},
+			local:   []*withdrawn{{now, "BTC", 0.5}},
+			want:    1.3,
+		},
+		{
+			name: "local withdrawal in history",
+			history: []*tradingapi.WithdrawalHistory{earlier, {
+				Currency:  "BTC",
+				Amount:    0.5,
+				Timestamp: now.Unix(),
+				Status:    "PENDING",
+			}},
+			local: []*withdrawn{{now, "BTC", 0.5}},
+			want:  1.3,
+		},
+		{
+			name: "local withdrawal cancelled",
+			history: []*tradingapi.WithdrawalHistory{earlier, {
+				Currency:  "BTC",
+				Amount:    0.5,
+				Timestamp: now.Unix(),
+				Status:    "CANCELED",
+			}},
+			local: []*withdrawn{{now, "BTC", 0.5}},
+			want:  0.8,
+		},
+		{
+			name:    "same amount sent twice, listed once",
+			history: []*tradingapi.WithdrawalHistory{{Currency: "BTC", Amount: 0.5, Timestamp: now.Unix(), Status: "PENDING"}},
+			local:   []*withdrawn{{now, "BTC", 0.5}, {now, "BTC", 0.5}},
+			want:    1,
+		},
+		{
+			name:    "other currency",
+			history: []*tradingapi.WithdrawalHistory{earlier},
+			local:   []*withdrawn{{now, "XMR", 0.5}},
+			want:    0.8,
+		},
+	}
+
+	for _, test := range tests {
+		if got := withdrawnTotal("BTC", test.history, test.local); got < test.want-1e-9 || got > test.want+1e-9 {
+			t.Errorf("%s: withdrawn %.8f, want %.8f", test.name, got, test.want)
+		}
+	}
+}
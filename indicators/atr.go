@@ -0,0 +1,96 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/joemocquant/poloniex-api/candles"
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+// ATR is Wilder's average true range, seeded with the simple average of the
+// first period true ranges.
+type ATR struct {
+	period    int
+	prevClose float64
+	hasPrev   bool
+	count     int
+	avg       float64 // running seed until count reaches period
+}
+
+func NewATR(period int) (*ATR, error) {
+
+	if err := checkPeriod(period); err != nil {
+		return nil, err
+	}
+	return &ATR{period: period}, nil
+}
+
+func (a *ATR) Add(cs *publicapi.CandleStick) float64 {
+
+	a.avg, a.count = a.next(cs)
+	a.prevClose, a.hasPrev = cs.Close, true
+
+	return a.Value()
+}
+
+func (a *ATR) Peek(cs *publicapi.CandleStick) float64 {
+
+	avg, count := a.next(cs)
+	if count < a.period {
+		return nan
+	}
+	return avg
+}
+
+// Update adds c when c is closed, peeks it otherwise.
+func (a *ATR) Update(c *candles.Candle) float64 {
+
+	if c.Closed {
+		return a.Add(c.CandleStick)
+	}
+	return a.Peek(c.CandleStick)
+}
+
+func (a *ATR) Value() float64 {
+
+	if !a.Ready() {
+		return nan
+	}
+	return a.avg
+}
+
+func (a *ATR) Ready() bool {
+	return a.count == a.period
+}
+
+func (a *ATR) next(cs *publicapi.CandleStick) (avg float64, count int) {
+
+	tr := cs.High - cs.Low
+	if a.hasPrev {
+		tr = math.Max(tr, math.Max(math.Abs(cs.High-a.prevClose), math.Abs(cs.Low-a.prevClose)))
+	}
+
+	if a.count < a.period {
+		c := float64(a.count)
+		return (a.avg*c + tr) / (c + 1), a.count + 1
+	}
+
+	n := float64(a.period)
+	return (a.avg*(n-1) + tr) / n, a.count
+}
+
+// ATRSeries returns the average true range of data.
+func ATRSeries(data publicapi.ChartData, period int) ([]float64, error) {
+
+	a, err := NewATR(period)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]float64, len(data))
+
+	for i, cs := range data {
+		res[i] = a.Add(cs)
+	}
+	return res, nil
+}
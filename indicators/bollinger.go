@@ -0,0 +1,114 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/joemocquant/poloniex-api/candles"
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+type BollingerValue struct {
+	Middle float64 // simple moving average
+	Upper  float64 // Middle + k standard deviations
+	Lower  float64 // Middle - k standard deviations
+}
+
+// Bollinger are the Bollinger bands (usually 20, 2) using the population
+// standard deviation of the last period values.
+type Bollinger struct {
+	period int
+	k      float64
+	window window
+	sum    float64
+	sumSq  float64
+	value  BollingerValue
+}
+
+func NewBollinger(period int, k float64) (*Bollinger, error) {
+
+	if err := checkPeriod(period); err != nil {
+		return nil, err
+	}
+
+	return &Bollinger{
+		period: period,
+		k:      k,
+		window: newWindow(period),
+		value:  BollingerValue{nan, nan, nan},
+	}, nil
+}
+
+func (b *Bollinger) Add(v float64) BollingerValue {
+
+	b.sum += v
+	b.sumSq += v * v
+
+	if b.window.full() {
+		old := b.window.oldest()
+		b.sum -= old
+		b.sumSq -= old * old
+	}
+	b.window.push(v)
+
+	if b.window.full() {
+		b.value = b.bands(b.sum, b.sumSq)
+	}
+
+	return b.value
+}
+
+func (b *Bollinger) Peek(v float64) BollingerValue {
+
+	switch {
+	case b.window.full():
+		old := b.window.oldest()
+		return b.bands(b.sum-old+v, b.sumSq-old*old+v*v)
+	case b.window.count+1 == b.period:
+		return b.bands(b.sum+v, b.sumSq+v*v)
+	default:
+		return BollingerValue{nan, nan, nan}
+	}
+}
+
+// Update adds the close of c when c is closed, peeks it otherwise.
+func (b *Bollinger) Update(c *candles.Candle) BollingerValue {
+
+	if c.Closed {
+		return b.Add(c.Close)
+	}
+	return b.Peek(c.Close)
+}
+
+func (b *Bollinger) Value() BollingerValue {
+	return b.value
+}
+
+func (b *Bollinger) Ready() bool {
+	return b.window.full()
+}
+
+func (b *Bollinger) bands(sum, sumSq float64) BollingerValue {
+
+	n := float64(b.period)
+	mean := sum / n
+	variance := math.Max(sumSq/n-mean*mean, 0) // rounding may make it slightly negative
+	width := b.k * math.Sqrt(variance)
+
+	return BollingerValue{mean, mean + width, mean - width}
+}
+
+// BollingerSeries returns the Bollinger bands of the closes of data.
+func BollingerSeries(data publicapi.ChartData, period int, k float64) ([]BollingerValue, error) {
+
+	b, err := NewBollinger(period, k)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]BollingerValue, len(data))
+
+	for i, cs := range data {
+		res[i] = b.Add(cs.Close)
+	}
+	return res, nil
+}
@@ -0,0 +1,81 @@
+package indicators
+
+import (
+	"github.com/joemocquant/poloniex-api/candles"
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+// EMA is the exponential moving average with smoothing factor 2 / (period + 1),
+// seeded with the simple moving average of the first period values.
+type EMA struct {
+	period int
+	alpha  float64
+	count  int
+	sum    float64 // seed
+	value  float64
+}
+
+func NewEMA(period int) (*EMA, error) {
+
+	if err := checkPeriod(period); err != nil {
+		return nil, err
+	}
+	return &EMA{period: period, alpha: 2 / float64(period+1), value: nan}, nil
+}
+
+func (e *EMA) Add(v float64) float64 {
+
+	e.value = e.Peek(v)
+
+	if e.count < e.period {
+		e.sum += v
+		e.count++
+	}
+
+	return e.value
+}
+
+func (e *EMA) Peek(v float64) float64 {
+
+	switch {
+	case e.count == e.period:
+		return e.alpha*v + (1-e.alpha)*e.value
+	case e.count+1 == e.period:
+		return (e.sum + v) / float64(e.period)
+	default:
+		return nan
+	}
+}
+
+// Update adds the close of c when c is closed, peeks it otherwise.
+func (e *EMA) Update(c *candles.Candle) float64 {
+
+	if c.Closed {
+		return e.Add(c.Close)
+	}
+	return e.Peek(c.Close)
+}
+
+func (e *EMA) Value() float64 {
+	return e.value
+}
+
+func (e *EMA) Ready() bool {
+	return e.count == e.period
+}
+
+// EMASeries returns the exponential moving average of the closes of data.
+func EMASeries(data publicapi.ChartData, period int) ([]float64, error) {
+
+	e, err := NewEMA(period)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]float64, len(data))
+
+	for i, cs := range data {
+		res[i] = e.Add(cs.Close)
+	}
+	return res, nil
+}
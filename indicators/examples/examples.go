@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/candles"
+	"github.com/joemocquant/poloniex-api/indicators"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/pushapi"
+)
+
+var (
+	publicClient *publicapi.Client
+	pushClient   *pushapi.Client
+)
+
+func main() {
+
+	var err error
+	publicClient = publicapi.NewClient()
+	pushClient, err = pushapi.NewClient()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	printIndicators()
+
+	// printLiveRSI()
+}
+
+// Print BTC_ETH 30min SMA(20), RSI(14) and MACD(12, 26, 9) the last 2 days
+func printIndicators() {
+
+	end := time.Now()
+	start := end.Add(-48 * time.Hour)
+	data, err := publicClient.GetChartData("BTC_ETH", start, end, 1800)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sma, err := indicators.SMASeries(data, 20)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rsi, err := indicators.RSISeries(data, 14)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	macd, err := indicators.MACDSeries(data, 12, 26, 9)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for i, cs := range data {
+		fmt.Printf("%s close: %.8f sma: %.8f rsi: %.2f macd: %+v\n",
+			time.Unix(cs.Date, 0), cs.Close, sma[i], rsi[i], macd[i])
+	}
+}
+
+// Print BTC_ETH 1min RSI(14) on every trade
+func printLiveRSI() {
+
+	aggregator, err := candles.NewAggregator("BTC_ETH", time.Minute)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := aggregator.Backfill(publicClient, time.Now().Add(-time.Hour)); err != nil {
+		log.Fatal(err)
+	}
+
+	rsi, err := indicators.NewRSI(14)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// The last candle is in progress, it is added once closed
+	data := aggregator.ChartData()
+	for i := 0; i < len(data)-1; i++ {
+		rsi.Add(data[i].Close)
+	}
+
+	marketUpdater, err := pushClient.SubscribeMarket("BTC_ETH")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go aggregator.Run(marketUpdater)
+
+	for candle := range aggregator.Candles() {
+		fmt.Printf("%s closed: %t rsi: %.2f\n",
+			time.Unix(candle.Date, 0), candle.Closed, rsi.Update(candle))
+	}
+}
@@ -0,0 +1,68 @@
+// Technical indicators over chart data.
+//
+// Every indicator is computed incrementally: Add commits a new value (a closed
+// candle) in constant time, Peek returns the indicator value as if a value was added
+// without committing it, which suits the partial candles emitted by candles.Aggregator.
+// Update does either depending on whether the candle is closed.
+//
+// The *Series functions compute an indicator over a whole publicapi.ChartData. Values
+// are NaN until the indicator has seen enough candles. Constructors and series return
+// an error for a non-positive period.
+package indicators
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+var nan = math.NaN()
+
+// Closes returns the close prices of data.
+func Closes(data publicapi.ChartData) []float64 {
+
+	res := make([]float64, len(data))
+	for i, cs := range data {
+		res[i] = cs.Close
+	}
+	return res
+}
+
+// window is a fixed size ring buffer of the last values added.
+type window struct {
+	values []float64
+	pos    int
+	count  int
+}
+
+func newWindow(size int) window {
+	return window{values: make([]float64, size)}
+}
+
+func (w *window) full() bool {
+	return w.count == len(w.values)
+}
+
+// oldest returns the value dropped by the next push when the window is full.
+func (w *window) oldest() float64 {
+	return w.values[w.pos]
+}
+
+func (w *window) push(v float64) {
+
+	w.values[w.pos] = v
+	w.pos = (w.pos + 1) % len(w.values)
+
+	if w.count < len(w.values) {
+		w.count++
+	}
+}
+
+func checkPeriod(period int) error {
+
+	if period < 1 {
+		return fmt.Errorf("Wrong period parameter: %d", period)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+// Reference series of the StockCharts ChartSchool articles on each indicator,
+// published rounded to 2 decimals.
+const tolerance = 0.01
+
+// 10-day SMA and EMA ("Moving Averages - Simple and Exponential")
+var (
+	movingAverageCloses = []float64{
+		22.27, 22.19, 22.08, 22.17, 22.18, 22.13, 22.23, 22.43, 22.24, 22.29,
+		22.15, 22.39, 22.38, 22.61, 23.36, 24.05, 23.75, 23.83, 23.95, 23.63,
+		23.82, 23.87, 23.65, 23.19, 23.10, 23.33, 22.68, 23.10, 22.40, 22.17,
+	}
+	sma10 = []float64{
+		22.22, 22.21, 22.23, 22.26, 22.31, 22.42, 22.61, 22.77, 22.91, 23.08,
+		23.21, 23.38, 23.53, 23.65, 23.71, 23.69, 23.61, 23.51, 23.43, 23.28,
+		23.13,
+	}
+	ema10 = []float64{
+		22.22, 22.21, 22.24, 22.27, 22.33, 22.52, 22.80, 22.97, 23.13, 23.28,
+		23.34, 23.43, 23.51, 23.54, 23.47, 23.40, 23.39, 23.26, 23.23, 23.08,
+		22.92,
+	}
+)
+
+// 14-day RSI ("Relative Strength Index")
+var (
+	rsiCloses = []float64{
+		44.3389, 44.0902, 44.1497, 43.6124, 44.3278, 44.8264, 45.0955, 45.4245,
+		45.8433, 46.0826, 45.8931, 46.0328, 45.6140, 46.2820, 46.2820, 46.0028,
+		46.0328, 46.4116, 46.2222, 45.6439, 46.2122, 46.2521, 45.7137, 46.4515,
+		45.7835, 45.3548, 44.0288, 44.1783, 44.2181, 44.5672, 43.4205, 42.6628,
+		43.1314,
+	}
+	rsi14 = []float64{
+		70.53, 66.32, 66.55, 69.41, 66.36, 57.97, 62.93, 63.26, 56.06, 62.38,
+		54.71, 50.42, 39.99, 41.46, 41.87, 45.46, 37.30, 33.08, 37.77,
+	}
+)
+
+// 20-day, 2 standard deviations Bollinger bands ("Bollinger Bands")
+var (
+	bollingerCloses = []float64{
+		86.16, 89.09, 88.78, 90.32, 89.07, 91.15, 89.44, 89.18, 86.93, 87.68,
+		86.96, 89.43, 89.32, 88.72, 87.45, 87.26, 89.50, 87.90, 89.13, 90.70,
+		92.90, 92.98, 91.80, 92.66, 92.68, 92.30, 92.77, 92.54, 92.95, 93.20,
+		91.07, 89.83,
+	}
+	bollingerMiddle = []float64{
+		88.71, 89.05, 89.24, 89.39, 89.51, 89.69, 89.75, 89.91, 90.08, 90.38,
+		90.66, 90.86, 90.88,
+	}
+	bollingerUpper = []float64{
+		91.29, 91.95, 92.61, 92.93, 93.31, 93.73, 93.90, 94.27, 94.57, 94.79,
+		95.04, 94.91, 94.90,
+	}
+	bollingerLower = []float64{
+		86.12, 86.14, 85.87, 85.85, 85.70, 85.65, 85.59, 85.56, 85.60, 85.98,
+		86.27, 86.82, 86.87,
+	}
+)
+
+// 14-day ATR ("Average True Range")
+var (
+	atrHighs = []float64{
+		48.70, 48.72, 48.90, 48.87, 48.82, 49.05, 49.20, 49.35, 49.92, 50.19,
+		50.12, 49.66, 49.88, 50.19, 50.36, 50.57, 50.65, 50.43, 49.63, 50.33,
+		50.29, 50.17, 49.32, 48.50, 48.32, 46.80, 47.80, 48.39, 48.66, 48.79,
+	}
+	atrLows = []float64{
+		47.79, 48.14, 48.39, 48.37, 48.24, 48.64, 48.94, 48.86, 49.50, 49.87,
+		49.20, 48.90, 49.43, 49.73, 49.26, 50.09, 50.30, 49.21, 48.98, 49.61,
+		49.20, 49.43, 48.08, 47.64, 41.55, 44.28, 47.31, 47.20, 47.90, 47.73,
+	}
+	atrCloses = []float64{
+		48.16, 48.61, 48.75, 48.63, 48.74, 49.03, 49.07, 49.32, 49.91, 50.13,
+		49.53, 49.50, 49.75, 50.03, 50.31, 50.52, 50.41, 49.34, 49.37, 50.23,
+		49.24, 49.93, 48.43, 48.18, 46.57, 45.41, 47.77, 47.72, 48.62, 47.85,
+	}
+	atr14 = []float64{
+		0.56, 0.59, 0.59, 0.57, 0.62, 0.62, 0.64, 0.67, 0.69, 0.78,
+		0.78, 1.21, 1.30, 1.38, 1.37, 1.34, 1.32,
+	}
+)
+
+func chartData(closes []float64) publicapi.ChartData {
+
+	data := make(publicapi.ChartData, len(closes))
+	for i, c := range closes {
+		data[i] = &publicapi.CandleStick{Date: int64(i), High: c, Low: c, Open: c, Close: c}
+	}
+	return data
+}
+
+// checkSeries compares the values of got from the first reference value on, and
+// checks that the values before are NaN.
+func checkSeries(t *testing.T, name string, got, want []float64) {
+
+	t.Helper()
+
+	first := len(got) - len(want)
+
+	for i, v := range got {
+
+		if i < first {
+			if !math.IsNaN(v) {
+				t.Errorf("%s[%d] = %.4f, want NaN", name, i, v)
+			}
+			continue
+		}
+
+		if math.Abs(v-want[i-first]) > tolerance {
+			t.Errorf("%s[%d] = %.4f, want %.2f", name, i, v, want[i-first])
+		}
+	}
+}
+
+func TestSMA(t *testing.T) {
+
+	got, err := SMASeries(chartData(movingAverageCloses), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkSeries(t, "SMA", got, sma10)
+}
+
+func TestEMA(t *testing.T) {
+
+	got, err := EMASeries(chartData(movingAverageCloses), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkSeries(t, "EMA", got, ema10)
+}
+
+func TestRSI(t *testing.T) {
+
+	got, err := RSISeries(chartData(rsiCloses), 14)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkSeries(t, "RSI", got, rsi14)
+}
+
+func TestBollinger(t *testing.T) {
+
+	got, err := BollingerSeries(chartData(bollingerCloses), 20, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var middle, upper, lower []float64
+	for _, v := range got {
+		middle = append(middle, v.Middle)
+		upper = append(upper, v.Upper)
+		lower = append(lower, v.Lower)
+	}
+
+	checkSeries(t, "Middle", middle, bollingerMiddle)
+	checkSeries(t, "Upper", upper, bollingerUpper)
+	checkSeries(t, "Lower", lower, bollingerLower)
+}
+
+func TestATR(t *testing.T) {
+
+	data := make(publicapi.ChartData, len(atrCloses))
+	for i := range atrCloses {
+		data[i] = &publicapi.CandleStick{
+			Date:  int64(i),
+			High:  atrHighs[i],
+			Low:   atrLows[i],
+			Close: atrCloses[i],
+		}
+	}
+
+	got, err := ATRSeries(data, 14)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkSeries(t, "ATR", got, atr14)
+}
+
+// VWAP from the base and quote volumes equals the average trade price weighted by
+// the traded amounts.
+func TestVWAP(t *testing.T) {
+
+	// Trades (rate, amount) of each candle
+	trades := [][][2]float64{
+		{{10, 1}, {11, 3}},
+		{{12, 2}},
+		{{9, 4}, {10, 1}},
+		{{11, 2}, {13, 2}},
+	}
+
+	data := make(publicapi.ChartData, len(trades))
+	for i, candle := range trades {
+		data[i] = &publicapi.CandleStick{Date: int64(i)}
+		for _, trade := range candle {
+			data[i].Volume += trade[0] * trade[1]
+			data[i].QuoteVolume += trade[1]
+		}
+	}
+
+	// (10 + 33) / 4, (43 + 24) / 6, (67 + 46) / 11, (113 + 48) / 15
+	cumulative := []float64{10.75, 67.0 / 6, 113.0 / 11, 161.0 / 15}
+
+	got, err := VWAPSeries(data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range got {
+		if math.Abs(v-cumulative[i]) > 1e-9 {
+			t.Errorf("VWAP[%d] = %.6f, want %.6f", i, v, cumulative[i])
+		}
+	}
+
+	// Last 2 candles: (24 + 46) / 7, (46 + 48) / 9
+	windowed := []float64{10.75, 67.0 / 6, 10, 94.0 / 9}
+
+	got, err = VWAPSeries(data, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range got {
+		if math.Abs(v-windowed[i]) > 1e-9 {
+			t.Errorf("VWAP(2)[%d] = %.6f, want %.6f", i, v, windowed[i])
+		}
+	}
+}
+
+// Peek returns the value Add would return, without committing it.
+func TestPeek(t *testing.T) {
+
+	sma, _ := NewSMA(10)
+	ema, _ := NewEMA(10)
+	rsi, _ := NewRSI(14)
+
+	for _, c := range rsiCloses {
+
+		peeked := []float64{sma.Peek(c), ema.Peek(c), rsi.Peek(c)}
+		added := []float64{sma.Add(c), ema.Add(c), rsi.Add(c)}
+
+		for i := range peeked {
+			if math.IsNaN(peeked[i]) != math.IsNaN(added[i]) || math.Abs(peeked[i]-added[i]) > 1e-9 {
+				t.Fatalf("indicator %d: Peek(%.4f) = %.6f, Add = %.6f", i, c, peeked[i], added[i])
+			}
+		}
+	}
+}
+
+func TestWrongPeriod(t *testing.T) {
+
+	for _, period := range []int{0, -1} {
+
+		if _, err := NewSMA(period); err == nil {
+			t.Errorf("NewSMA(%d): no error", period)
+		}
+		if _, err := NewEMA(period); err == nil {
+			t.Errorf("NewEMA(%d): no error", period)
+		}
+		if _, err := NewRSI(period); err == nil {
+			t.Errorf("NewRSI(%d): no error", period)
+		}
+		if _, err := NewATR(period); err == nil {
+			t.Errorf("NewATR(%d): no error", period)
+		}
+		if _, err := NewBollinger(period, 2); err == nil {
+			t.Errorf("NewBollinger(%d): no error", period)
+		}
+		if _, err := NewMACD(12, period, 9); err == nil {
+			t.Errorf("NewMACD(12, %d, 9): no error", period)
+		}
+		if _, err := SMASeries(chartData(movingAverageCloses), period); err == nil {
+			t.Errorf("SMASeries(%d): no error", period)
+		}
+	}
+
+	if _, err := NewVWAP(-1); err == nil {
+		t.Error("NewVWAP(-1): no error")
+	}
+}
@@ -0,0 +1,100 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/joemocquant/poloniex-api/candles"
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+type MACDValue struct {
+	MACD      float64 // fast EMA - slow EMA
+	Signal    float64 // EMA of MACD
+	Histogram float64 // MACD - Signal
+}
+
+// MACD is the moving average convergence divergence (usually 12, 26, 9).
+type MACD struct {
+	fast   *EMA
+	slow   *EMA
+	signal *EMA
+	value  MACDValue
+}
+
+func NewMACD(fast, slow, signal int) (*MACD, error) {
+
+	m := MACD{value: MACDValue{nan, nan, nan}}
+
+	var err error
+
+	if m.fast, err = NewEMA(fast); err != nil {
+		return nil, err
+	}
+	if m.slow, err = NewEMA(slow); err != nil {
+		return nil, err
+	}
+	if m.signal, err = NewEMA(signal); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func (m *MACD) Add(v float64) MACDValue {
+
+	macd := m.fast.Add(v) - m.slow.Add(v)
+
+	if math.IsNaN(macd) {
+		return m.value
+	}
+
+	signal := m.signal.Add(macd)
+	m.value = MACDValue{macd, signal, macd - signal}
+
+	return m.value
+}
+
+func (m *MACD) Peek(v float64) MACDValue {
+
+	macd := m.fast.Peek(v) - m.slow.Peek(v)
+
+	if math.IsNaN(macd) {
+		return MACDValue{nan, nan, nan}
+	}
+
+	signal := m.signal.Peek(macd)
+	return MACDValue{macd, signal, macd - signal}
+}
+
+// Update adds the close of c when c is closed, peeks it otherwise.
+func (m *MACD) Update(c *candles.Candle) MACDValue {
+
+	if c.Closed {
+		return m.Add(c.Close)
+	}
+	return m.Peek(c.Close)
+}
+
+func (m *MACD) Value() MACDValue {
+	return m.value
+}
+
+func (m *MACD) Ready() bool {
+	return m.signal.Ready()
+}
+
+// MACDSeries returns the moving average convergence divergence of the closes of data.
+func MACDSeries(data publicapi.ChartData, fast, slow, signal int) ([]MACDValue, error) {
+
+	m, err := NewMACD(fast, slow, signal)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]MACDValue, len(data))
+
+	for i, cs := range data {
+		res[i] = m.Add(cs.Close)
+	}
+	return res, nil
+}
@@ -0,0 +1,117 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/joemocquant/poloniex-api/candles"
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+// RSI is Wilder's relative strength index. Average gain and loss are seeded with
+// the simple average of the first period changes, then smoothed by 1 / period.
+type RSI struct {
+	period  int
+	prev    float64
+	hasPrev bool
+	count   int // number of changes seen, up to period
+	avgGain float64
+	avgLoss float64
+	value   float64
+}
+
+func NewRSI(period int) (*RSI, error) {
+
+	if err := checkPeriod(period); err != nil {
+		return nil, err
+	}
+	return &RSI{period: period, value: nan}, nil
+}
+
+func (r *RSI) Add(v float64) float64 {
+
+	if !r.hasPrev {
+		r.prev, r.hasPrev = v, true
+		return r.value
+	}
+
+	r.avgGain, r.avgLoss, r.count = r.next(v)
+	r.prev = v
+
+	if r.count == r.period {
+		r.value = rsi(r.avgGain, r.avgLoss)
+	}
+
+	return r.value
+}
+
+func (r *RSI) Peek(v float64) float64 {
+
+	if !r.hasPrev {
+		return nan
+	}
+
+	avgGain, avgLoss, count := r.next(v)
+	if count < r.period {
+		return nan
+	}
+	return rsi(avgGain, avgLoss)
+}
+
+// Update adds the close of c when c is closed, peeks it otherwise.
+func (r *RSI) Update(c *candles.Candle) float64 {
+
+	if c.Closed {
+		return r.Add(c.Close)
+	}
+	return r.Peek(c.Close)
+}
+
+func (r *RSI) Value() float64 {
+	return r.value
+}
+
+func (r *RSI) Ready() bool {
+	return r.count == r.period
+}
+
+func (r *RSI) next(v float64) (avgGain, avgLoss float64, count int) {
+
+	change := v - r.prev
+	gain, loss := math.Max(change, 0), math.Max(-change, 0)
+	n := float64(r.period)
+
+	if r.count < r.period {
+		// Seed: running simple average of the first period changes
+		c := float64(r.count)
+		return (r.avgGain*c + gain) / (c + 1), (r.avgLoss*c + loss) / (c + 1), r.count + 1
+	}
+
+	return (r.avgGain*(n-1) + gain) / n, (r.avgLoss*(n-1) + loss) / n, r.count
+}
+
+func rsi(avgGain, avgLoss float64) float64 {
+
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+avgGain/avgLoss)
+}
+
+// RSISeries returns the relative strength index of the closes of data.
+func RSISeries(data publicapi.ChartData, period int) ([]float64, error) {
+
+	r, err := NewRSI(period)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]float64, len(data))
+
+	for i, cs := range data {
+		res[i] = r.Add(cs.Close)
+	}
+	return res, nil
+}
@@ -0,0 +1,82 @@
+package indicators
+
+import (
+	"github.com/joemocquant/poloniex-api/candles"
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+// SMA is the simple moving average of the last period values.
+type SMA struct {
+	period int
+	window window
+	sum    float64
+	value  float64
+}
+
+func NewSMA(period int) (*SMA, error) {
+
+	if err := checkPeriod(period); err != nil {
+		return nil, err
+	}
+	return &SMA{period: period, window: newWindow(period), value: nan}, nil
+}
+
+func (s *SMA) Add(v float64) float64 {
+
+	s.sum += v
+	if s.window.full() {
+		s.sum -= s.window.oldest()
+	}
+	s.window.push(v)
+
+	if s.window.full() {
+		s.value = s.sum / float64(s.period)
+	}
+
+	return s.value
+}
+
+func (s *SMA) Peek(v float64) float64 {
+
+	switch {
+	case s.window.full():
+		return (s.sum - s.window.oldest() + v) / float64(s.period)
+	case s.window.count+1 == s.period:
+		return (s.sum + v) / float64(s.period)
+	default:
+		return nan
+	}
+}
+
+// Update adds the close of c when c is closed, peeks it otherwise.
+func (s *SMA) Update(c *candles.Candle) float64 {
+
+	if c.Closed {
+		return s.Add(c.Close)
+	}
+	return s.Peek(c.Close)
+}
+
+func (s *SMA) Value() float64 {
+	return s.value
+}
+
+func (s *SMA) Ready() bool {
+	return s.window.full()
+}
+
+// SMASeries returns the simple moving average of the closes of data.
+func SMASeries(data publicapi.ChartData, period int) ([]float64, error) {
+
+	s, err := NewSMA(period)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]float64, len(data))
+
+	for i, cs := range data {
+		res[i] = s.Add(cs.Close)
+	}
+	return res, nil
+}
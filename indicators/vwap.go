@@ -0,0 +1,113 @@
+package indicators
+
+import (
+	"fmt"
+
+	"github.com/joemocquant/poloniex-api/candles"
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+// VWAP is the volume weighted average price, computed from the base (Volume) and
+// quote (QuoteVolume) volumes of the candles: it is exact rather than approximated
+// from a typical price. With a zero period it is cumulative since the first candle
+// (or the last Reset), otherwise it covers the last period candles.
+type VWAP struct {
+	period       int
+	volumes      window
+	quoteVolumes window
+	sum          float64
+	quoteSum     float64
+	value        float64
+}
+
+func NewVWAP(period int) (*VWAP, error) {
+
+	if period < 0 {
+		return nil, fmt.Errorf("Wrong period parameter: %d", period)
+	}
+
+	return newVWAP(period), nil
+}
+
+func newVWAP(period int) *VWAP {
+
+	v := VWAP{period: period, value: nan}
+
+	if period > 0 {
+		v.volumes = newWindow(period)
+		v.quoteVolumes = newWindow(period)
+	}
+
+	return &v
+}
+
+func (v *VWAP) Add(cs *publicapi.CandleStick) float64 {
+
+	v.sum, v.quoteSum = v.next(cs)
+
+	if v.period > 0 {
+		v.volumes.push(cs.Volume)
+		v.quoteVolumes.push(cs.QuoteVolume)
+	}
+
+	v.value = vwap(v.sum, v.quoteSum)
+	return v.value
+}
+
+func (v *VWAP) Peek(cs *publicapi.CandleStick) float64 {
+	return vwap(v.next(cs))
+}
+
+// Update adds c when c is closed, peeks it otherwise.
+func (v *VWAP) Update(c *candles.Candle) float64 {
+
+	if c.Closed {
+		return v.Add(c.CandleStick)
+	}
+	return v.Peek(c.CandleStick)
+}
+
+func (v *VWAP) Value() float64 {
+	return v.value
+}
+
+// Reset starts a new cumulative VWAP (e.g. at the beginning of a session).
+func (v *VWAP) Reset() {
+	*v = *newVWAP(v.period)
+}
+
+func (v *VWAP) next(cs *publicapi.CandleStick) (sum, quoteSum float64) {
+
+	sum, quoteSum = v.sum+cs.Volume, v.quoteSum+cs.QuoteVolume
+
+	if v.period > 0 && v.volumes.full() {
+		sum -= v.volumes.oldest()
+		quoteSum -= v.quoteVolumes.oldest()
+	}
+
+	return sum, quoteSum
+}
+
+func vwap(sum, quoteSum float64) float64 {
+
+	if quoteSum <= 0 {
+		return nan
+	}
+	return sum / quoteSum
+}
+
+// VWAPSeries returns the volume weighted average price of data.
+func VWAPSeries(data publicapi.ChartData, period int) ([]float64, error) {
+
+	v, err := NewVWAP(period)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]float64, len(data))
+
+	for i, cs := range data {
+		res[i] = v.Add(cs)
+	}
+	return res, nil
+}
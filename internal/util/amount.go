@@ -0,0 +1,54 @@
+package util
+
+import "math"
+
+// Poloniex rates and amounts have 8 decimals
+const Decimals = 8
+
+// Amounts below Epsilon are considered null
+const Epsilon = 1e-9
+
+// Tolerance of float64 representation errors when rounding: absolute for small
+// values, relative (a few units in the last place) for large ones. A float64 has
+// about 16 significant digits, so 1e8 times an amount above 1000 may be off by
+// more than 1e-6.
+const (
+	tolerance         = 1e-6
+	relativeTolerance = 1e-15
+)
+
+// Floor rounds v down to 8 decimals.
+func Floor(v float64) float64 {
+	return FloorDecimals(v, Decimals)
+}
+
+// Ceil rounds v up to 8 decimals.
+func Ceil(v float64) float64 {
+	return CeilDecimals(v, Decimals)
+}
+
+// FloorDecimals rounds v down to the given number of decimals.
+func FloorDecimals(v float64, decimals int) float64 {
+
+	scaled := v * math.Pow10(decimals)
+	return math.Floor(scaled+margin(scaled)) / math.Pow10(decimals)
+}
+
+// CeilDecimals rounds v up to the given number of decimals.
+func CeilDecimals(v float64, decimals int) float64 {
+
+	scaled := v * math.Pow10(decimals)
+	return math.Ceil(scaled-margin(scaled)) / math.Pow10(decimals)
+}
+
+// HasDecimals reports whether v has at most the given number of decimals.
+func HasDecimals(v float64, decimals int) bool {
+
+	scaled := v * math.Pow10(decimals)
+	return math.Abs(scaled-math.Round(scaled)) < margin(scaled)
+}
+
+// margin returns the representation error tolerated on a scaled value.
+func margin(scaled float64) float64 {
+	return math.Max(tolerance, relativeTolerance*math.Abs(scaled))
+}
@@ -0,0 +1,55 @@
+package util
+
+import "strings"
+
+// Prefix of the errors returned by the trading API when the exchange answers with
+// {"error": "..."} (see tradingapi.checkAPIError)
+const apiErrorPrefix = "API error: "
+
+// Messages of the exchange, matched case insensitively
+const (
+	nonceMessage     = "nonce must be greater than"
+	rateLimitMessage = "api calls per second"
+	// cancelOrder and moveOrder on an order that is filled, cancelled or unknown
+	invalidOrderMessage = "invalid order number"
+	// returnOrderTrades on an order that has no trades or is unknown
+	orderNotFoundMessage = "order not found"
+)
+
+// IsAPIError reports whether err was returned by the exchange, rather than by the
+// transport (network, timeout, status code).
+func IsAPIError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), apiErrorPrefix)
+}
+
+// IsRetryable reports whether the command may succeed if sent again: transport
+// errors, nonce errors (concurrent commands reaching the exchange out of order) and
+// rate limiting.
+func IsRetryable(err error) bool {
+
+	if err == nil {
+		return false
+	}
+
+	if !IsAPIError(err) {
+		return true
+	}
+
+	return hasMessage(err, nonceMessage) || hasMessage(err, rateLimitMessage)
+}
+
+// IsOrderClosed reports whether a cancelOrder or moveOrder command failed because
+// the order is no longer on the book (filled, cancelled or unknown).
+func IsOrderClosed(err error) bool {
+	return IsAPIError(err) && hasMessage(err, invalidOrderMessage)
+}
+
+// IsNoTrades reports whether a returnOrderTrades command failed because the order
+// has no trades (or is unknown).
+func IsNoTrades(err error) bool {
+	return IsAPIError(err) && hasMessage(err, orderNotFoundMessage)
+}
+
+func hasMessage(err error, message string) bool {
+	return strings.Contains(strings.ToLower(err.Error()), message)
+}
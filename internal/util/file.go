@@ -0,0 +1,26 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// WriteFile writes content to path, readable by the user only. The content is
+// written to a temporary file then renamed so that a crash never leaves a
+// truncated file.
+func WriteFile(path string, content []byte) error {
+
+	tmp := path + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, content, 0600); err != nil {
+		return fmt.Errorf("ioutil.WriteFile: %v", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("os.Rename: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package util
+
+import "strings"
+
+// SplitPair returns the base and quote currencies of a pair (e.g. BTC and XMR for
+// BTC_XMR), false when the pair is malformed.
+func SplitPair(currencyPair string) (string, string, bool) {
+
+	parts := strings.Split(currencyPair, "_")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
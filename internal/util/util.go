@@ -0,0 +1,4 @@
+// Package util holds the helpers shared by the packages of the module: Poloniex
+// amount rounding, currency pairs, trading API error and withdrawal status
+// classification and file writing.
+package util
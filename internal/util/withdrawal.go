@@ -0,0 +1,28 @@
+package util
+
+import "strings"
+
+// Classes of the status of a withdrawal
+type WithdrawalStatus int
+
+const (
+	WithdrawalPending  WithdrawalStatus = iota // e.g. PENDING, AWAITING APPROVAL: debited
+	WithdrawalComplete                         // e.g. COMPLETE: <txid>
+	WithdrawalFailed                           // e.g. COMPLETE: ERROR, CANCELED: refunded
+)
+
+// WithdrawalStatusOf classifies the status of a withdrawal ("COMPLETE: <txid>",
+// "PENDING", ...).
+func WithdrawalStatusOf(status string) WithdrawalStatus {
+
+	upper := strings.ToUpper(status)
+
+	switch {
+	case strings.Contains(upper, "ERROR") || strings.HasPrefix(upper, "CANCEL"):
+		return WithdrawalFailed
+	case strings.HasPrefix(upper, "COMPLETE"):
+		return WithdrawalComplete
+	default:
+		return WithdrawalPending
+	}
+}
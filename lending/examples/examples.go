@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/lending"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+func main() {
+
+	tradingClient, err := tradingapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	publicClient := publicapi.NewClient()
+
+	dryRun(tradingClient, publicClient)
+
+	// run(tradingClient, publicClient)
+}
+
+var params = lending.Params{
+	Currencies:  []string{"BTC", "ETH"},
+	MinRate:     0.0001,
+	Spread:      4,
+	SpreadDepth: 50,
+	Durations: []lending.Duration{
+		{Rate: 0.0005, Days: 30},
+		{Rate: 0.0003, Days: 7},
+	},
+	Refresh: 30 * time.Minute,
+}
+
+// Print the offers which would be placed
+func dryRun(tradingClient *tradingapi.Client, publicClient *publicapi.Client) {
+
+	p := params
+	p.DryRun = true
+
+	bot, err := lending.NewBot(tradingClient, publicClient, p)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	offers, err := bot.Check()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, o := range offers {
+		fmt.Printf("%.8f %s at %.6f%% for %d days\n", o.Amount, o.Currency, o.Rate*100, o.Duration)
+	}
+}
+
+// Lend for one day and print the earnings
+func run(tradingClient *tradingapi.Client, publicClient *publicapi.Client) {
+
+	bot, err := lending.NewBot(tradingClient, publicClient, params)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	time.AfterFunc(24*time.Hour, func() { close(done) })
+
+	bot.Run(done)
+
+	earned, err := bot.Earned()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for currency, e := range earned {
+		fmt.Printf("%s: %d loans, earned %.8f (interest %.8f, fees %.8f)\n",
+			currency, e.Loans, e.Earned, e.Interest, e.Fees)
+	}
+}
@@ -0,0 +1,394 @@
+// Lending bot.
+//
+// A Bot lends the idle balances of the lending account (GetAccountBalances("lending")).
+// Every check, offers left unfilled longer than the refresh period are cancelled, and
+// the idle balance of each currency is split into offers spread over the loan offer
+// book (GetLoanOrders), never below a minimum rate, with a duration chosen from the
+// rate. Interest earned since the bot started is collected from the lending history.
+//
+// In dry-run mode, offers are computed and logged but nothing is placed or cancelled.
+package lending
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:lending]")
+
+const (
+	defaultMinAmount = 0.01 // smallest loan offer accepted by Poloniex
+	defaultDuration  = 2    // days
+	defaultRefresh   = time.Hour
+	defaultInterval  = time.Minute
+)
+
+// Duration of the offers whose rate is at least Rate.
+type Duration struct {
+	Rate float64
+	Days int // 2 to 60
+}
+
+type Params struct {
+	Currencies []string // lent currencies, every currency of the lending account when empty
+	MinRate    float64  // daily rate floor, e.g. 0.0001 for 0.01% per day
+	// Number of offers the idle balance of a currency is split into, 1 when zero
+	Spread int
+	// Amount of the offer book the spread covers: offer i of n is placed at the rate of
+	// the book once i/n of SpreadDepth is offered at lower rates. Every offer is placed
+	// at the lowest rate when zero.
+	SpreadDepth float64
+	MinAmount   float64    // minimum offer amount, 0.01 when zero
+	Durations   []Duration // 2 days for rates below every duration
+	AutoRenew   bool
+	Refresh     time.Duration // unfilled offers are placed again after Refresh, 1 hour when zero
+	Interval    time.Duration // check period of Run, 1 minute when zero
+	DryRun      bool
+}
+
+type Offer struct {
+	Currency string
+	Amount   float64
+	Rate     float64
+	Duration int
+	OrderId  int64 // 0 in dry-run mode
+}
+
+type Earnings struct {
+	Loans    int
+	Amount   float64 // lent amount
+	Interest float64
+	Fees     float64 // negative
+	Earned   float64 // interest net of fees
+}
+
+type Bot struct {
+	client *tradingapi.Client
+	public *publicapi.Client
+	params Params
+
+	mu       sync.Mutex
+	started  time.Time
+	loanIds  map[int64]bool
+	earnings map[string]*Earnings // by currency
+}
+
+// NewBot returns a lending bot using client, loading the loan books with public.
+func NewBot(client *tradingapi.Client, public *publicapi.Client, params Params) (*Bot, error) {
+
+	switch {
+	case params.MinRate < 0:
+		return nil, fmt.Errorf("Wrong min rate parameter: %v", params.MinRate)
+	case params.Spread < 0:
+		return nil, fmt.Errorf("Wrong spread parameter: %d", params.Spread)
+	case params.SpreadDepth < 0:
+		return nil, fmt.Errorf("Wrong spread depth parameter: %v", params.SpreadDepth)
+	}
+
+	for _, d := range params.Durations {
+		if d.Days < 2 || d.Days > 60 {
+			return nil, fmt.Errorf("Wrong duration parameter: %d days", d.Days)
+		}
+	}
+
+	if params.Spread == 0 {
+		params.Spread = 1
+	}
+	if params.MinAmount == 0 {
+		params.MinAmount = defaultMinAmount
+	}
+	if params.Refresh == 0 {
+		params.Refresh = defaultRefresh
+	}
+	if params.Interval == 0 {
+		params.Interval = defaultInterval
+	}
+
+	// Highest rates first
+	params.Durations = append([]Duration(nil), params.Durations...)
+	sort.Slice(params.Durations, func(i, j int) bool {
+		return params.Durations[i].Rate > params.Durations[j].Rate
+	})
+
+	b := Bot{
+		client:   client,
+		public:   public,
+		params:   params,
+		started:  time.Now(),
+		loanIds:  make(map[int64]bool),
+		earnings: make(map[string]*Earnings),
+	}
+
+	return &b, nil
+}
+
+// Run checks every interval until done is closed.
+func (b *Bot) Run(done <-chan struct{}) {
+
+	ticker := time.NewTicker(b.params.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := b.Check(); err != nil {
+			logger.WithField("error", err).Error("Bot.Check")
+		}
+
+		if err := b.collect(); err != nil {
+			logger.WithField("error", err).Error("Bot.collect")
+		}
+
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+	}
+}
+
+// Check refreshes the stale offers and lends the idle balances. It returns the offers
+// placed (computed only in dry-run mode).
+func (b *Bot) Check() ([]*Offer, error) {
+
+	if err := b.refresh(); err != nil {
+		return nil, err
+	}
+
+	balances, err := b.client.GetAccountBalances("lending")
+	if err != nil {
+		if !strings.Contains(err.Error(), "No account found") {
+			return nil, fmt.Errorf("TradingClient.GetAccountBalances: %v", err)
+		}
+		balances = tradingapi.AccountBalances{}
+	}
+
+	var res []*Offer
+
+	for _, currency := range b.currencies(balances) {
+
+		idle := balances[currency]
+		if idle < b.params.MinAmount {
+			continue
+		}
+
+		book, err := b.public.GetLoanOrders(currency)
+		if err != nil {
+			return res, fmt.Errorf("PublicClient.GetLoanOrders: %v", err)
+		}
+
+		for _, o := range b.offers(currency, idle, book) {
+
+			if err := b.place(o); err != nil {
+				return res, err
+			}
+			res = append(res, o)
+		}
+	}
+
+	return res, nil
+}
+
+func (b *Bot) currencies(balances tradingapi.AccountBalances) []string {
+
+	if len(b.params.Currencies) > 0 {
+		return b.params.Currencies
+	}
+
+	var res []string
+	for currency := range balances {
+		res = append(res, currency)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// refresh cancels the offers older than the refresh period.
+func (b *Bot) refresh() error {
+
+	open, err := b.client.GetOpenLoanOffers()
+	if err != nil {
+		return fmt.Errorf("TradingClient.GetOpenLoanOffers: %v", err)
+	}
+
+	for currency, offers := range open {
+		for _, o := range offers {
+
+			if time.Since(time.Unix(o.Date, 0)) < b.params.Refresh {
+				continue
+			}
+
+			if b.params.DryRun {
+				logger.Infof("dry-run: cancel %s offer %d (%.8f at %.6f%%)",
+					currency, o.Id, o.Amount, o.Rate*100)
+				continue
+			}
+
+			res, err := b.client.CancelLoanOffer(o.Id)
+			if err != nil {
+				return fmt.Errorf("TradingClient.CancelLoanOffer: %v", err)
+			}
+			if !res.Success {
+				// Most likely taken meanwhile
+				logger.Warnf("%s offer %d not cancelled: %s", currency, o.Id, res.Message)
+				continue
+			}
+
+			logger.Debugf("%s offer %d cancelled", currency, o.Id)
+		}
+	}
+
+	return nil
+}
+
+// offers splits idle into offers spread over the offer book.
+func (b *Bot) offers(currency string, idle float64, book *publicapi.LoanOrders) []*Offer {
+
+	n := b.params.Spread
+
+	amount := util.Floor(idle / float64(n))
+	if amount < b.params.MinAmount {
+		n, amount = 1, util.Floor(idle)
+	}
+
+	res := make([]*Offer, 0, n)
+
+	for i := 0; i < n; i++ {
+
+		if i == n-1 {
+			// Rounding leftovers
+			amount = util.Floor(idle - util.Floor(idle/float64(n))*float64(n-1))
+		}
+
+		rate := b.rate(book, b.params.SpreadDepth*float64(i)/float64(n))
+		if rate <= 0 {
+			logger.Warnf("%s: empty offer book and no min rate, nothing offered", currency)
+			return nil
+		}
+
+		res = append(res, &Offer{
+			Currency: currency,
+			Amount:   amount,
+			Rate:     rate,
+			Duration: b.duration(rate),
+		})
+	}
+
+	return res
+}
+
+// rate returns the rate of the first book offer after depth is offered at lower rates,
+// not below the minimum rate.
+func (b *Bot) rate(book *publicapi.LoanOrders, depth float64) float64 {
+
+	rate := 0.0
+	offered := 0.0
+
+	for _, o := range book.Offers {
+		rate = o.Rate
+		offered += o.Amount
+		if offered > depth {
+			break
+		}
+	}
+
+	return math.Max(rate, b.params.MinRate)
+}
+
+func (b *Bot) duration(rate float64) int {
+
+	for _, d := range b.params.Durations {
+		if rate >= d.Rate {
+			return d.Days
+		}
+	}
+
+	return defaultDuration
+}
+
+func (b *Bot) place(o *Offer) error {
+
+	if b.params.DryRun {
+		logger.Infof("dry-run: offer %.8f %s at %.6f%% for %d days",
+			o.Amount, o.Currency, o.Rate*100, o.Duration)
+		return nil
+	}
+
+	res, err := b.client.CreateLoanOffer(o.Currency, o.Amount, o.Duration, b.params.AutoRenew, o.Rate)
+	if err != nil {
+		return fmt.Errorf("TradingClient.CreateLoanOffer: %v", err)
+	}
+
+	if !res.Success {
+		return fmt.Errorf("%s offer of %.8f at %v not placed: %s",
+			o.Currency, o.Amount, o.Rate, res.Message)
+	}
+
+	o.OrderId = res.OrderId
+
+	logger.Infof("offer %d: %.8f %s at %.6f%% for %d days",
+		o.OrderId, o.Amount, o.Currency, o.Rate*100, o.Duration)
+
+	return nil
+}
+
+// collect adds the loans closed since the bot started to the earnings.
+func (b *Bot) collect() error {
+
+	history, err := b.client.GetLendingHistory(b.started, time.Now(), 0)
+	if err != nil {
+		return fmt.Errorf("TradingClient.GetLendingHistory: %v", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, l := range history {
+
+		if b.loanIds[l.Id] || l.Open < b.started.Unix() {
+			continue
+		}
+		b.loanIds[l.Id] = true
+
+		e, ok := b.earnings[l.Currency]
+		if !ok {
+			e = &Earnings{}
+			b.earnings[l.Currency] = e
+		}
+
+		e.Loans++
+		e.Amount += l.Amount
+		e.Interest += l.Interest
+		e.Fees += l.Fee
+		e.Earned += l.Earned
+	}
+
+	return nil
+}
+
+// Earned returns the earnings of the loans opened and closed since the bot started, by
+// currency.
+func (b *Bot) Earned() (map[string]Earnings, error) {
+
+	if err := b.collect(); err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	res := make(map[string]Earnings)
+	for currency, e := range b.earnings {
+		res[currency] = *e
+	}
+
+	return res, nil
+}
@@ -0,0 +1,360 @@
+// Live order book.
+//
+// A Book keeps a local copy of the order book of a market: a publicapi snapshot kept
+// up to date with the orderBookModify and orderBookRemove updates of the push API,
+// applied in sequence number order (out of order updates are buffered, the snapshot
+// is reloaded when an update is missing). Listeners are notified of every applied
+// update, with the trades it contains.
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/pushapi"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:orderbook]")
+
+const (
+	snapshotDepth = 1000 // depth of the publicapi snapshot
+	maxPending    = 100  // out of order updates buffered before reloading the snapshot
+)
+
+type Level struct {
+	Rate   float64
+	Amount float64
+}
+
+// Update is sent to listeners after every applied market update.
+type Update struct {
+	Seq    int64
+	Trades []*pushapi.NewTrade
+}
+
+type Book struct {
+	client       *publicapi.Client
+	currencyPair string
+
+	mu       sync.RWMutex
+	asks     map[float64]float64 // rate -> amount
+	bids     map[float64]float64
+	seq      int64
+	synced   bool
+	isFrozen bool
+	pending  map[int64]*pushapi.MarketUpdates
+
+	listenersMu sync.Mutex
+	listeners   []chan *Update
+}
+
+// NewBook returns the order book of currencyPair, loading snapshots with client.
+func NewBook(client *publicapi.Client, currencyPair string) *Book {
+
+	b := Book{
+		client:       client,
+		currencyPair: currencyPair,
+		asks:         make(map[float64]float64),
+		bids:         make(map[float64]float64),
+		pending:      make(map[int64]*pushapi.MarketUpdates),
+	}
+
+	return &b
+}
+
+func (b *Book) CurrencyPair() string {
+	return b.currencyPair
+}
+
+// Run loads the snapshot and applies market updates until updater is unsubscribed
+// (nil update received).
+func (b *Book) Run(updater pushapi.MarketUpdater) error {
+
+	if err := b.Sync(); err != nil {
+		return err
+	}
+
+	for updates := range updater {
+
+		if updates == nil {
+			return nil
+		}
+
+		if err := b.Apply(updates); err != nil {
+			logger.WithField("error", err).Error("Book.Apply")
+		}
+	}
+
+	return nil
+}
+
+// Sync (re)loads the snapshot. Buffered updates newer than the snapshot are applied.
+func (b *Book) Sync() error {
+
+	snapshot, err := b.client.GetOrderBook(b.currencyPair, snapshotDepth)
+	if err != nil {
+		return fmt.Errorf("PublicClient.GetOrderBook: %v", err)
+	}
+
+	b.mu.Lock()
+
+	b.asks = make(map[float64]float64, len(snapshot.Asks))
+	for _, o := range snapshot.Asks {
+		b.asks[o.Rate] = o.Quantity
+	}
+
+	b.bids = make(map[float64]float64, len(snapshot.Bids))
+	for _, o := range snapshot.Bids {
+		b.bids[o.Rate] = o.Quantity
+	}
+
+	b.seq = snapshot.Seq
+	b.isFrozen = snapshot.IsFrozen
+	b.synced = true
+
+	for seq := range b.pending {
+		if seq <= b.seq {
+			delete(b.pending, seq)
+		}
+	}
+
+	applied := b.applyPending()
+
+	b.mu.Unlock()
+
+	b.notify(applied)
+
+	return nil
+}
+
+// Apply applies market updates received from the push API (for callers consuming
+// the market updater themselves).
+func (b *Book) Apply(updates *pushapi.MarketUpdates) error {
+
+	b.mu.Lock()
+
+	if !b.synced || updates.Sequence <= b.seq {
+		// Not synced yet or older than the snapshot
+		if !b.synced {
+			b.pending[updates.Sequence] = updates
+		}
+		b.mu.Unlock()
+		return nil
+	}
+
+	b.pending[updates.Sequence] = updates
+	applied := b.applyPending()
+	resync := len(b.pending) > maxPending
+
+	b.mu.Unlock()
+
+	b.notify(applied)
+
+	if resync {
+		logger.Warnf("%s: missing update %d, reloading order book", b.currencyPair, b.seq+1)
+		if err := b.Sync(); err != nil {
+			return fmt.Errorf("Book.Sync: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// applyPending applies the buffered updates following the current sequence number.
+// It must be called with b.mu held.
+func (b *Book) applyPending() []*Update {
+
+	var applied []*Update
+
+	for {
+		updates, ok := b.pending[b.seq+1]
+		if !ok {
+			return applied
+		}
+		delete(b.pending, b.seq+1)
+		b.seq++
+
+		u := Update{Seq: b.seq}
+
+		for _, update := range updates.Updates {
+
+			switch d := update.Data.(type) {
+			case *pushapi.OrderBookModify:
+				b.side(d.TypeOrder)[d.Rate] = d.Amount
+			case *pushapi.OrderBookRemove:
+				delete(b.side(d.TypeOrder), d.Rate)
+			case *pushapi.NewTrade:
+				u.Trades = append(u.Trades, d)
+			}
+		}
+
+		applied = append(applied, &u)
+	}
+}
+
+// side must be called with b.mu held.
+func (b *Book) side(typeOrder string) map[float64]float64 {
+
+	if typeOrder == "ask" {
+		return b.asks
+	}
+	return b.bids
+}
+
+// Updates returns a new channel on which every applied update is sent, until
+// StopUpdates. Updates are dropped when the channel is full.
+func (b *Book) Updates() <-chan *Update {
+
+	c := make(chan *Update, 100)
+
+	b.listenersMu.Lock()
+	b.listeners = append(b.listeners, c)
+	b.listenersMu.Unlock()
+
+	return c
+}
+
+// StopUpdates stops sending updates on a channel returned by Updates, and closes it.
+func (b *Book) StopUpdates(updates <-chan *Update) {
+
+	b.listenersMu.Lock()
+	defer b.listenersMu.Unlock()
+
+	for i, c := range b.listeners {
+		if c == updates {
+			b.listeners = append(b.listeners[:i], b.listeners[i+1:]...)
+			close(c)
+			return
+		}
+	}
+}
+
+func (b *Book) notify(applied []*Update) {
+
+	b.listenersMu.Lock()
+	defer b.listenersMu.Unlock()
+
+	for _, u := range applied {
+		for _, c := range b.listeners {
+			select {
+			case c <- u:
+			default:
+				logger.Warnf("%s: listener full, update %d dropped", b.currencyPair, u.Seq)
+			}
+		}
+	}
+}
+
+// Seq returns the sequence number of the last applied update.
+func (b *Book) Seq() int64 {
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.seq
+}
+
+func (b *Book) IsFrozen() bool {
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.isFrozen
+}
+
+// BestBid returns the highest bid.
+func (b *Book) BestBid() (Level, error) {
+
+	levels := b.Bids(1)
+	if len(levels) == 0 {
+		return Level{}, errors.New("no bid")
+	}
+	return levels[0], nil
+}
+
+// BestAsk returns the lowest ask.
+func (b *Book) BestAsk() (Level, error) {
+
+	levels := b.Asks(1)
+	if len(levels) == 0 {
+		return Level{}, errors.New("no ask")
+	}
+	return levels[0], nil
+}
+
+// Mid returns the rate halfway between the best bid and the best ask.
+func (b *Book) Mid() (float64, error) {
+
+	bid, err := b.BestBid()
+	if err != nil {
+		return 0, err
+	}
+
+	ask, err := b.BestAsk()
+	if err != nil {
+		return 0, err
+	}
+
+	return (bid.Rate + ask.Rate) / 2, nil
+}
+
+// Asks returns the depth lowest asks (every ask when depth is 0), lowest first.
+func (b *Book) Asks(depth int) []Level {
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return levels(b.asks, depth, func(a, c float64) bool { return a < c })
+}
+
+// Bids returns the depth highest bids (every bid when depth is 0), highest first.
+func (b *Book) Bids(depth int) []Level {
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return levels(b.bids, depth, func(a, c float64) bool { return a > c })
+}
+
+// Snapshot returns a copy of the book as a publicapi order book.
+func (b *Book) Snapshot() *publicapi.OrderBook {
+
+	asks, bids := b.Asks(0), b.Bids(0)
+
+	b.mu.RLock()
+	res := publicapi.OrderBook{IsFrozen: b.isFrozen, Seq: b.seq}
+	b.mu.RUnlock()
+
+	for _, l := range asks {
+		res.Asks = append(res.Asks, &publicapi.Order{Rate: l.Rate, Quantity: l.Amount})
+	}
+	for _, l := range bids {
+		res.Bids = append(res.Bids, &publicapi.Order{Rate: l.Rate, Quantity: l.Amount})
+	}
+
+	return &res
+}
+
+func levels(side map[float64]float64, depth int, before func(a, b float64) bool) []Level {
+
+	res := make([]Level, 0, len(side))
+	for rate, amount := range side {
+		if amount > 0 {
+			res = append(res, Level{rate, amount})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return before(res[i].Rate, res[j].Rate)
+	})
+
+	if depth > 0 && len(res) > depth {
+		res = res[:depth]
+	}
+
+	return res
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/joemocquant/poloniex-api/orderbook"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/pushapi"
+)
+
+func main() {
+
+	pushClient, err := pushapi.NewClient()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	printTopOfBook(publicapi.NewClient(), pushClient)
+}
+
+// Print BTC_ETH best bid and ask after every update
+func printTopOfBook(publicClient *publicapi.Client, pushClient *pushapi.Client) {
+
+	updater, err := pushClient.SubscribeMarket("BTC_ETH")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	book := orderbook.NewBook(publicClient, "BTC_ETH")
+	updates := book.Updates()
+
+	go func() {
+		if err := book.Run(updater); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	for u := range updates {
+
+		bid, err := book.BestBid()
+		if err != nil {
+			continue
+		}
+		ask, err := book.BestAsk()
+		if err != nil {
+			continue
+		}
+
+		fmt.Printf("%d: %.8f (%.8f) / %.8f (%.8f), %d trades\n",
+			u.Seq, bid.Rate, bid.Amount, ask.Rate, ask.Amount, len(u.Trades))
+	}
+}
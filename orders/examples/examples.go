@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/orders"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+var (
+	client  *tradingapi.Client
+	manager *orders.Manager
+)
+
+func main() {
+
+	var err error
+	client, err = tradingapi.NewClient()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	manager = orders.NewManager(client)
+
+	go printEvents()
+
+	trackOpenOrders()
+
+	// buyAndMove()
+}
+
+func printEvents() {
+
+	for e := range manager.Events() {
+		fmt.Printf("%s order %d (%s %s): %s, filled %.8f, remaining %.8f\n",
+			e.Type, e.Order.OrderNumber, e.Order.Type, e.Order.CurrencyPair,
+			e.Order.State, e.Order.Filled, e.Order.Remaining)
+	}
+}
+
+// Track the open orders and reconcile them every 10 seconds for one minute
+func trackOpenOrders() {
+
+	if err := manager.TrackOpenOrders(); err != nil {
+		log.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go manager.Run(10*time.Second, done)
+
+	time.Sleep(time.Minute)
+	close(done)
+}
+
+// Post-only buy of 1 BTC_ETH, moved up after 30 seconds then cancelled
+func buyAndMove() {
+
+	order, err := manager.Place("BTC_ETH", "buy", 0.03, 1, client.BuyPostOnly)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	time.Sleep(30 * time.Second)
+	if err := manager.Reconcile(); err != nil {
+		log.Fatal(err)
+	}
+
+	order, _ = manager.Order(order.OrderNumber)
+	order, err = manager.MoveWith(order.OrderNumber, 0.031, order.Remaining,
+		client.MoveOrderPostOnly)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	time.Sleep(30 * time.Second)
+	if _, err := manager.Cancel(order.OrderNumber); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := manager.Reconcile(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,485 @@
+// Order lifecycle management.
+//
+// The trading API only returns an order number (and the trades executed immediately)
+// when an order is placed, moved or cancelled. A Manager keeps a local model of each
+// order it places or tracks through its lifetime (new, partially filled, filled,
+// cancelled), following the order number changes caused by MoveOrder, and reconciles
+// it with the exchange by polling GetOpenOrders and GetTradesFromOrder. Every change
+// is emitted as an Event.
+package orders
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	poloniex "github.com/joemocquant/poloniex-api"
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:orders]")
+
+type State int
+
+const (
+	New             State = iota // on the book, nothing filled yet
+	PartiallyFilled              // on the book, partially filled
+	Filled                       // completely filled
+	Cancelled                    // cancelled (possibly after partial fills)
+)
+
+func (s State) String() string {
+
+	switch s {
+	case New:
+		return "new"
+	case PartiallyFilled:
+		return "partially filled"
+	case Filled:
+		return "filled"
+	case Cancelled:
+		return "cancelled"
+	default:
+		return fmt.Sprintf("unknown state %d", int(s))
+	}
+}
+
+// Done reports whether the order left the book.
+func (s State) Done() bool {
+	return s == Filled || s == Cancelled
+}
+
+type Trade struct {
+	TradeId     int64
+	OrderNumber int64 // order number the trade was executed under
+	Rate        float64
+	Amount      float64
+	Total       float64
+	Fee         float64 // fee rate, known once reconciled with GetTradesFromOrder
+	Date        int64   // Unix timestamp
+}
+
+type Order struct {
+	Id              int64 // first order number, unchanged by moves
+	OrderNumber     int64 // current order number
+	PreviousNumbers []int64
+	CurrencyPair    string
+	Type            string // buy or sell
+	Rate            float64
+	Amount          float64 // amount of the current order number
+	Remaining       float64 // amount still on the book
+	Filled          float64 // amount filled under every order number
+	State           State
+	Trades          []*Trade
+	Created         time.Time
+	Updated         time.Time
+
+	tradeIds map[int64]*Trade
+	pending  []int64 // previous order numbers whose last trades are not fetched yet
+}
+
+type EventType int
+
+const (
+	Placed EventType = iota
+	Fill             // new trades, Order.State is PartiallyFilled or Filled
+	Moved
+	Done // filled or cancelled, see Order.State
+)
+
+func (t EventType) String() string {
+
+	switch t {
+	case Placed:
+		return "placed"
+	case Fill:
+		return "fill"
+	case Moved:
+		return "moved"
+	case Done:
+		return "done"
+	default:
+		return fmt.Sprintf("unknown event %d", int(t))
+	}
+}
+
+type Event struct {
+	Type           EventType
+	Order          *Order   // copy of the order after the event
+	Trades         []*Trade // Fill only: new trades
+	PreviousNumber int64    // Moved only: order number before the move
+}
+
+type Events chan *Event
+
+// PlaceFunc places an order, e.g. tradingapi.Client.BuyPostOnly.
+type PlaceFunc func(currencyPair string, rate, amount float64) (*tradingapi.BuyOrSellOrder, error)
+
+// MoveFunc moves an order, e.g. tradingapi.Client.MoveOrderPostOnly.
+type MoveFunc func(orderNumber int64, rate, amount float64) (*tradingapi.MovedOrder, error)
+
+type Manager struct {
+	client *tradingapi.Client
+
+	mu     sync.Mutex
+	orders map[int64]*Order // by current and previous order numbers
+
+	reconcileMu sync.Mutex
+
+	events Events
+}
+
+// NewManager returns a manager placing and reconciling orders with client.
+func NewManager(client *tradingapi.Client) *Manager {
+
+	m := Manager{
+		client: client,
+		orders: make(map[int64]*Order),
+		events: make(Events, 100),
+	}
+
+	return &m
+}
+
+// Events returns the channel on which lifecycle events are emitted. It must be
+// consumed while orders are managed.
+func (m *Manager) Events() Events {
+	return m.events
+}
+
+// Buy places a limit buy order.
+func (m *Manager) Buy(currencyPair string, rate, amount float64) (*Order, error) {
+	return m.Place(currencyPair, "buy", rate, amount, m.client.Buy)
+}
+
+// Sell places a limit sell order.
+func (m *Manager) Sell(currencyPair string, rate, amount float64) (*Order, error) {
+	return m.Place(currencyPair, "sell", rate, amount, m.client.Sell)
+}
+
+// Place places an order with place (any buy or sell function of the trading
+// client) and tracks it. typeOrder is "buy" or "sell".
+func (m *Manager) Place(currencyPair, typeOrder string, rate, amount float64, place PlaceFunc) (*Order, error) {
+
+	res, err := place(currencyPair, rate, amount)
+	if err != nil {
+		return nil, fmt.Errorf("place: %v", err)
+	}
+
+	return m.Track(currencyPair, typeOrder, rate, amount, res), nil
+}
+
+// Track tracks an order placed without the manager.
+func (m *Manager) Track(currencyPair, typeOrder string, rate, amount float64, res *tradingapi.BuyOrSellOrder) *Order {
+
+	now := time.Now()
+
+	o := &Order{
+		Id:           res.OrderNumber,
+		OrderNumber:  res.OrderNumber,
+		CurrencyPair: currencyPair,
+		Type:         typeOrder,
+		Rate:         rate,
+		Amount:       amount,
+		Remaining:    amount,
+		State:        New,
+		Created:      now,
+		Updated:      now,
+		tradeIds:     make(map[int64]*Trade),
+	}
+
+	m.mu.Lock()
+
+	m.orders[o.OrderNumber] = o
+	events := []*Event{{Type: Placed, Order: o.copy()}}
+
+	var trades []*Trade
+	for i := range res.ResultingTrades {
+		trades = append(trades, newTrade(o.OrderNumber, &res.ResultingTrades[i]))
+	}
+	events = append(events, m.applyTrades(o, trades)...)
+
+	c := o.copy()
+
+	m.mu.Unlock()
+
+	m.emit(events)
+
+	return c
+}
+
+// Move moves an order with MoveOrder.
+func (m *Manager) Move(orderNumber int64, rate, amount float64) (*Order, error) {
+	return m.MoveWith(orderNumber, rate, amount, m.client.MoveOrder)
+}
+
+// MoveWith moves an order with move (any move function of the trading client).
+// The order keeps its Id and gets the new order number.
+func (m *Manager) MoveWith(orderNumber int64, rate, amount float64, move MoveFunc) (*Order, error) {
+
+	m.mu.Lock()
+	o, ok := m.orders[orderNumber]
+	if ok && o.OrderNumber != orderNumber {
+		ok = false
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("order %d not tracked or already moved", orderNumber)
+	}
+
+	res, err := move(orderNumber, rate, amount)
+	if err != nil {
+		return nil, fmt.Errorf("move: %v", err)
+	}
+
+	return m.TrackMove(orderNumber, rate, amount, res)
+}
+
+// TrackMove applies a move done without the manager.
+func (m *Manager) TrackMove(orderNumber int64, rate, amount float64, res *tradingapi.MovedOrder) (*Order, error) {
+
+	if !res.Success {
+		return nil, fmt.Errorf("order %d not moved", orderNumber)
+	}
+
+	m.mu.Lock()
+
+	o, ok := m.orders[orderNumber]
+	if !ok {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("order %d not tracked", orderNumber)
+	}
+
+	o.PreviousNumbers = append(o.PreviousNumbers, o.OrderNumber)
+	o.pending = append(o.pending, o.OrderNumber)
+	o.OrderNumber = res.OrderNumber
+	o.Rate = rate
+	o.Amount = amount
+	o.Remaining = amount
+	o.Updated = time.Now()
+	if o.Filled > 0 {
+		o.State = PartiallyFilled
+	} else {
+		o.State = New
+	}
+	m.orders[o.OrderNumber] = o
+
+	events := []*Event{{Type: Moved, Order: o.copy(), PreviousNumber: orderNumber}}
+
+	var trades []*Trade
+	for _, resultingTrades := range res.ResultingTrades {
+		for _, t := range resultingTrades {
+			trades = append(trades, newTrade(o.OrderNumber, t))
+		}
+	}
+	events = append(events, m.applyTrades(o, trades)...)
+
+	c := o.copy()
+
+	m.mu.Unlock()
+
+	m.emit(events)
+
+	return c, nil
+}
+
+// Cancel cancels an order. Trades executed before the cancellation and not yet
+// reconciled are reported by the next reconciliation.
+func (m *Manager) Cancel(orderNumber int64) (*Order, error) {
+
+	m.mu.Lock()
+	o, ok := m.orders[orderNumber]
+	if ok && o.OrderNumber != orderNumber {
+		ok = false
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("order %d not tracked or already moved", orderNumber)
+	}
+
+	res, err := m.client.CancelOrder(orderNumber)
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.CancelOrder: %v", err)
+	}
+
+	if !res.Success {
+		return nil, fmt.Errorf("order %d not cancelled: %s", orderNumber, res.Message)
+	}
+
+	m.mu.Lock()
+
+	o.pending = append(o.pending, o.OrderNumber)
+	events := m.setDone(o, Cancelled)
+	c := o.copy()
+
+	m.mu.Unlock()
+
+	m.emit(events)
+
+	return c, nil
+}
+
+// Order returns a copy of the order with the given current or previous order number.
+func (m *Manager) Order(orderNumber int64) (*Order, bool) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, ok := m.orders[orderNumber]
+	if !ok {
+		return nil, false
+	}
+
+	return o.copy(), true
+}
+
+// Orders returns a copy of every tracked order sorted by creation date. Filled and
+// cancelled orders are included when done is true.
+func (m *Manager) Orders(done bool) []*Order {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var res []*Order
+
+	for number, o := range m.orders {
+		if number == o.OrderNumber && (done || !o.State.Done()) {
+			res = append(res, o.copy())
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Created.Before(res[j].Created)
+	})
+
+	return res
+}
+
+// Forget stops tracking a filled or cancelled order.
+func (m *Manager) Forget(orderNumber int64) error {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, ok := m.orders[orderNumber]
+	if !ok {
+		return fmt.Errorf("order %d not tracked", orderNumber)
+	}
+
+	if !o.State.Done() || len(o.pending) > 0 {
+		return errors.New("order still open or not reconciled")
+	}
+
+	delete(m.orders, o.OrderNumber)
+	for _, number := range o.PreviousNumbers {
+		delete(m.orders, number)
+	}
+
+	return nil
+}
+
+// applyTrades adds the trades not seen yet and updates the order state. It must be
+// called with m.mu held.
+func (m *Manager) applyTrades(o *Order, trades []*Trade) []*Event {
+
+	var added []*Trade
+
+	for _, t := range trades {
+
+		if known, ok := o.tradeIds[t.TradeId]; ok {
+			if t.Fee != 0 {
+				known.Fee = t.Fee
+			}
+			continue
+		}
+
+		o.tradeIds[t.TradeId] = t
+		o.Trades = append(o.Trades, t)
+		o.Filled += t.Amount
+		if t.OrderNumber == o.OrderNumber {
+			o.Remaining -= t.Amount
+		}
+		added = append(added, t)
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	o.Updated = time.Now()
+	if o.Remaining < util.Epsilon {
+		o.Remaining = 0
+	}
+
+	var done []*Event
+
+	switch {
+	case o.State.Done():
+	case o.Remaining == 0:
+		done = m.setDone(o, Filled)
+	default:
+		o.State = PartiallyFilled
+	}
+
+	events := []*Event{{Type: Fill, Order: o.copy(), Trades: added}}
+
+	return append(events, done...)
+}
+
+// setDone must be called with m.mu held.
+func (m *Manager) setDone(o *Order, state State) []*Event {
+
+	if o.State.Done() {
+		return nil
+	}
+
+	o.State = state
+	o.Remaining = 0
+	o.Updated = time.Now()
+
+	return []*Event{{Type: Done, Order: o.copy()}}
+}
+
+func (m *Manager) emit(events []*Event) {
+
+	for _, e := range events {
+		logger.Debugf("order %d (%s): %s, %s", e.Order.Id, e.Order.CurrencyPair,
+			e.Type, e.Order.State)
+		m.events <- e
+	}
+}
+
+// copy returns a copy of the order sharing no mutable data with it.
+func (o *Order) copy() *Order {
+
+	c := *o
+
+	c.PreviousNumbers = append([]int64(nil), o.PreviousNumbers...)
+	c.Trades = make([]*Trade, len(o.Trades))
+	for i, t := range o.Trades {
+		trade := *t
+		c.Trades[i] = &trade
+	}
+	c.tradeIds = nil
+	c.pending = nil
+
+	return &c
+}
+
+func newTrade(orderNumber int64, t *poloniex.ResultingTrade) *Trade {
+
+	return &Trade{
+		TradeId:     t.TradeId,
+		OrderNumber: orderNumber,
+		Rate:        t.Rate,
+		Amount:      t.Amount,
+		Total:       t.Total,
+		Date:        t.Date,
+	}
+}
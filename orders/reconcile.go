@@ -0,0 +1,241 @@
+package orders
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+// TrackOpenOrders tracks the open orders not tracked yet, e.g. after a restart.
+// Their past trades are loaded by the next reconciliation.
+func (m *Manager) TrackOpenOrders() error {
+
+	open, err := m.client.GetAllOpenOrders()
+	if err != nil {
+		return fmt.Errorf("TradingClient.GetAllOpenOrders: %v", err)
+	}
+
+	var events []*Event
+
+	m.mu.Lock()
+
+	for pair, orders := range open {
+
+		if orders == nil {
+			continue
+		}
+
+		for _, oo := range *orders {
+
+			if _, ok := m.orders[oo.OrderNumber]; ok {
+				continue
+			}
+
+			o := &Order{
+				Id:           oo.OrderNumber,
+				OrderNumber:  oo.OrderNumber,
+				CurrencyPair: pair,
+				Type:         oo.Type,
+				Rate:         oo.Rate,
+				Amount:       oo.StartingAmount,
+				Remaining:    oo.StartingAmount,
+				State:        New,
+				Created:      time.Unix(oo.Date, 0),
+				Updated:      time.Now(),
+				tradeIds:     make(map[int64]*Trade),
+			}
+
+			m.orders[o.OrderNumber] = o
+			events = append(events, &Event{Type: Placed, Order: o.copy()})
+		}
+	}
+
+	m.mu.Unlock()
+
+	m.emit(events)
+
+	return nil
+}
+
+// Reconcile polls the open orders and the trades of the orders whose remaining
+// amount changed or which left the book. An order missing from the open orders is
+// filled when its trades cover its amount, cancelled otherwise (cancelled outside
+// the manager, or immediate-or-cancel and fill-or-kill orders).
+func (m *Manager) Reconcile() error {
+
+	m.reconcileMu.Lock()
+	defer m.reconcileMu.Unlock()
+
+	type check struct {
+		order       *Order
+		orderNumber int64
+		remaining   float64
+		pending     []int64
+	}
+
+	m.mu.Lock()
+	var checks []check
+	for number, o := range m.orders {
+		if number == o.OrderNumber && (!o.State.Done() || len(o.pending) > 0) {
+			checks = append(checks, check{o, o.OrderNumber, o.Remaining,
+				append([]int64(nil), o.pending...)})
+		}
+	}
+	m.mu.Unlock()
+
+	if len(checks) == 0 {
+		return nil
+	}
+
+	open, err := m.client.GetAllOpenOrders()
+	if err != nil {
+		return fmt.Errorf("TradingClient.GetAllOpenOrders: %v", err)
+	}
+
+	onBook := make(map[int64]*tradingapi.OpenOrder)
+	for _, orders := range open {
+		if orders != nil {
+			for _, oo := range *orders {
+				onBook[oo.OrderNumber] = oo
+			}
+		}
+	}
+
+	var errs []string
+
+	for _, c := range checks {
+
+		// Trades of previous order numbers (moved or cancelled orders)
+		numbers := c.pending
+
+		oo, isOpen := onBook[c.orderNumber]
+		if !isOpen || oo.Amount < c.remaining-util.Epsilon {
+			numbers = append(numbers, c.orderNumber)
+		}
+
+		var trades []*Trade
+		fetched := true
+
+		for _, number := range numbers {
+			t, err := m.getTrades(number)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("order %d: %v", number, err))
+				fetched = false
+				break
+			}
+			trades = append(trades, t...)
+		}
+
+		if !fetched {
+			continue
+		}
+
+		m.mu.Lock()
+
+		o := c.order
+		if o.OrderNumber != c.orderNumber {
+			// Moved meanwhile, next reconciliation
+			m.mu.Unlock()
+			continue
+		}
+
+		o.pending = removeNumbers(o.pending, c.pending)
+		events := m.applyTrades(o, trades)
+
+		if isOpen {
+			o.Remaining = oo.Amount
+		} else if !o.State.Done() {
+			filled := 0.0
+			for _, t := range o.Trades {
+				if t.OrderNumber == o.OrderNumber {
+					filled += t.Amount
+				}
+			}
+			if filled >= o.Amount-util.Epsilon {
+				events = append(events, m.setDone(o, Filled)...)
+			} else {
+				events = append(events, m.setDone(o, Cancelled)...)
+			}
+		}
+
+		m.mu.Unlock()
+
+		m.emit(events)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("reconcile: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// Run reconciles the orders every interval until done is closed.
+func (m *Manager) Run(interval time.Duration, done <-chan struct{}) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.Reconcile(); err != nil {
+				logger.WithField("error", err).Error("Manager.Reconcile")
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// getTrades returns the trades of an order number. The API returns an "order not
+// found" error when the order has no trade; any other error is returned.
+func (m *Manager) getTrades(orderNumber int64) ([]*Trade, error) {
+
+	res, err := m.client.GetTradesFromOrder(orderNumber)
+	if err != nil {
+		if util.IsNoTrades(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("TradingClient.GetTradesFromOrder: %v", err)
+	}
+
+	trades := make([]*Trade, 0, len(res))
+
+	for _, t := range res {
+		trades = append(trades, &Trade{
+			TradeId:     t.TradeId,
+			OrderNumber: orderNumber,
+			Rate:        t.Rate,
+			Amount:      t.Amount,
+			Total:       t.Total,
+			Fee:         t.Fee,
+			Date:        t.Date,
+		})
+	}
+
+	return trades, nil
+}
+
+func removeNumbers(numbers, removed []int64) []int64 {
+
+	var res []int64
+
+	for _, n := range numbers {
+		keep := true
+		for _, r := range removed {
+			if n == r {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			res = append(res, n)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,523 @@
+// Profit and loss.
+//
+// An Engine ingests the trade history (GetFullTradeHistory or GetTradeHistory) and the
+// deposits and withdrawals (GetDepositsWithdrawals) of an account, and maintains the
+// lots of every currency with their cost in a reporting currency (e.g. BTC or USDT),
+// matched under the FIFO, LIFO or average cost method. It reports the realized profit
+// per period and the unrealized profit of the lots against current tickers.
+//
+// Every trade is a swap: a buy on BTC_XMR disposes of BTC and acquires XMR. Trades
+// not involving the reporting currency, and deposits, are valued with a Pricer (e.g.
+// ChartPricer). Fees are accounted as Poloniex charges them: deducted from the amount
+// received. Deposits are acquired at market value, withdrawals remove lots without
+// realizing a gain. Lending earnings (GetLendingHistory) are income acquired at market
+// value. Only exchange trades are accounted.
+package pnl
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:pnl]")
+
+// A Pricer returns the price of one unit of currency in the reporting currency at date.
+type Pricer func(currency string, date time.Time) (float64, error)
+
+type eventKind int
+
+const (
+	deposit eventKind = iota // deposits first at the same date
+	loan
+	trade
+	withdrawal
+)
+
+type event struct {
+	kind         eventKind
+	key          string
+	date         time.Time
+	currencyPair string
+	trade        *tradingapi.Trade
+	deposit      *tradingapi.DepositHistory
+	withdrawal   *tradingapi.WithdrawalHistory
+	loan         *tradingapi.Loan
+}
+
+type Engine struct {
+	quote  string
+	method Method
+	pricer Pricer
+
+	mu     sync.Mutex
+	events map[string]*event
+
+	// Computed from the events when dirty
+	dirty     bool
+	inventory *inventory
+	disposals []*Disposal
+	transfers []*Transfer
+	income    []*Income
+	fees      []*fee
+	skipped   int
+}
+
+type fee struct {
+	date  time.Time
+	value float64
+}
+
+// NewEngine returns an engine reporting in quote (e.g. BTC) under method. pricer may be
+// nil when every trade involves quote and only quote is deposited.
+func NewEngine(quote string, method Method, pricer Pricer) *Engine {
+
+	return &Engine{
+		quote:     quote,
+		method:    method,
+		pricer:    pricer,
+		events:    make(map[string]*event),
+		inventory: newInventory(method),
+	}
+}
+
+// Load ingests the trades, deposits and withdrawals of the account between start and
+// end.
+func (e *Engine) Load(client *tradingapi.Client, start, end time.Time) error {
+
+	trades, err := client.GetFullTradeHistory(start, end)
+	if err != nil {
+		return fmt.Errorf("TradingClient.GetFullTradeHistory: %v", err)
+	}
+
+	dw, err := client.GetDepositsWithdrawals(start, end)
+	if err != nil {
+		return fmt.Errorf("TradingClient.GetDepositsWithdrawals: %v", err)
+	}
+
+	e.AddAllTrades(trades)
+	e.AddDepositsWithdrawals(dw)
+
+	return nil
+}
+
+// AddTrades ingests trades of currencyPair. Trades already ingested are ignored.
+func (e *Engine) AddTrades(currencyPair string, trades tradingapi.TradeHistory) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, t := range trades {
+
+		key := "trade:" + strconv.FormatInt(t.GlobalTradeId, 10)
+		if t.GlobalTradeId == 0 {
+			key = "trade:" + currencyPair + ":" + strconv.FormatInt(t.TradeId, 10)
+		}
+
+		e.add(&event{
+			kind:         trade,
+			key:          key,
+			date:         time.Unix(t.Date, 0),
+			currencyPair: currencyPair,
+			trade:        t,
+		})
+	}
+}
+
+func (e *Engine) AddAllTrades(trades tradingapi.AllTradeHistory) {
+
+	for pair, history := range trades {
+		e.AddTrades(pair, history)
+	}
+}
+
+// AddDepositsWithdrawals ingests the completed deposits and withdrawals.
+func (e *Engine) AddDepositsWithdrawals(dw *tradingapi.DepositsWithdrawals) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, d := range dw.Deposits {
+		if d.Status != "COMPLETE" {
+			continue
+		}
+		e.add(&event{
+			kind:    deposit,
+			key:     "deposit:" + d.Currency + ":" + d.TxId,
+			date:    time.Unix(d.Timestamp, 0),
+			deposit: d,
+		})
+	}
+
+	for _, w := range dw.Withdrawals {
+		if util.WithdrawalStatusOf(w.Status) != util.WithdrawalComplete {
+			continue
+		}
+		e.add(&event{
+			kind:       withdrawal,
+			key:        "withdrawal:" + strconv.FormatInt(w.WithdrawalNumber, 10),
+			date:       time.Unix(w.Timestamp, 0),
+			withdrawal: w,
+		})
+	}
+}
+
+// AddLendingHistory ingests the earnings of closed loans.
+func (e *Engine) AddLendingHistory(history tradingapi.LendingHistory) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, l := range history {
+		if l.Earned <= 0 {
+			continue
+		}
+		e.add(&event{
+			kind: loan,
+			key:  "loan:" + strconv.FormatInt(l.Id, 10),
+			date: time.Unix(l.Close, 0),
+			loan: l,
+		})
+	}
+}
+
+// add must be called with e.mu held.
+func (e *Engine) add(ev *event) {
+
+	if _, ok := e.events[ev.key]; ok {
+		return
+	}
+
+	e.events[ev.key] = ev
+	e.dirty = true
+}
+
+// compute replays the events in date order. It must be called with e.mu held.
+func (e *Engine) compute() error {
+
+	if !e.dirty {
+		return nil
+	}
+
+	events := make([]*event, 0, len(e.events))
+	for _, ev := range e.events {
+		events = append(events, ev)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		switch {
+		case !a.date.Equal(b.date):
+			return a.date.Before(b.date)
+		case a.kind != b.kind:
+			return a.kind < b.kind
+		default:
+			return a.key < b.key
+		}
+	})
+
+	e.inventory = newInventory(e.method)
+	e.disposals, e.transfers, e.income, e.fees, e.skipped = nil, nil, nil, nil, 0
+
+	for _, ev := range events {
+
+		var err error
+
+		switch ev.kind {
+		case trade:
+			err = e.applyTrade(ev)
+		case deposit:
+			err = e.applyDeposit(ev)
+		case loan:
+			err = e.applyLoan(ev)
+		case withdrawal:
+			e.applyWithdrawal(ev)
+		}
+
+		if err != nil {
+			e.dirty = true
+			return fmt.Errorf("%s: %v", ev.key, err)
+		}
+	}
+
+	e.dirty = false
+
+	return nil
+}
+
+// price returns the price of currency in the reporting currency at date.
+func (e *Engine) price(currency string, date time.Time) (float64, error) {
+
+	if currency == e.quote {
+		return 1, nil
+	}
+
+	if e.pricer == nil {
+		return 0, fmt.Errorf("no pricer to value %s in %s", currency, e.quote)
+	}
+
+	price, err := e.pricer(currency, date)
+	if err != nil {
+		return 0, fmt.Errorf("price of %s in %s at %s: %v", currency, e.quote, date.Format(time.RFC3339), err)
+	}
+
+	return price, nil
+}
+
+func (e *Engine) applyTrade(ev *event) error {
+
+	t := ev.trade
+
+	if t.Category != "" && t.Category != "exchange" {
+		e.skipped++
+		return nil
+	}
+
+	base, quote, ok := util.SplitPair(ev.currencyPair)
+	if !ok {
+		return fmt.Errorf("wrong currency pair: %s", ev.currencyPair)
+	}
+
+	// Value of the trade (total) in the reporting currency
+	var value float64
+	switch {
+	case base == e.quote:
+		value = t.Total
+	case quote == e.quote:
+		value = t.Amount
+	default:
+		price, err := e.price(base, ev.date)
+		if err != nil {
+			return err
+		}
+		value = t.Total * price
+	}
+
+	e.fees = append(e.fees, &fee{ev.date, value * t.Fee})
+
+	if t.TypeOrder == "buy" {
+		// base spent, quote received net of fees
+		e.dispose(ev, base, t.Total, value)
+		e.acquire(ev, quote, t.Amount*(1-t.Fee), value)
+	} else {
+		// quote spent, base received net of fees
+		e.dispose(ev, quote, t.Amount, value*(1-t.Fee))
+		e.acquire(ev, base, t.Total*(1-t.Fee), value*(1-t.Fee))
+	}
+
+	return nil
+}
+
+func (e *Engine) acquire(ev *event, currency string, amount, cost float64) {
+
+	if currency == e.quote {
+		return
+	}
+
+	e.inventory.acquire(&Lot{
+		Currency: currency,
+		Amount:   amount,
+		Cost:     cost,
+		Acquired: ev.date,
+		Source:   ev.key,
+	})
+}
+
+func (e *Engine) dispose(ev *event, currency string, amount, proceeds float64) {
+
+	if currency == e.quote || amount <= util.Epsilon {
+		return
+	}
+
+	parts, unmatched := e.inventory.take(currency, amount)
+
+	for _, l := range parts {
+		share := proceeds * l.Amount / amount
+		e.disposals = append(e.disposals, &Disposal{
+			Currency:     currency,
+			Amount:       l.Amount,
+			Proceeds:     share,
+			Cost:         l.Cost,
+			Gain:         share - l.Cost,
+			Acquired:     l.Acquired,
+			Disposed:     ev.date,
+			CurrencyPair: ev.currencyPair,
+			TradeId:      ev.trade.TradeId,
+		})
+	}
+
+	if unmatched > 0 {
+		logger.Warnf("%s: %.8f %s disposed of without lot, zero cost basis", ev.key, unmatched, currency)
+		share := proceeds * unmatched / amount
+		e.disposals = append(e.disposals, &Disposal{
+			Currency:     currency,
+			Amount:       unmatched,
+			Proceeds:     share,
+			Gain:         share,
+			Acquired:     ev.date,
+			Disposed:     ev.date,
+			CurrencyPair: ev.currencyPair,
+			TradeId:      ev.trade.TradeId,
+			Unmatched:    true,
+		})
+	}
+}
+
+func (e *Engine) applyDeposit(ev *event) error {
+
+	d := ev.deposit
+
+	price, err := e.price(d.Currency, ev.date)
+	if err != nil {
+		return err
+	}
+
+	e.acquire(ev, d.Currency, d.Amount, d.Amount*price)
+
+	return nil
+}
+
+func (e *Engine) applyLoan(ev *event) error {
+
+	l := ev.loan
+
+	price, err := e.price(l.Currency, ev.date)
+	if err != nil {
+		return err
+	}
+
+	e.acquire(ev, l.Currency, l.Earned, l.Earned*price)
+
+	e.income = append(e.income, &Income{
+		Currency: l.Currency,
+		Amount:   l.Earned,
+		Value:    l.Earned * price,
+		Date:     ev.date,
+		LoanId:   l.Id,
+	})
+
+	return nil
+}
+
+func (e *Engine) applyWithdrawal(ev *event) {
+
+	w := ev.withdrawal
+
+	if w.Currency == e.quote {
+		return
+	}
+
+	parts, unmatched := e.inventory.take(w.Currency, w.Amount)
+	if unmatched > 0 {
+		logger.Warnf("%s: %.8f %s withdrawn without lot", ev.key, unmatched, w.Currency)
+	}
+
+	t := Transfer{
+		Currency:         w.Currency,
+		Amount:           w.Amount,
+		Date:             ev.date,
+		WithdrawalNumber: w.WithdrawalNumber,
+	}
+	for _, l := range parts {
+		t.Cost += l.Cost
+	}
+
+	e.transfers = append(e.transfers, &t)
+}
+
+// Skipped returns the number of trades not accounted (margin trades and settlements).
+func (e *Engine) Skipped() (int, error) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.compute(); err != nil {
+		return 0, err
+	}
+
+	return e.skipped, nil
+}
+
+func (e *Engine) Quote() string {
+	return e.quote
+}
+
+func (e *Engine) Method() Method {
+	return e.method
+}
+
+// Disposals returns the disposals in date order.
+func (e *Engine) Disposals() ([]*Disposal, error) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.compute(); err != nil {
+		return nil, err
+	}
+
+	res := make([]*Disposal, len(e.disposals))
+	for i, d := range e.disposals {
+		c := *d
+		res[i] = &c
+	}
+
+	return res, nil
+}
+
+// Transfers returns the withdrawals in date order.
+func (e *Engine) Transfers() ([]*Transfer, error) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.compute(); err != nil {
+		return nil, err
+	}
+
+	res := make([]*Transfer, len(e.transfers))
+	for i, t := range e.transfers {
+		c := *t
+		res[i] = &c
+	}
+
+	return res, nil
+}
+
+// Income returns the lending earnings in date order.
+func (e *Engine) Income() ([]*Income, error) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.compute(); err != nil {
+		return nil, err
+	}
+
+	res := make([]*Income, len(e.income))
+	for i, in := range e.income {
+		c := *in
+		res[i] = &c
+	}
+
+	return res, nil
+}
+
+// Lots returns the open lots of currency.
+func (e *Engine) Lots(currency string) ([]*Lot, error) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.compute(); err != nil {
+		return nil, err
+	}
+
+	return e.inventory.copy(currency), nil
+}
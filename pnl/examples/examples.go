@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/pnl"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+var (
+	tradingClient *tradingapi.Client
+	publicClient  *publicapi.Client
+	engine        *pnl.Engine
+)
+
+func main() {
+
+	var err error
+	tradingClient, err = tradingapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	publicClient = publicapi.NewClient()
+
+	// Profit in BTC of the last year, FIFO
+	engine = pnl.NewEngine("BTC", pnl.FIFO, pnl.ChartPricer(publicClient, "BTC"))
+
+	end := time.Now()
+	if err := engine.Load(tradingClient, end.AddDate(-1, 0, 0), end); err != nil {
+		log.Fatal(err)
+	}
+
+	printRealized()
+
+	// printUnrealized()
+
+	// printLots("XMR")
+}
+
+// Print the realized profit per month
+func printRealized() {
+
+	periods, err := engine.Realized(pnl.Month)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, p := range periods {
+		fmt.Printf("%s: %3d disposals, realized %.8f %s (fees %.8f)\n",
+			p.Start.Format("2006-01"), p.Disposals, p.Realized, engine.Quote(), p.Fees)
+	}
+}
+
+// Print the unrealized profit at current tickers
+func printUnrealized() {
+
+	ticks, err := publicClient.GetTickers()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	positions, err := engine.Unrealized(ticks)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, p := range positions {
+		fmt.Printf("%-6s %16.8f cost %.8f value %.8f unrealized %.8f\n",
+			p.Currency, p.Amount, p.Cost, p.Value, p.Unrealized)
+	}
+}
+
+// Print the open lots of currency
+func printLots(currency string) {
+
+	lots, err := engine.Lots(currency)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, l := range lots {
+		fmt.Printf("%s %.8f %s cost %.8f\n",
+			l.Acquired.Format("2006-01-02 15:04:05"), l.Amount, l.Currency, l.Cost)
+	}
+}
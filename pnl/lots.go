@@ -0,0 +1,161 @@
+package pnl
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+)
+
+// Method selects the lots a disposal is matched against.
+type Method int
+
+const (
+	FIFO        Method = iota // oldest lots first
+	LIFO                      // newest lots first
+	AverageCost               // single pooled lot per currency
+)
+
+func (m Method) String() string {
+
+	switch m {
+	case FIFO:
+		return "FIFO"
+	case LIFO:
+		return "LIFO"
+	case AverageCost:
+		return "average cost"
+	default:
+		return fmt.Sprintf("unknown method %d", int(m))
+	}
+}
+
+// A Lot is an amount of a currency acquired at once (at least for FIFO and LIFO),
+// with its cost in the reporting currency.
+type Lot struct {
+	Currency string
+	Amount   float64
+	Cost     float64
+	Acquired time.Time // earliest acquisition of the pooled lot for AverageCost
+	Source   string    // key of the trade or deposit which acquired the lot
+}
+
+// A Disposal is an amount of a currency sold or swapped, matched against one lot (a
+// disposal spanning several lots is split).
+type Disposal struct {
+	Currency     string
+	Amount       float64
+	Proceeds     float64 // in the reporting currency, net of fees
+	Cost         float64 // cost basis of the amount in the reporting currency
+	Gain         float64 // Proceeds - Cost
+	Acquired     time.Time
+	Disposed     time.Time
+	CurrencyPair string
+	TradeId      int64
+	// Amount disposed of beyond the known lots (e.g. history loaded from a later date):
+	// its cost basis is zero
+	Unmatched bool
+}
+
+// A Transfer is an amount withdrawn, removed from the lots without realizing a gain.
+type Transfer struct {
+	Currency         string
+	Amount           float64
+	Cost             float64 // cost basis of the lots removed
+	Date             time.Time
+	WithdrawalNumber int64
+}
+
+// Income is the earnings of a loan, acquired as a lot at its market value.
+type Income struct {
+	Currency string
+	Amount   float64
+	Value    float64 // in the reporting currency
+	Date     time.Time
+	LoanId   int64
+}
+
+// inventory holds the lots of every currency.
+type inventory struct {
+	method Method
+	lots   map[string][]*Lot
+}
+
+func newInventory(method Method) *inventory {
+	return &inventory{method: method, lots: make(map[string][]*Lot)}
+}
+
+func (inv *inventory) acquire(l *Lot) {
+
+	if l.Amount <= util.Epsilon {
+		return
+	}
+
+	lots := inv.lots[l.Currency]
+
+	if inv.method == AverageCost && len(lots) > 0 {
+		pool := lots[0]
+		pool.Amount += l.Amount
+		pool.Cost += l.Cost
+		return
+	}
+
+	inv.lots[l.Currency] = append(lots, l)
+}
+
+// take removes amount of currency from the lots, returning the parts removed (one per
+// lot) and the amount which could not be matched.
+func (inv *inventory) take(currency string, amount float64) ([]*Lot, float64) {
+
+	var parts []*Lot
+	lots := inv.lots[currency]
+
+	for amount > util.Epsilon && len(lots) > 0 {
+
+		i := 0
+		if inv.method == LIFO {
+			i = len(lots) - 1
+		}
+		l := lots[i]
+
+		if l.Amount <= amount+util.Epsilon {
+			parts = append(parts, l)
+			amount -= l.Amount
+			lots = append(lots[:i], lots[i+1:]...)
+			continue
+		}
+
+		ratio := amount / l.Amount
+		part := &Lot{
+			Currency: currency,
+			Amount:   amount,
+			Cost:     l.Cost * ratio,
+			Acquired: l.Acquired,
+			Source:   l.Source,
+		}
+		l.Amount -= amount
+		l.Cost -= part.Cost
+
+		parts = append(parts, part)
+		amount = 0
+	}
+
+	inv.lots[currency] = lots
+
+	if amount < util.Epsilon {
+		amount = 0
+	}
+
+	return parts, amount
+}
+
+func (inv *inventory) copy(currency string) []*Lot {
+
+	var res []*Lot
+	for _, l := range inv.lots[currency] {
+		c := *l
+		res = append(res, &c)
+	}
+
+	return res
+}
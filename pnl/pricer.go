@@ -0,0 +1,157 @@
+package pnl
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+// Candle period of the charts loaded by ChartPricer
+const pricerPeriod = 1800
+
+type chartPricer struct {
+	public *publicapi.Client
+	quote  string
+
+	mu     sync.Mutex
+	pairs  map[string]bool
+	charts map[string]publicapi.ChartData // by currency pair and day
+}
+
+// ChartPricer returns a pricer valuing currencies in quote with the weighted average
+// rate of the 30 minute candle (GetChartData) containing the date. Currencies without
+// a market against quote are valued through BTC. Charts are loaded a day at a time and
+// kept in memory.
+func ChartPricer(public *publicapi.Client, quote string) Pricer {
+
+	p := chartPricer{
+		public: public,
+		quote:  quote,
+		charts: make(map[string]publicapi.ChartData),
+	}
+
+	return p.price
+}
+
+func (p *chartPricer) price(currency string, date time.Time) (float64, error) {
+
+	if err := p.loadPairs(); err != nil {
+		return 0, err
+	}
+
+	price, ok, err := p.direct(currency, p.quote, date)
+	if err != nil || ok {
+		return price, err
+	}
+
+	if currency != "BTC" && p.quote != "BTC" {
+
+		toBTC, ok, err := p.direct(currency, "BTC", date)
+		if err != nil {
+			return 0, err
+		}
+
+		if ok {
+			fromBTC, ok, err := p.direct("BTC", p.quote, date)
+			if err != nil {
+				return 0, err
+			}
+			if ok {
+				return toBTC * fromBTC, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("no market to value %s in %s", currency, p.quote)
+}
+
+func (p *chartPricer) loadPairs() error {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pairs != nil {
+		return nil
+	}
+
+	ticks, err := p.public.GetTickers()
+	if err != nil {
+		return fmt.Errorf("PublicClient.GetTickers: %v", err)
+	}
+
+	p.pairs = make(map[string]bool)
+	for pair := range ticks {
+		p.pairs[pair] = true
+	}
+
+	return nil
+}
+
+// direct returns the price of currency in quote from their common market, false
+// when there is none.
+func (p *chartPricer) direct(currency, quote string, date time.Time) (float64, bool, error) {
+
+	p.mu.Lock()
+	inverse, direct := p.pairs[currency+"_"+quote], p.pairs[quote+"_"+currency]
+	p.mu.Unlock()
+
+	switch {
+	case direct:
+		// Rates of quote_currency are quote per currency
+		rate, err := p.rate(quote+"_"+currency, date)
+		return rate, true, err
+
+	case inverse:
+		rate, err := p.rate(currency+"_"+quote, date)
+		if err != nil || rate == 0 {
+			return 0, true, err
+		}
+		return 1 / rate, true, nil
+	}
+
+	return 0, false, nil
+}
+
+// rate returns the rate of currencyPair at date.
+func (p *chartPricer) rate(currencyPair string, date time.Time) (float64, error) {
+
+	day := date.UTC().Truncate(24 * time.Hour)
+	key := currencyPair + day.Format(":2006-01-02")
+
+	p.mu.Lock()
+	chart, ok := p.charts[key]
+	p.mu.Unlock()
+
+	if !ok {
+
+		var err error
+		chart, err = p.public.GetChartData(currencyPair, day, day.Add(24*time.Hour), pricerPeriod)
+		if err != nil {
+			return 0, fmt.Errorf("PublicClient.GetChartData: %v", err)
+		}
+
+		p.mu.Lock()
+		p.charts[key] = chart
+		p.mu.Unlock()
+	}
+
+	// Last candle starting before date
+	var candle *publicapi.CandleStick
+	for _, c := range chart {
+		if c.Date <= date.Unix() {
+			candle = c
+		}
+	}
+
+	if candle == nil {
+		return 0, fmt.Errorf("no %s candle at %s", currencyPair, date.Format(time.RFC3339))
+	}
+
+	if candle.WeighedtAverage > 0 {
+		return candle.WeighedtAverage, nil
+	}
+
+	return candle.Close, nil
+}
@@ -0,0 +1,147 @@
+package pnl
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/portfolio"
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+type Period int
+
+const (
+	Day Period = iota
+	Month
+	Year
+)
+
+// start returns the start of the period (UTC) containing date.
+func (p Period) start(date time.Time) time.Time {
+
+	date = date.UTC()
+
+	switch p {
+	case Day:
+		return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	case Month:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(date.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Realized profit of a period, in the reporting currency.
+type PeriodPnL struct {
+	Start     time.Time
+	Disposals int
+	Proceeds  float64
+	Cost      float64
+	Realized  float64 // Proceeds - Cost
+	Fees      float64 // fees of the trades of the period, included in Proceeds and Cost
+}
+
+// Realized returns the realized profit of every period with disposals or fees, in
+// date order.
+func (e *Engine) Realized(period Period) ([]*PeriodPnL, error) {
+
+	if period < Day || period > Year {
+		return nil, fmt.Errorf("Wrong period parameter: %d", period)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.compute(); err != nil {
+		return nil, err
+	}
+
+	periods := make(map[time.Time]*PeriodPnL)
+
+	get := func(date time.Time) *PeriodPnL {
+		start := period.start(date)
+		p, ok := periods[start]
+		if !ok {
+			p = &PeriodPnL{Start: start}
+			periods[start] = p
+		}
+		return p
+	}
+
+	for _, d := range e.disposals {
+		p := get(d.Disposed)
+		p.Disposals++
+		p.Proceeds += d.Proceeds
+		p.Cost += d.Cost
+		p.Realized += d.Gain
+	}
+
+	for _, f := range e.fees {
+		get(f.date).Fees += f.value
+	}
+
+	res := make([]*PeriodPnL, 0, len(periods))
+	for _, p := range periods {
+		res = append(res, p)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Start.Before(res[j].Start) })
+
+	return res, nil
+}
+
+// Position of a currency valued at current rates, in the reporting currency.
+type Position struct {
+	Currency   string
+	Amount     float64
+	Cost       float64
+	Value      float64
+	Unrealized float64 // Value - Cost
+	Unpriced   bool    // no path to the reporting currency: Value and Unrealized are 0
+}
+
+// Unrealized values the open lots with the last rates of ticks (GetTickers).
+func (e *Engine) Unrealized(ticks publicapi.Ticks) ([]*Position, error) {
+
+	rates := portfolio.NewRates()
+	for pair, tick := range ticks {
+		rates.Set(pair, tick.Last)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.compute(); err != nil {
+		return nil, err
+	}
+
+	var res []*Position
+
+	for currency, lots := range e.inventory.lots {
+
+		p := Position{Currency: currency}
+		for _, l := range lots {
+			p.Amount += l.Amount
+			p.Cost += l.Cost
+		}
+
+		if p.Amount <= util.Epsilon {
+			continue
+		}
+
+		if value, err := rates.Convert(p.Amount, currency, e.quote); err != nil {
+			p.Unpriced = true
+		} else {
+			p.Value = value
+			p.Unrealized = value - p.Cost
+		}
+
+		res = append(res, &p)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Currency < res[j].Currency })
+
+	return res, nil
+}
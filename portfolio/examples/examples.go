@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/portfolio"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/pushapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+var p *portfolio.Portfolio
+
+func main() {
+
+	tradingClient, err := tradingapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	p, err = portfolio.NewPortfolio(tradingClient, publicapi.NewClient(),
+		portfolio.Params{Quote: "USDT"})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := p.Refresh(); err != nil {
+		log.Fatal(err)
+	}
+
+	printValuations()
+
+	// watch()
+}
+
+// Print the portfolio valued in BTC, ETH and USDT
+func printValuations() {
+
+	for _, quote := range []string{"BTC", "ETH", "USDT"} {
+		printValuation(p.Value(quote))
+	}
+}
+
+// Print the USDT valuation on every tick, reloading balances every minute
+func watch() {
+
+	pushClient, err := pushapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ticker, err := pushClient.SubscribeTicker()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go p.RunTicker(ticker, time.Minute, nil)
+
+	for v := range p.Updates() {
+		fmt.Printf("%s %.8f %s\n", v.Time.Format(time.RFC3339), v.Total, v.Quote)
+	}
+}
+
+func printValuation(v *portfolio.Valuation) {
+
+	fmt.Printf("Total: %.8f %s\n", v.Total, v.Quote)
+
+	for _, h := range v.Holdings {
+		fmt.Printf("  %-6s %16.8f %16.8f %6.2f%% (%s)\n",
+			h.Currency, h.Amount, h.Value, h.Allocation, strings.Join(h.Path, "->"))
+	}
+
+	if len(v.Unpriced) > 0 {
+		fmt.Printf("  unpriced: %s\n", strings.Join(v.Unpriced, ", "))
+	}
+}
@@ -0,0 +1,288 @@
+// Portfolio valuation.
+//
+// A Portfolio combines the account balances with the last rates of the markets to
+// value the holdings in any currency reachable through markets (BTC, ETH, USDT...):
+// a holding without a market against the valuation currency is converted along the
+// shortest path of markets (e.g. XMR to USDT through BTC). Valuations include the
+// allocation of every holding, and are pushed on every tick of a market they depend
+// on when the portfolio follows the push ticker.
+package portfolio
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/pushapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:portfolio]")
+
+type Params struct {
+	Quote         string // valuation currency of Valuation and of the pushed valuations
+	AvailableOnly bool   // GetBalances instead of GetCompleteBalances (no amounts on orders)
+}
+
+type Holding struct {
+	Currency   string
+	Amount     float64
+	Value      float64  // in the valuation currency
+	Allocation float64  // percentage of the total value
+	Path       []string // conversion path, from Currency to the valuation currency
+}
+
+type Valuation struct {
+	Quote    string
+	Total    float64
+	Holdings []*Holding // by decreasing value
+	Unpriced []string   // currencies held without a path to the valuation currency
+	Time     time.Time
+}
+
+type Portfolio struct {
+	client *tradingapi.Client
+	public *publicapi.Client
+	params Params
+
+	mu       sync.Mutex
+	balances map[string]float64
+	rates    *Rates
+	watched  map[string]bool // markets the pushed valuation depends on
+
+	updates chan *Valuation
+}
+
+// NewPortfolio returns the portfolio of the account of client. public is used to load
+// the tickers.
+func NewPortfolio(client *tradingapi.Client, public *publicapi.Client, params Params) (*Portfolio, error) {
+
+	if params.Quote == "" {
+		return nil, fmt.Errorf("Wrong quote parameter: no currency")
+	}
+
+	p := Portfolio{
+		client:   client,
+		public:   public,
+		params:   params,
+		balances: make(map[string]float64),
+		rates:    NewRates(),
+		watched:  make(map[string]bool),
+		updates:  make(chan *Valuation, 100),
+	}
+
+	return &p, nil
+}
+
+// Refresh loads the balances and the tickers.
+func (p *Portfolio) Refresh() error {
+
+	if err := p.RefreshBalances(); err != nil {
+		return err
+	}
+
+	ticks, err := p.public.GetTickers()
+	if err != nil {
+		return fmt.Errorf("PublicClient.GetTickers: %v", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for pair, tick := range ticks {
+		if !p.rates.Set(pair, tick.Last) {
+			logger.Warnf("wrong currency pair: %s", pair)
+		}
+	}
+
+	return nil
+}
+
+// RefreshBalances loads the balances only.
+func (p *Portfolio) RefreshBalances() error {
+
+	balances := make(map[string]float64)
+
+	if p.params.AvailableOnly {
+
+		res, err := p.client.GetBalances()
+		if err != nil {
+			return fmt.Errorf("TradingClient.GetBalances: %v", err)
+		}
+
+		for currency, amount := range res {
+			if amount > 0 {
+				balances[currency] = amount
+			}
+		}
+
+	} else {
+
+		res, err := p.client.GetCompleteBalances()
+		if err != nil {
+			return fmt.Errorf("TradingClient.GetCompleteBalances: %v", err)
+		}
+
+		for currency, b := range res {
+			if amount := b.Available + b.OnOrders; amount > 0 {
+				balances[currency] = amount
+			}
+		}
+	}
+
+	p.mu.Lock()
+	p.balances = balances
+	p.mu.Unlock()
+
+	return nil
+}
+
+// Valuation values the portfolio in the currency of the parameters.
+func (p *Portfolio) Valuation() *Valuation {
+	return p.Value(p.params.Quote)
+}
+
+// Value values the portfolio in quote with the last loaded rates.
+func (p *Portfolio) Value(quote string) *Valuation {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.value(quote)
+}
+
+// value must be called with p.mu held.
+func (p *Portfolio) value(quote string) *Valuation {
+
+	v := Valuation{Quote: quote, Time: time.Now()}
+
+	for currency, amount := range p.balances {
+
+		path := p.rates.Path(currency, quote)
+		if path == nil {
+			v.Unpriced = append(v.Unpriced, currency)
+			continue
+		}
+
+		value, err := p.rates.convert(amount, path)
+		if err != nil {
+			v.Unpriced = append(v.Unpriced, currency)
+			continue
+		}
+
+		v.Holdings = append(v.Holdings, &Holding{
+			Currency: currency,
+			Amount:   amount,
+			Value:    value,
+			Path:     path,
+		})
+		v.Total += value
+	}
+
+	for _, h := range v.Holdings {
+		if v.Total > 0 {
+			h.Allocation = h.Value / v.Total * 100
+		}
+	}
+
+	sort.Slice(v.Holdings, func(i, j int) bool {
+		return v.Holdings[i].Value > v.Holdings[j].Value
+	})
+	sort.Strings(v.Unpriced)
+
+	return &v
+}
+
+// Convert converts amount of from into to with the last loaded rates.
+func (p *Portfolio) Convert(amount float64, from, to string) (float64, error) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.rates.Convert(amount, from, to)
+}
+
+// Updates returns the channel of the valuations pushed by RunTicker. Valuations are
+// dropped when it is full.
+func (p *Portfolio) Updates() <-chan *Valuation {
+	return p.updates
+}
+
+// RunTicker updates the rates from the push ticker until done is closed, and pushes a
+// valuation on every tick of a market it depends on. Balances are reloaded every
+// refresh period (never when zero).
+func (p *Portfolio) RunTicker(ticker pushapi.Ticker, refresh time.Duration, done <-chan struct{}) {
+
+	var reload <-chan time.Time
+	if refresh > 0 {
+		t := time.NewTicker(refresh)
+		defer t.Stop()
+		reload = t.C
+	}
+
+	p.mu.Lock()
+	p.watch(p.value(p.params.Quote))
+	p.mu.Unlock()
+
+	for {
+		select {
+		case tick := <-ticker:
+
+			if tick == nil {
+				continue
+			}
+
+			p.mu.Lock()
+			p.rates.Set(tick.CurrencyPair, tick.Last)
+			if !p.watched[tick.CurrencyPair] {
+				p.mu.Unlock()
+				continue
+			}
+			v := p.value(p.params.Quote)
+			p.mu.Unlock()
+
+			p.push(v)
+
+		case <-reload:
+
+			if err := p.RefreshBalances(); err != nil {
+				logger.WithField("error", err).Error("Portfolio.RefreshBalances")
+				continue
+			}
+
+			p.mu.Lock()
+			v := p.value(p.params.Quote)
+			p.watch(v)
+			p.mu.Unlock()
+
+			p.push(v)
+
+		case <-done:
+			return
+		}
+	}
+}
+
+// watch sets the markets v depends on. It must be called with p.mu held.
+func (p *Portfolio) watch(v *Valuation) {
+
+	p.watched = make(map[string]bool)
+
+	for _, h := range v.Holdings {
+		for _, pair := range pairs(h.Path) {
+			p.watched[pair] = true
+		}
+	}
+}
+
+func (p *Portfolio) push(v *Valuation) {
+
+	select {
+	case p.updates <- v:
+	default:
+		logger.Warn("updates channel full, valuation dropped")
+	}
+}
@@ -0,0 +1,141 @@
+package portfolio
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+)
+
+// Rates holds the last rate of every market and converts amounts between currencies
+// along the shortest path of markets. It is not safe for concurrent use.
+type Rates struct {
+	last  map[string]float64  // by currency pair
+	links map[string][]string // currency -> currencies sharing a market, sorted
+	paths map[[2]string][]string
+}
+
+func NewRates() *Rates {
+
+	return &Rates{
+		last:  make(map[string]float64),
+		links: make(map[string][]string),
+		paths: make(map[[2]string][]string),
+	}
+}
+
+// Set updates the last rate of currencyPair. It returns false for a wrong pair name.
+func (r *Rates) Set(currencyPair string, last float64) bool {
+
+	base, quote, ok := util.SplitPair(currencyPair)
+	if !ok {
+		return false
+	}
+
+	if _, known := r.last[currencyPair]; !known {
+		r.links[base] = insert(r.links[base], quote)
+		r.links[quote] = insert(r.links[quote], base)
+		r.paths = make(map[[2]string][]string) // new market, paths may be shorter
+	}
+
+	r.last[currencyPair] = last
+
+	return true
+}
+
+// Path returns the currencies from from to to (both included) with the fewest
+// conversions, nil when to can't be reached.
+func (r *Rates) Path(from, to string) []string {
+
+	if from == to {
+		return []string{from}
+	}
+
+	key := [2]string{from, to}
+	if p, ok := r.paths[key]; ok {
+		return p
+	}
+
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+
+	for len(queue) > 0 && previous[to] == "" {
+
+		c := queue[0]
+		queue = queue[1:]
+
+		for _, next := range r.links[c] {
+			if _, seen := previous[next]; !seen {
+				previous[next] = c
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	var p []string
+	if _, ok := previous[to]; ok {
+		for c := to; c != ""; c = previous[c] {
+			p = append([]string{c}, p...)
+		}
+	}
+
+	r.paths[key] = p
+
+	return p
+}
+
+// Convert converts amount of from into to along the path of Path.
+func (r *Rates) Convert(amount float64, from, to string) (float64, error) {
+
+	path := r.Path(from, to)
+	if path == nil {
+		return 0, fmt.Errorf("no path from %s to %s", from, to)
+	}
+
+	return r.convert(amount, path)
+}
+
+// convert converts amount along path.
+func (r *Rates) convert(amount float64, path []string) (float64, error) {
+
+	for i := 1; i < len(path); i++ {
+
+		from, to := path[i-1], path[i]
+
+		if rate, ok := r.last[from+"_"+to]; ok && rate > 0 {
+			// from is the base currency: rates are from per to
+			amount /= rate
+		} else if rate, ok := r.last[to+"_"+from]; ok && rate > 0 {
+			amount *= rate
+		} else {
+			return 0, fmt.Errorf("no rate between %s and %s", from, to)
+		}
+	}
+
+	return amount, nil
+}
+
+// pairs returns the markets used along path.
+func pairs(path []string) []string {
+
+	var res []string
+	for i := 1; i < len(path); i++ {
+		res = append(res, path[i-1]+"_"+path[i], path[i]+"_"+path[i-1])
+	}
+
+	return res
+}
+
+func insert(s []string, v string) []string {
+
+	i := sort.SearchStrings(s, v)
+	if i < len(s) && s[i] == v {
+		return s
+	}
+
+	s = append(s, "")
+	copy(s[i+1:], s[i:])
+	s[i] = v
+
+	return s
+}
@@ -14,6 +14,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 
@@ -52,14 +53,24 @@ func init() {
 
 	logger = logrus.WithField("prefix", "[api:poloniex:publicapi]")
 
+	// Default configuration, overridden by conf.json when present
+	conf = &configuration{apiConf{
+		APIUrl:               "https://poloniex.com/public",
+		HTTPClientTimeoutSec: 10,
+		MaxRequestsSec:       5,
+		LogLevel:             "warn",
+	}}
+
 	content, err := ioutil.ReadFile("conf.json")
 
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		logger.WithField("error", err).Fatal("loading configuration")
 	}
 
-	if err := json.Unmarshal(content, &conf); err != nil {
-		logger.WithField("error", err).Fatal("loading configuration")
+	if err == nil {
+		if err := json.Unmarshal(content, &conf); err != nil {
+			logger.WithField("error", err).Fatal("loading configuration")
+		}
 	}
 
 	switch conf.LogLevel {
@@ -12,6 +12,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"strconv"
 	"sync"
 	"time"
@@ -62,14 +63,25 @@ func init() {
 
 	logger = logrus.WithField("prefix", "[api:poloniex:pushapi]")
 
+	// Default configuration, overridden by conf.json when present
+	conf = &configuration{apiConf{
+		WssUri:          "wss://api.poloniex.com",
+		Realm:           "realm1",
+		LogLevel:        "warn",
+		TimeoutSec:      30,
+		TopicTimeoutMin: 5,
+	}}
+
 	content, err := ioutil.ReadFile("conf.json")
 
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		logger.WithField("error", err).Fatal("loading configuration")
 	}
 
-	if err := json.Unmarshal(content, &conf); err != nil {
-		logger.WithField("error", err).Fatal("loading configuration")
+	if err == nil {
+		if err := json.Unmarshal(content, &conf); err != nil {
+			logger.WithField("error", err).Fatal("loading configuration")
+		}
 	}
 
 	switch conf.LogLevel {
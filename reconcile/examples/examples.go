@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/joemocquant/poloniex-api/reconcile"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+func main() {
+
+	client, err := tradingapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	reconciler, err := reconcile.NewReconciler(client, reconcile.Params{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	report, err := reconciler.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	printReport(report)
+}
+
+func printReport(report *reconcile.Report) {
+
+	fmt.Printf("%d currencies, %d mismatches\n", len(report.Deltas), len(report.Mismatches))
+
+	for _, d := range report.Mismatches {
+
+		fmt.Printf("%-6s expected %.8f reported %.8f delta %+.8f (holds %.8f on orders %.8f)\n",
+			d.Currency, d.Expected, d.Reported, d.Delta, d.ExpectedHolds, d.OnOrders)
+
+		for _, c := range d.Candidates {
+			fmt.Printf("    %s %s %+.8f %s (%s): %s\n",
+				c.Date.Format("2006-01-02 15:04:05"), c.Kind, c.Amount, c.Reference, c.Status, c.Reason)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package reconcile
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+type Kind int
+
+const (
+	Deposit Kind = iota
+	Withdrawal
+	Trade
+	Fee
+)
+
+func (k Kind) String() string {
+
+	switch k {
+	case Deposit:
+		return "deposit"
+	case Withdrawal:
+		return "withdrawal"
+	case Trade:
+		return "trade"
+	case Fee:
+		return "fee"
+	default:
+		return fmt.Sprintf("unknown kind %d", int(k))
+	}
+}
+
+// An Entry is a change of the balance of a currency in the exchange account.
+type Entry struct {
+	Kind      Kind
+	Currency  string
+	Amount    float64 // signed: negative when debited
+	Date      time.Time
+	Reference string // e.g. "trade:BTC_XMR:1234", "deposit:<txid>", "withdrawal:5678"
+	Status    string // deposit and withdrawal status, trade category
+	// Whether the entry is included in the expected balance (completed deposits,
+	// withdrawals not canceled, exchange trades). Other entries are kept as candidates
+	// to explain deltas.
+	Counted bool
+}
+
+// NewLedger returns the entries of trades and dw in date order. A trade gives three
+// entries: the currency spent, the currency received (gross) and the fee, deducted
+// from the currency received.
+func NewLedger(trades tradingapi.AllTradeHistory, dw *tradingapi.DepositsWithdrawals) []*Entry {
+
+	var ledger []*Entry
+
+	for pair, history := range trades {
+
+		base, quote, ok := util.SplitPair(pair)
+		if !ok {
+			logger.Warnf("skipping trades of wrong currency pair: %s", pair)
+			continue
+		}
+
+		for _, t := range history {
+
+			counted := t.Category == "" || t.Category == "exchange"
+			date := time.Unix(t.Date, 0)
+			ref := "trade:" + pair + ":" + strconv.FormatInt(t.TradeId, 10)
+
+			spent, spentAmount := base, t.Total
+			received, receivedAmount := quote, t.Amount
+			if t.TypeOrder == "sell" {
+				spent, spentAmount = quote, t.Amount
+				received, receivedAmount = base, t.Total
+			}
+
+			ledger = append(ledger,
+				&Entry{Trade, spent, -spentAmount, date, ref, t.Category, counted},
+				&Entry{Trade, received, receivedAmount, date, ref, t.Category, counted},
+				&Entry{Fee, received, -receivedAmount * t.Fee, date, ref, t.Category, counted},
+			)
+		}
+	}
+
+	if dw != nil {
+
+		for _, d := range dw.Deposits {
+			ledger = append(ledger, &Entry{
+				Kind:      Deposit,
+				Currency:  d.Currency,
+				Amount:    d.Amount,
+				Date:      time.Unix(d.Timestamp, 0),
+				Reference: "deposit:" + d.TxId,
+				Status:    d.Status,
+				Counted:   d.Status == "COMPLETE",
+			})
+		}
+
+		for _, w := range dw.Withdrawals {
+			// Withdrawals are debited when requested
+			ledger = append(ledger, &Entry{
+				Kind:      Withdrawal,
+				Currency:  w.Currency,
+				Amount:    -w.Amount,
+				Date:      time.Unix(w.Timestamp, 0),
+				Reference: "withdrawal:" + strconv.FormatInt(w.WithdrawalNumber, 10),
+				Status:    w.Status,
+				Counted:   util.WithdrawalStatusOf(w.Status) != util.WithdrawalFailed,
+			})
+		}
+	}
+
+	sort.SliceStable(ledger, func(i, j int) bool { return ledger[i].Date.Before(ledger[j].Date) })
+
+	return ledger
+}
+
+// uncertain returns whether the entry may or may not be reflected in the balances
+// (pending deposits and withdrawals, margin and lending trades).
+func (e *Entry) uncertain() bool {
+
+	switch e.Kind {
+	case Deposit:
+		return e.Status != "COMPLETE"
+	case Withdrawal:
+		return util.WithdrawalStatusOf(e.Status) == util.WithdrawalPending
+	default:
+		return !e.Counted
+	}
+}
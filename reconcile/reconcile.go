@@ -0,0 +1,272 @@
+// Balance reconciliation.
+//
+// The expected balance of every currency is rebuilt from the account history: opening
+// balances, deposits, withdrawals and exchange trades net of fees (see NewLedger). It
+// is compared to the balances reported by GetCompleteBalances (available + on orders),
+// and the amounts held by open orders to OnOrders.
+//
+// For every currency whose delta exceeds the tolerance, the entries most likely
+// responsible are listed: counted entries the exchange does not reflect (delta close to
+// minus their amount), and uncounted or uncertain entries it does (delta close to their
+// amount), such as pending deposits, withdrawals not yet processed or margin trades.
+//
+// Only the exchange account is reconciled: transfers to and from the margin and lending
+// accounts are not part of the history and show as unexplained deltas.
+package reconcile
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:reconcile]")
+
+const (
+	defaultTolerance = 1e-6
+	// Start of the history (Poloniex opened in January 2014)
+	defaultSince = "2014-01-01"
+	// Relative difference between a delta and an entry amount to consider the entry
+	maxCandidateError = 0.01
+	maxCandidates     = 5
+)
+
+type Params struct {
+	Since     time.Time          // start of the history, 2014-01-01 by default
+	Opening   map[string]float64 // balances at Since, by currency
+	Tolerance float64            // deltas up to Tolerance are ignored (rounding), 1e-6 by default
+}
+
+// A Candidate is an entry which may explain a delta.
+type Candidate struct {
+	*Entry
+	// What the delta suggests: "not reflected" for counted entries missing from the
+	// reported balance, "reflected" for uncounted entries included in it
+	Reason string
+	Error  float64 // absolute difference between the delta and the entry
+}
+
+type Delta struct {
+	Currency string
+	Expected float64
+	Reported float64 // available + on orders
+	Delta    float64 // Reported - Expected
+
+	ExpectedHolds float64 // held by open orders
+	OnOrders      float64
+	HoldDelta     float64 // OnOrders - ExpectedHolds (e.g. lending offers, stale orders)
+
+	Entries    int // entries of the currency in the ledger
+	Candidates []*Candidate
+}
+
+// Matches returns whether the balance and the holds are within tolerance.
+func (d *Delta) Matches(tolerance float64) bool {
+	return math.Abs(d.Delta) <= tolerance && math.Abs(d.HoldDelta) <= tolerance
+}
+
+type Report struct {
+	Time       time.Time
+	Since      time.Time
+	Tolerance  float64
+	Deltas     []*Delta // every currency with a balance, a hold or an entry, by currency
+	Mismatches []*Delta // deltas not within tolerance
+}
+
+type Reconciler struct {
+	client *tradingapi.Client
+	params Params
+}
+
+func NewReconciler(client *tradingapi.Client, params Params) (*Reconciler, error) {
+
+	if params.Tolerance < 0 {
+		return nil, fmt.Errorf("Wrong tolerance parameter: %v", params.Tolerance)
+	}
+
+	setDefaults(&params)
+
+	return &Reconciler{client, params}, nil
+}
+
+// Run loads the history, balances and open orders and compares them.
+func (r *Reconciler) Run() (*Report, error) {
+
+	now := time.Now()
+
+	// The history is loaded up to the time of the balances
+	balances, err := r.client.GetCompleteBalances()
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.GetCompleteBalances: %v", err)
+	}
+
+	orders, err := r.client.GetAllOpenOrders()
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.GetAllOpenOrders: %v", err)
+	}
+
+	trades, err := r.client.GetFullTradeHistory(r.params.Since, now)
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.GetFullTradeHistory: %v", err)
+	}
+
+	dw, err := r.client.GetDepositsWithdrawals(r.params.Since, now)
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.GetDepositsWithdrawals: %v", err)
+	}
+
+	report := Compare(NewLedger(trades, dw), balances, orders, r.params)
+	report.Time = now
+
+	return report, nil
+}
+
+// Compare compares the ledger (see NewLedger) to the reported balances and open orders.
+func Compare(ledger []*Entry, balances tradingapi.CompleteBalances, orders tradingapi.AllOpenOrders, params Params) *Report {
+
+	setDefaults(&params)
+
+	deltas := make(map[string]*Delta)
+
+	get := func(currency string) *Delta {
+		d, ok := deltas[currency]
+		if !ok {
+			d = &Delta{Currency: currency}
+			deltas[currency] = d
+		}
+		return d
+	}
+
+	for currency, amount := range params.Opening {
+		get(currency).Expected += amount
+	}
+
+	for _, e := range ledger {
+		d := get(e.Currency)
+		d.Entries++
+		if e.Counted {
+			d.Expected += e.Amount
+		}
+	}
+
+	for currency, b := range balances {
+		if b.Available == 0 && b.OnOrders == 0 {
+			if _, ok := deltas[currency]; !ok {
+				continue
+			}
+		}
+		d := get(currency)
+		d.Reported = b.Available + b.OnOrders
+		d.OnOrders = b.OnOrders
+	}
+
+	for pair, pairOrders := range orders {
+
+		base, quote, ok := util.SplitPair(pair)
+		if !ok || pairOrders == nil {
+			continue
+		}
+
+		for _, o := range *pairOrders {
+			if o.Type == "buy" {
+				get(base).ExpectedHolds += o.Total
+			} else {
+				get(quote).ExpectedHolds += o.Amount
+			}
+		}
+	}
+
+	report := Report{
+		Time:      time.Now(),
+		Since:     params.Since,
+		Tolerance: params.Tolerance,
+	}
+
+	for _, d := range deltas {
+
+		d.Delta = d.Reported - d.Expected
+		d.HoldDelta = d.OnOrders - d.ExpectedHolds
+
+		report.Deltas = append(report.Deltas, d)
+
+		if !d.Matches(params.Tolerance) {
+			if math.Abs(d.Delta) > params.Tolerance {
+				d.Candidates = candidates(ledger, d)
+			}
+			report.Mismatches = append(report.Mismatches, d)
+		}
+	}
+
+	sort.Slice(report.Deltas, func(i, j int) bool {
+		return report.Deltas[i].Currency < report.Deltas[j].Currency
+	})
+	sort.Slice(report.Mismatches, func(i, j int) bool {
+		return report.Mismatches[i].Currency < report.Mismatches[j].Currency
+	})
+
+	return &report
+}
+
+// candidates returns the entries of the currency of d whose amount best explains the
+// delta, uncertain entries first at equal error.
+func candidates(ledger []*Entry, d *Delta) []*Candidate {
+
+	var res []*Candidate
+
+	maxError := math.Max(math.Abs(d.Delta)*maxCandidateError, 1e-8)
+
+	for _, e := range ledger {
+
+		if e.Currency != d.Currency {
+			continue
+		}
+
+		var c *Candidate
+
+		if e.Counted {
+			// Counted but not reflected: Reported = Expected - Amount
+			if err := math.Abs(d.Delta + e.Amount); err <= maxError {
+				c = &Candidate{e, "not reflected", err}
+			}
+		} else {
+			// Reflected but not counted: Reported = Expected + Amount
+			if err := math.Abs(d.Delta - e.Amount); err <= maxError {
+				c = &Candidate{e, "reflected", err}
+			}
+		}
+
+		if c != nil {
+			res = append(res, c)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.uncertain() != b.uncertain() {
+			return a.uncertain()
+		}
+		return a.Error < b.Error
+	})
+
+	if len(res) > maxCandidates {
+		res = res[:maxCandidates]
+	}
+
+	return res
+}
+
+func setDefaults(params *Params) {
+
+	if params.Tolerance <= 0 {
+		params.Tolerance = defaultTolerance
+	}
+
+	if params.Since.IsZero() {
+		params.Since, _ = time.Parse("2006-01-02", defaultSince)
+	}
+}
@@ -0,0 +1,86 @@
+package risk
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrKilled is returned for every order once the kill switch is engaged.
+type ErrKilled struct{}
+
+func (e *ErrKilled) Error() string {
+	return "kill switch engaged"
+}
+
+// ErrMaxNotional is returned when rate * amount exceeds the maximum notional of the
+// base currency.
+type ErrMaxNotional struct {
+	Currency string
+	Notional float64
+	Max      float64
+}
+
+func (e *ErrMaxNotional) Error() string {
+	return fmt.Sprintf("notional %.8f %s above maximum %.8f", e.Notional, e.Currency, e.Max)
+}
+
+// ErrMaxPosition is returned when a buy order would take the position of a currency
+// above its maximum.
+type ErrMaxPosition struct {
+	Currency string
+	Position float64 // position if the order was filled
+	Max      float64
+}
+
+func (e *ErrMaxPosition) Error() string {
+	return fmt.Sprintf("position %.8f %s above maximum %.8f", e.Position, e.Currency, e.Max)
+}
+
+// ErrMaxOpenOrders is returned when the market already has the maximum number of
+// open orders.
+type ErrMaxOpenOrders struct {
+	CurrencyPair string
+	Open         int
+	Max          int
+}
+
+func (e *ErrMaxOpenOrders) Error() string {
+	return fmt.Sprintf("%s: %d open orders, maximum %d", e.CurrencyPair, e.Open, e.Max)
+}
+
+// ErrPriceBand is returned when the rate deviates from the last ticker rate by more
+// than the price band.
+type ErrPriceBand struct {
+	CurrencyPair string
+	Rate         float64
+	Last         float64
+	Band         float64
+}
+
+func (e *ErrPriceBand) Error() string {
+	return fmt.Sprintf("%s: rate %.8f outside %.2f%% of last rate %.8f",
+		e.CurrencyPair, e.Rate, 100*e.Band, e.Last)
+}
+
+// ErrDailyLoss is returned when the account value, net of deposits and withdrawals,
+// dropped by more than the daily loss limit since the first check of the day.
+type ErrDailyLoss struct {
+	Loss float64 // BTC
+	Max  float64
+}
+
+func (e *ErrDailyLoss) Error() string {
+	return fmt.Sprintf("daily loss %.8f BTC above limit %.8f", e.Loss, e.Max)
+}
+
+// ErrOrderRate is returned when the number of orders placed or moved during the
+// rate period reached the maximum.
+type ErrOrderRate struct {
+	Count  int
+	Max    int
+	Period time.Duration
+}
+
+func (e *ErrOrderRate) Error() string {
+	return fmt.Sprintf("%d orders in %s, maximum %d", e.Count, e.Period, e.Max)
+}
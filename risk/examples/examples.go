@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/risk"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+var client *risk.Client
+
+func main() {
+
+	tradingClient, err := tradingapi.NewClient()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	limits := risk.Limits{
+		MaxNotional:   map[string]float64{"BTC": 0.1},
+		MaxPosition:   map[string]float64{"ETH": 10},
+		MaxOpenOrders: 5,
+		PriceBand:     0.05,
+		MaxDailyLoss:  0.05,
+		MaxOrders:     10,
+		OrderPeriod:   time.Minute,
+		TickerMaxAge:  10 * time.Second,
+	}
+
+	client = risk.NewClient(tradingClient, publicapi.NewClient(), limits)
+
+	buyOutsideBand()
+
+	// kill()
+}
+
+// Try to buy BTC_ETH far above the market
+func buyOutsideBand() {
+
+	_, err := client.BuyPostOnly("BTC_ETH", 1, 0.01)
+
+	switch err := err.(type) {
+	case *risk.ErrPriceBand:
+		fmt.Printf("rejected: last rate %.8f\n", err.Last)
+	default:
+		fmt.Println(err)
+	}
+}
+
+// Cancel every open order and block new ones
+func kill() {
+
+	report, err := client.Kill()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%d orders cancelled, refunded %v\n",
+		len(report.Outcomes)-report.Failed, report.Refunded)
+
+	_, err = client.Buy("BTC_ETH", 0.03, 1)
+	fmt.Println(err)
+}
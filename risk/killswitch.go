@@ -0,0 +1,50 @@
+package risk
+
+import (
+	"fmt"
+
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+// Kill engages the kill switch: orders placed or moved afterwards are rejected with
+// ErrKilled until Reset, and every open order is cancelled (see
+// tradingapi.Client.CancelAll). Orders which could not be cancelled are reported
+// in the error.
+func (c *Client) Kill() (*tradingapi.CancelReport, error) {
+
+	c.mu.Lock()
+	c.killed = true
+	c.mu.Unlock()
+
+	logger.Warn("kill switch engaged")
+
+	report, err := c.tradingClient.CancelAll()
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.CancelAll: %v", err)
+	}
+
+	if err := report.Err(); err != nil {
+		return report, fmt.Errorf("TradingClient.CancelAll: %v", err)
+	}
+
+	return report, nil
+}
+
+// Killed reports whether the kill switch is engaged.
+func (c *Client) Killed() bool {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.killed
+}
+
+// Reset disengages the kill switch.
+func (c *Client) Reset() {
+
+	c.mu.Lock()
+	c.killed = false
+	c.mu.Unlock()
+
+	logger.Warn("kill switch reset")
+}
@@ -0,0 +1,430 @@
+// Pre-trade risk limits.
+//
+// A Client wraps a trading client and checks every order placed or moved against
+// risk limits before sending it: maximum notional, maximum position per currency,
+// maximum open orders per market, price band around the last ticker rate, daily loss
+// limit and order rate. Violating orders are rejected with typed errors. The kill
+// switch cancels every open order and rejects new ones until reset.
+//
+// The Client has the methods of tradingapi.Client, so its Buy*, Sell* and MoveOrder*
+// methods can be used wherever a trading client function is expected (e.g.
+// orders.PlaceFunc).
+package risk
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:risk]")
+
+// Limits set to zero (or missing from maps) are not checked.
+//
+// The daily loss is measured from the account value at the first check of the UTC
+// day (not at 00:00), net of the deposits and withdrawals made since, valued at the
+// last rates of the tickers.
+type Limits struct {
+	MaxNotional   map[string]float64 // rate * amount, by base currency (BTC for BTC_XMR)
+	MaxPosition   map[string]float64 // balance + open buy amounts after a buy, by currency
+	MaxOpenOrders int                // per currency pair
+	PriceBand     float64            // maximum deviation from the last rate, e.g. 0.05 for 5%
+	MaxDailyLoss  float64            // drop of the account BTC value during the UTC day
+	MaxOrders     int                // orders placed or moved per OrderPeriod
+	OrderPeriod   time.Duration
+	TickerMaxAge  time.Duration // refresh period of the tickers (price band, transfers value)
+}
+
+// tradingClient is embedded under an unexported name: its methods are promoted, but
+// the unchecked order methods can not be reached through the field from other
+// packages.
+type tradingClient = tradingapi.Client
+
+type Client struct {
+	*tradingClient
+
+	public *publicapi.Client
+	limits Limits
+
+	// Held while an order is checked and sent, so that concurrent orders are
+	// checked against each other
+	mu sync.Mutex
+
+	killed     bool
+	orderTimes []time.Time
+
+	ticks        publicapi.Ticks
+	ticksUpdated time.Time
+
+	day      time.Time
+	dayStart time.Time // time of the first check of the day
+	dayValue float64   // BTC value at dayStart
+}
+
+// NewClient returns a client checking the orders sent with client against limits.
+// public is used to load the tickers of the price band check.
+func NewClient(client *tradingapi.Client, public *publicapi.Client, limits Limits) *Client {
+	return &Client{tradingClient: client, public: public, limits: limits}
+}
+
+func (c *Client) Buy(currencyPair string, rate, amount float64) (*tradingapi.BuyOrSellOrder, error) {
+	return c.place(currencyPair, "buy", rate, amount, c.tradingClient.Buy)
+}
+
+func (c *Client) BuyPostOnly(currencyPair string, rate, amount float64) (*tradingapi.BuyOrSellOrder, error) {
+	return c.place(currencyPair, "buy", rate, amount, c.tradingClient.BuyPostOnly)
+}
+
+func (c *Client) BuyImmediateOrCancel(currencyPair string, rate, amount float64) (*tradingapi.BuyOrSellOrder, error) {
+	return c.place(currencyPair, "buy", rate, amount, c.tradingClient.BuyImmediateOrCancel)
+}
+
+func (c *Client) BuyFillOrKill(currencyPair string, rate, amount float64) (*tradingapi.BuyOrSellOrder, error) {
+	return c.place(currencyPair, "buy", rate, amount, c.tradingClient.BuyFillOrKill)
+}
+
+func (c *Client) Sell(currencyPair string, rate, amount float64) (*tradingapi.BuyOrSellOrder, error) {
+	return c.place(currencyPair, "sell", rate, amount, c.tradingClient.Sell)
+}
+
+func (c *Client) SellPostOnly(currencyPair string, rate, amount float64) (*tradingapi.BuyOrSellOrder, error) {
+	return c.place(currencyPair, "sell", rate, amount, c.tradingClient.SellPostOnly)
+}
+
+func (c *Client) SellImmediateOrCancel(currencyPair string, rate, amount float64) (*tradingapi.BuyOrSellOrder, error) {
+	return c.place(currencyPair, "sell", rate, amount, c.tradingClient.SellImmediateOrCancel)
+}
+
+func (c *Client) SellFillOrKill(currencyPair string, rate, amount float64) (*tradingapi.BuyOrSellOrder, error) {
+	return c.place(currencyPair, "sell", rate, amount, c.tradingClient.SellFillOrKill)
+}
+
+func (c *Client) MoveOrder(orderNumber int64, rate, amount float64) (*tradingapi.MovedOrder, error) {
+	return c.move(orderNumber, rate, amount, c.tradingClient.MoveOrder)
+}
+
+func (c *Client) MoveOrderPostOnly(orderNumber int64, rate, amount float64) (*tradingapi.MovedOrder, error) {
+	return c.move(orderNumber, rate, amount, c.tradingClient.MoveOrderPostOnly)
+}
+
+func (c *Client) MoveOrderImmediateOrCancel(orderNumber int64, rate, amount float64) (*tradingapi.MovedOrder, error) {
+	return c.move(orderNumber, rate, amount, c.tradingClient.MoveOrderImmediateOrCancel)
+}
+
+type order struct {
+	currencyPair string
+	typeOrder    string
+	rate         float64
+	amount       float64
+	moved        int64 // order number of a moved order
+}
+
+func (c *Client) place(currencyPair, typeOrder string, rate, amount float64,
+	place func(string, float64, float64) (*tradingapi.BuyOrSellOrder, error)) (*tradingapi.BuyOrSellOrder, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.check(&order{currencyPair, typeOrder, rate, amount, 0}, nil); err != nil {
+		return nil, err
+	}
+
+	c.orderTimes = append(c.orderTimes, time.Now())
+
+	return place(currencyPair, rate, amount)
+}
+
+func (c *Client) move(orderNumber int64, rate, amount float64,
+	move func(int64, float64, float64) (*tradingapi.MovedOrder, error)) (*tradingapi.MovedOrder, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.killed {
+		return nil, &ErrKilled{}
+	}
+
+	open, err := c.tradingClient.GetAllOpenOrders()
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.GetAllOpenOrders: %v", err)
+	}
+
+	o := order{rate: rate, amount: amount, moved: orderNumber}
+
+	for pair, orders := range open {
+		if orders == nil {
+			continue
+		}
+		for _, oo := range *orders {
+			if oo.OrderNumber == orderNumber {
+				o.currencyPair, o.typeOrder = pair, oo.Type
+			}
+		}
+	}
+
+	if o.currencyPair == "" {
+		return nil, fmt.Errorf("order %d not found in open orders", orderNumber)
+	}
+
+	if err := c.check(&o, open); err != nil {
+		return nil, err
+	}
+
+	c.orderTimes = append(c.orderTimes, time.Now())
+
+	return move(orderNumber, rate, amount)
+}
+
+// check must be called with c.mu held. open is loaded when nil and needed.
+func (c *Client) check(o *order, open tradingapi.AllOpenOrders) error {
+
+	if c.killed {
+		return &ErrKilled{}
+	}
+
+	if err := c.checkOrderRate(); err != nil {
+		return err
+	}
+
+	base, quote, ok := util.SplitPair(o.currencyPair)
+	if !ok {
+		return fmt.Errorf("wrong currency pair: %s", o.currencyPair)
+	}
+
+	if max, ok := c.limits.MaxNotional[base]; ok {
+		if notional := o.rate * o.amount; notional > max {
+			return &ErrMaxNotional{base, notional, max}
+		}
+	}
+
+	if c.limits.PriceBand > 0 {
+		if err := c.checkPriceBand(o); err != nil {
+			return err
+		}
+	}
+
+	maxPosition, checkPosition := c.limits.MaxPosition[quote]
+	checkPosition = checkPosition && o.typeOrder == "buy"
+
+	if open == nil && (c.limits.MaxOpenOrders > 0 || checkPosition) {
+		var err error
+		if open, err = c.tradingClient.GetAllOpenOrders(); err != nil {
+			return fmt.Errorf("TradingClient.GetAllOpenOrders: %v", err)
+		}
+	}
+
+	if c.limits.MaxOpenOrders > 0 && o.moved == 0 {
+		count := 0
+		if orders := open[o.currencyPair]; orders != nil {
+			count = len(*orders)
+		}
+		if count >= c.limits.MaxOpenOrders {
+			return &ErrMaxOpenOrders{o.currencyPair, count, c.limits.MaxOpenOrders}
+		}
+	}
+
+	if !checkPosition && c.limits.MaxDailyLoss == 0 {
+		return nil
+	}
+
+	balances, err := c.tradingClient.GetCompleteBalances()
+	if err != nil {
+		return fmt.Errorf("TradingClient.GetCompleteBalances: %v", err)
+	}
+
+	if checkPosition {
+		if position := c.position(quote, balances, open, o); position > maxPosition {
+			return &ErrMaxPosition{quote, position, maxPosition}
+		}
+	}
+
+	if c.limits.MaxDailyLoss > 0 {
+		if err := c.checkDailyLoss(balances); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) checkOrderRate() error {
+
+	if c.limits.MaxOrders == 0 {
+		return nil
+	}
+
+	since := time.Now().Add(-c.limits.OrderPeriod)
+
+	i := 0
+	for i < len(c.orderTimes) && !c.orderTimes[i].After(since) {
+		i++
+	}
+	c.orderTimes = c.orderTimes[i:]
+
+	if len(c.orderTimes) >= c.limits.MaxOrders {
+		return &ErrOrderRate{len(c.orderTimes), c.limits.MaxOrders, c.limits.OrderPeriod}
+	}
+
+	return nil
+}
+
+// loadTicks refreshes the tickers older than TickerMaxAge.
+func (c *Client) loadTicks() error {
+
+	if c.ticks == nil || time.Since(c.ticksUpdated) > c.limits.TickerMaxAge {
+
+		ticks, err := c.public.GetTickers()
+		if err != nil {
+			return fmt.Errorf("PublicClient.GetTickers: %v", err)
+		}
+		c.ticks, c.ticksUpdated = ticks, time.Now()
+	}
+
+	return nil
+}
+
+// btcRate returns the BTC value of one unit of currency from the tickers, false when
+// no market links it to BTC.
+func (c *Client) btcRate(currency string) (float64, bool) {
+
+	if currency == "BTC" {
+		return 1, true
+	}
+
+	if tick, ok := c.ticks["BTC_"+currency]; ok && tick.Last > 0 {
+		return tick.Last, true
+	}
+
+	if tick, ok := c.ticks[currency+"_BTC"]; ok && tick.Last > 0 {
+		return 1 / tick.Last, true
+	}
+
+	return 0, false
+}
+
+func (c *Client) checkPriceBand(o *order) error {
+
+	if err := c.loadTicks(); err != nil {
+		return err
+	}
+
+	tick, ok := c.ticks[o.currencyPair]
+	if !ok || tick.Last == 0 {
+		return fmt.Errorf("no ticker for %s", o.currencyPair)
+	}
+
+	if math.Abs(o.rate/tick.Last-1) > c.limits.PriceBand {
+		return &ErrPriceBand{o.currencyPair, o.rate, tick.Last, c.limits.PriceBand}
+	}
+
+	return nil
+}
+
+// position returns the position in currency if the buy order o was filled: balance
+// (available and on orders) plus the amounts of the open buy orders of every market
+// quoting currency.
+func (c *Client) position(currency string, balances tradingapi.CompleteBalances,
+	open tradingapi.AllOpenOrders, o *order) float64 {
+
+	position := o.amount
+
+	if b, ok := balances[currency]; ok {
+		position += b.Available + b.OnOrders
+	}
+
+	for pair, orders := range open {
+
+		if orders == nil {
+			continue
+		}
+
+		if _, quote, ok := util.SplitPair(pair); !ok || quote != currency {
+			continue
+		}
+
+		for _, oo := range *orders {
+			if oo.Type == "buy" && oo.OrderNumber != o.moved {
+				position += oo.Amount
+			}
+		}
+	}
+
+	return position
+}
+
+func (c *Client) checkDailyLoss(balances tradingapi.CompleteBalances) error {
+
+	value := 0.0
+	for _, b := range balances {
+		value += b.BtcValue
+	}
+
+	now := time.Now()
+
+	day := now.UTC().Truncate(24 * time.Hour)
+	if !day.Equal(c.day) {
+		c.day, c.dayStart, c.dayValue = day, now, value
+		logger.Infof("account value at start of day: %.8f BTC", value)
+		return nil
+	}
+
+	withdrawn, err := c.withdrawn(c.dayStart, now)
+	if err != nil {
+		return err
+	}
+
+	if loss := c.dayValue - value - withdrawn; loss > c.limits.MaxDailyLoss {
+		return &ErrDailyLoss{loss, c.limits.MaxDailyLoss}
+	}
+
+	return nil
+}
+
+// withdrawn returns the BTC value of the withdrawals minus the deposits completed
+// between start and end, at the last rates of the tickers.
+func (c *Client) withdrawn(start, end time.Time) (float64, error) {
+
+	dw, err := c.tradingClient.GetDepositsWithdrawals(start, end)
+	if err != nil {
+		return 0, fmt.Errorf("TradingClient.GetDepositsWithdrawals: %v", err)
+	}
+
+	if len(dw.Withdrawals) == 0 && len(dw.Deposits) == 0 {
+		return 0, nil
+	}
+
+	if err := c.loadTicks(); err != nil {
+		return 0, err
+	}
+
+	rate := func(currency string) float64 {
+		r, ok := c.btcRate(currency)
+		if !ok {
+			logger.Warnf("no BTC ticker for %s, transfer not counted", currency)
+		}
+		return r
+	}
+
+	withdrawn := 0.0
+
+	for _, w := range dw.Withdrawals {
+		// Failed withdrawals are credited back
+		if util.WithdrawalStatusOf(w.Status) != util.WithdrawalFailed {
+			withdrawn += w.Amount * rate(w.Currency)
+		}
+	}
+
+	for _, d := range dw.Deposits {
+		if d.Status == "COMPLETE" {
+			withdrawn -= d.Amount * rate(d.Currency)
+		}
+	}
+
+	return withdrawn, nil
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+	bolt "go.etcd.io/bbolt"
+)
+
+func chartDataPath(currencyPair string, period int) [][]byte {
+	return [][]byte{chartDataBucket, pairKey(currencyPair), []byte(strconv.Itoa(period))}
+}
+
+// PutChartData stores candlesticks of the given period, replacing the ones already
+// stored at the same dates.
+func (s *Store) PutChartData(currencyPair string, period int, data publicapi.ChartData) error {
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+
+		b, err := bucket(tx, chartDataPath(currencyPair, period)...)
+		if err != nil {
+			return err
+		}
+
+		for _, cs := range data {
+
+			if cs.Date == 0 {
+				continue // empty range
+			}
+
+			value, err := encode(cs)
+			if err != nil {
+				return err
+			}
+
+			if err := b.Put(key(cs.Date), value); err != nil {
+				return fmt.Errorf("bolt.Bucket.Put: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ChartData returns the stored candlesticks of the given period between start and end.
+func (s *Store) ChartData(currencyPair string, period int, start, end time.Time) (publicapi.ChartData, error) {
+
+	res := publicapi.ChartData{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+
+		b, err := bucket(tx, chartDataPath(currencyPair, period)...)
+		if err != nil {
+			return err
+		}
+
+		return scan(b, key(start.Unix()), key(end.Unix()), func(k, v []byte) error {
+
+			cs := publicapi.CandleStick{}
+			if err := decode(v, &cs); err != nil {
+				return err
+			}
+			res = append(res, &cs)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// chartDataDates returns the set of dates stored between start and end.
+func (s *Store) chartDataDates(currencyPair string, period int, start, end time.Time) (map[int64]bool, error) {
+
+	res := make(map[int64]bool)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+
+		b, err := bucket(tx, chartDataPath(currencyPair, period)...)
+		if err != nil {
+			return err
+		}
+
+		return scan(b, key(start.Unix()), key(end.Unix()), func(k, v []byte) error {
+			res[keyValue(k, 0)] = true
+			return nil
+		})
+	})
+
+	return res, err
+}
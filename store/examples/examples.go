@@ -0,0 +1,68 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	poloniex "github.com/joemocquant/poloniex-api"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/store"
+)
+
+var (
+	client *publicapi.Client
+	db     *store.Store
+)
+
+func main() {
+
+	var err error
+	client = publicapi.NewClient()
+	db, err = store.Open("poloniex.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	syncOnce()
+
+	// runSync()
+}
+
+// Sync BTC_ETH 5min candlesticks and trades the last day, then print the last hour
+func syncOnce() {
+
+	job := store.SyncJob{
+		CurrencyPair: "BTC_ETH",
+		Since:        time.Now().Add(-24 * time.Hour),
+		ChartPeriods: []int{300},
+		TradeHistory: true,
+	}
+
+	if err := db.Sync(client, &job); err != nil {
+		log.Fatal(err)
+	}
+
+	end := time.Now()
+	start := end.Add(-time.Hour)
+
+	data, err := db.ChartData("BTC_ETH", 300, start, end)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	poloniex.PrettyPrintJson(data)
+}
+
+// Keep BTC_ETH and BTC_XMR data (and all tickers) up to date every minute
+func runSync() {
+
+	since := time.Now().AddDate(0, 0, -7)
+	jobs := []*store.SyncJob{
+		{CurrencyPair: "BTC_ETH", Since: since, ChartPeriods: []int{300, 86400}, TradeHistory: true, OrderBookDepth: 50},
+		{CurrencyPair: "BTC_XMR", Since: since, ChartPeriods: []int{300}},
+	}
+
+	db.RunSync(client, jobs, time.Minute, true, make(chan struct{}))
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+	bolt "go.etcd.io/bbolt"
+)
+
+type OrderBookSnapshot struct {
+	Date int64 // Unix timestamp
+	*publicapi.OrderBook
+}
+
+// PutOrderBook stores a snapshot of the order book of currencyPair taken at date.
+func (s *Store) PutOrderBook(currencyPair string, date time.Time, book *publicapi.OrderBook) error {
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+
+		b, err := bucket(tx, orderBookBucket, pairKey(currencyPair))
+		if err != nil {
+			return err
+		}
+
+		value, err := encode(book)
+		if err != nil {
+			return err
+		}
+
+		if err := b.Put(key(date.Unix(), int64(date.Nanosecond())), value); err != nil {
+			return fmt.Errorf("bolt.Bucket.Put: %v", err)
+		}
+		return nil
+	})
+}
+
+// OrderBooks returns the stored order book snapshots of currencyPair between start and end.
+func (s *Store) OrderBooks(currencyPair string, start, end time.Time) ([]*OrderBookSnapshot, error) {
+
+	res := []*OrderBookSnapshot{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+
+		b, err := bucket(tx, orderBookBucket, pairKey(currencyPair))
+		if err != nil {
+			return err
+		}
+
+		return scan(b, key(start.Unix(), 0), key(end.Unix(), math.MaxInt64), func(k, v []byte) error {
+
+			book := publicapi.OrderBook{}
+			if err := decode(v, &book); err != nil {
+				return err
+			}
+			res = append(res, &OrderBookSnapshot{keyValue(k, 0), &book})
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
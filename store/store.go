@@ -0,0 +1,140 @@
+// Persistent market data store.
+//
+// Candlesticks, public trades, ticker and order book snapshots are stored in an
+// embedded bolt database (a single file, no server) and queried by currency pair and
+// time range. Sync jobs (see sync.go) fill the gaps using the public API fetchers.
+//
+// Layout: one top level bucket per data type, one nested bucket per currency pair
+// (and per period for chart data). Keys are big endian Unix timestamps so that
+// cursors iterate in chronological order; values are gob encoded.
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:store]")
+
+var (
+	chartDataBucket    = []byte("chartData")
+	tradeHistoryBucket = []byte("tradeHistory")
+	tickBucket         = []byte("ticks")
+	orderBookBucket    = []byte("orderBooks")
+	coverageBucket     = []byte("coverage")
+)
+
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens (creating it if needed) the database file at path.
+func Open(path string) (*Store, error) {
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("bolt.Open: %v", err)
+	}
+
+	return &Store{db}, nil
+}
+
+func (s *Store) Close() error {
+
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("bolt.DB.Close: %v", err)
+	}
+	return nil
+}
+
+// bucket returns the nested bucket at path, creating it when tx is writable.
+// It returns nil when a read only transaction finds no such bucket.
+func bucket(tx *bolt.Tx, path ...[]byte) (*bolt.Bucket, error) {
+
+	var b *bolt.Bucket
+
+	for i, name := range path {
+
+		if tx.Writable() {
+			var err error
+			if i == 0 {
+				b, err = tx.CreateBucketIfNotExists(name)
+			} else {
+				b, err = b.CreateBucketIfNotExists(name)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("bolt.CreateBucketIfNotExists: %v", err)
+			}
+			continue
+		}
+
+		if i == 0 {
+			b = tx.Bucket(name)
+		} else {
+			b = b.Bucket(name)
+		}
+		if b == nil {
+			return nil, nil
+		}
+	}
+
+	return b, nil
+}
+
+// scan calls fn for each key between start and end (both included) in order.
+func scan(b *bolt.Bucket, start, end []byte, fn func(k, v []byte) error) error {
+
+	if b == nil {
+		return nil
+	}
+
+	c := b.Cursor()
+	for k, v := c.Seek(start); k != nil && bytes.Compare(k, end) <= 0; k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pairKey(currencyPair string) []byte {
+	return []byte(strings.ToUpper(currencyPair))
+}
+
+// key returns the big endian encoding of the given values.
+func key(values ...int64) []byte {
+
+	k := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint64(k[8*i:], uint64(v))
+	}
+	return k
+}
+
+func keyValue(k []byte, i int) int64 {
+	return int64(binary.BigEndian.Uint64(k[8*i:]))
+}
+
+func encode(v interface{}) ([]byte, error) {
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, fmt.Errorf("gob.Encoder.Encode: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func decode(data []byte, v interface{}) error {
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(v); err != nil {
+		return fmt.Errorf("gob.Decoder.Decode: %v", err)
+	}
+	return nil
+}
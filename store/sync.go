@@ -0,0 +1,360 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Time range requested per returnTradeHistory call. Ranges returning the maximum
+// number of trades (50,000) are split in halves until they fit.
+const (
+	tradeHistoryWindow   = time.Hour
+	maxTradeHistoryLen   = 50000
+	minTradeHistoryRange = time.Second
+)
+
+type SyncJob struct {
+	CurrencyPair   string
+	Since          time.Time // start of the history kept in the store
+	ChartPeriods   []int     // chart data periods to sync
+	TradeHistory   bool      // sync public trades
+	OrderBookDepth int       // depth of the order book snapshot taken on each run, 0 for none
+}
+
+// RunSync runs the jobs every interval until done is closed. When ticks is true a
+// snapshot of all tickers is also stored on each run. Errors are logged.
+func (s *Store) RunSync(client *publicapi.Client, jobs []*SyncJob, interval time.Duration, ticks bool, done <-chan struct{}) {
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		if ticks {
+			if err := s.SyncTicks(client); err != nil {
+				logger.WithField("error", err).Error("Store.SyncTicks")
+			}
+		}
+
+		for _, job := range jobs {
+			if err := s.Sync(client, job); err != nil {
+				logger.WithField("error", err).Errorf("Store.Sync %s", job.CurrencyPair)
+			}
+		}
+
+		select {
+		case <-t.C:
+		case <-done:
+			return
+		}
+	}
+}
+
+// Sync runs job once, fetching only the data missing between job.Since and now.
+func (s *Store) Sync(client *publicapi.Client, job *SyncJob) error {
+
+	now := time.Now()
+
+	for _, period := range job.ChartPeriods {
+		n, err := s.SyncChartData(client, job.CurrencyPair, period, job.Since, now)
+		if err != nil {
+			return fmt.Errorf("Store.SyncChartData: %v", err)
+		}
+		logger.Debugf("%s: %d candlesticks (%ds) stored", job.CurrencyPair, n, period)
+	}
+
+	if job.TradeHistory {
+		n, err := s.SyncTradeHistory(client, job.CurrencyPair, job.Since, now)
+		if err != nil {
+			return fmt.Errorf("Store.SyncTradeHistory: %v", err)
+		}
+		logger.Debugf("%s: %d trades stored", job.CurrencyPair, n)
+	}
+
+	if job.OrderBookDepth > 0 {
+		book, err := client.GetOrderBook(job.CurrencyPair, job.OrderBookDepth)
+		if err != nil {
+			return fmt.Errorf("PublicClient.GetOrderBook: %v", err)
+		}
+		if err := s.PutOrderBook(job.CurrencyPair, now, book); err != nil {
+			return fmt.Errorf("Store.PutOrderBook: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// SyncTicks stores a snapshot of all tickers.
+func (s *Store) SyncTicks(client *publicapi.Client) error {
+
+	ticks, err := client.GetTickers()
+	if err != nil {
+		return fmt.Errorf("PublicClient.GetTickers: %v", err)
+	}
+
+	if err := s.PutTicks(time.Now(), ticks); err != nil {
+		return fmt.Errorf("Store.PutTicks: %v", err)
+	}
+
+	return nil
+}
+
+// SyncChartData fetches and stores the closed candlesticks missing between start
+// and end. Synced ranges are recorded so that ranges without any candlestick are
+// not fetched again. It returns the number of candlesticks stored.
+func (s *Store) SyncChartData(client *publicapi.Client, currencyPair string, period int, start, end time.Time) (int, error) {
+
+	p := int64(period)
+	if p <= 0 {
+		return 0, fmt.Errorf("Wrong period parameter: %d", period)
+	}
+
+	// Last closed candlestick
+	last := time.Now().Unix()
+	last -= last%p + p
+	if end.Unix() < last {
+		last = end.Unix() - end.Unix()%p
+	}
+
+	first := start.Unix() - start.Unix()%p
+
+	dates, err := s.chartDataDates(currencyPair, period, time.Unix(first, 0), time.Unix(last, 0))
+	if err != nil {
+		return 0, fmt.Errorf("Store.chartDataDates: %v", err)
+	}
+
+	// Each candlestick covers [date, date + period]
+	kind := [][]byte{chartDataBucket, []byte(strconv.Itoa(period))}
+
+	covered, err := s.coverage(currencyPair, kind...)
+	if err != nil {
+		return 0, fmt.Errorf("Store.coverage: %v", err)
+	}
+
+	var gaps []interval
+	for date := first; date <= last; date += p {
+
+		if dates[date] || len(missing(covered, date, date+p)) == 0 {
+			continue
+		}
+
+		if n := len(gaps); n > 0 && gaps[n-1].End == date-p {
+			gaps[n-1].End = date
+		} else {
+			gaps = append(gaps, interval{date, date})
+		}
+	}
+
+	stored := 0
+
+	for _, gap := range gaps {
+
+		data, err := client.GetChartData(currencyPair, time.Unix(gap.Start, 0),
+			time.Unix(gap.End, 0), period)
+
+		if err != nil {
+			return stored, fmt.Errorf("PublicClient.GetChartData: %v", err)
+		}
+
+		closed := make(publicapi.ChartData, 0, len(data))
+		for _, cs := range data {
+			if cs.Date != 0 && cs.Date <= last {
+				closed = append(closed, cs)
+			}
+		}
+
+		if err := s.PutChartData(currencyPair, period, closed); err != nil {
+			return stored, fmt.Errorf("Store.PutChartData: %v", err)
+		}
+		stored += len(closed)
+
+		if err := s.addCoverage(currencyPair, interval{gap.Start, gap.End + p}, kind...); err != nil {
+			return stored, fmt.Errorf("Store.addCoverage: %v", err)
+		}
+	}
+
+	return stored, nil
+}
+
+// SyncTradeHistory fetches and stores the trades between start and end that were
+// not synced yet. Synced ranges are recorded so that gaps are detected even where
+// no trade happened. It returns the number of trades stored.
+func (s *Store) SyncTradeHistory(client *publicapi.Client, currencyPair string, start, end time.Time) (int, error) {
+
+	if now := time.Now(); end.After(now) {
+		end = now
+	}
+
+	covered, err := s.coverage(currencyPair, tradeHistoryBucket)
+	if err != nil {
+		return 0, fmt.Errorf("Store.coverage: %v", err)
+	}
+
+	stored := 0
+
+	for _, gap := range missing(covered, start.Unix(), end.Unix()) {
+
+		for from := gap.Start; from < gap.End; from += int64(tradeHistoryWindow / time.Second) {
+
+			to := from + int64(tradeHistoryWindow/time.Second)
+			if to > gap.End {
+				to = gap.End
+			}
+
+			trades, err := fetchTradeHistory(client, currencyPair, time.Unix(from, 0), time.Unix(to, 0))
+			if err != nil {
+				return stored, fmt.Errorf("fetchTradeHistory: %v", err)
+			}
+
+			if err := s.PutTradeHistory(currencyPair, trades); err != nil {
+				return stored, fmt.Errorf("Store.PutTradeHistory: %v", err)
+			}
+			stored += len(trades)
+
+			if err := s.addCoverage(currencyPair, interval{from, to}, tradeHistoryBucket); err != nil {
+				return stored, fmt.Errorf("Store.addCoverage: %v", err)
+			}
+		}
+	}
+
+	return stored, nil
+}
+
+// fetchTradeHistory splits [start, end] until each call returns less than the
+// maximum number of trades served by returnTradeHistory.
+func fetchTradeHistory(client *publicapi.Client, currencyPair string, start, end time.Time) (publicapi.TradeHistory, error) {
+
+	trades, err := client.GetTradeHistory(currencyPair, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("PublicClient.GetTradeHistory: %v", err)
+	}
+
+	if len(trades) < maxTradeHistoryLen || end.Sub(start) <= minTradeHistoryRange {
+		return trades, nil
+	}
+
+	middle := start.Add(end.Sub(start) / 2)
+
+	first, err := fetchTradeHistory(client, currencyPair, start, middle)
+	if err != nil {
+		return nil, err
+	}
+
+	second, err := fetchTradeHistory(client, currencyPair, middle, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(first, second...), nil
+}
+
+// interval is a range of Unix timestamps, both ends included.
+type interval struct {
+	Start int64
+	End   int64
+}
+
+// coveragePath returns the bucket of the ranges synced for kind (a data bucket,
+// followed by the period for candlesticks).
+func coveragePath(kind ...[]byte) [][]byte {
+	return append([][]byte{coverageBucket}, kind...)
+}
+
+func (s *Store) coverage(currencyPair string, kind ...[]byte) ([]interval, error) {
+
+	var res []interval
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+
+		b, err := bucket(tx, coveragePath(kind...)...)
+		if err != nil || b == nil {
+			return err
+		}
+
+		if v := b.Get(pairKey(currencyPair)); v != nil {
+			return decode(v, &res)
+		}
+		return nil
+	})
+
+	return res, err
+}
+
+func (s *Store) addCoverage(currencyPair string, iv interval, kind ...[]byte) error {
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+
+		b, err := bucket(tx, coveragePath(kind...)...)
+		if err != nil {
+			return err
+		}
+
+		var covered []interval
+		if v := b.Get(pairKey(currencyPair)); v != nil {
+			if err := decode(v, &covered); err != nil {
+				return err
+			}
+		}
+
+		value, err := encode(merge(append(covered, iv)))
+		if err != nil {
+			return err
+		}
+
+		if err := b.Put(pairKey(currencyPair), value); err != nil {
+			return fmt.Errorf("bolt.Bucket.Put: %v", err)
+		}
+		return nil
+	})
+}
+
+// merge returns the union of intervals, sorted.
+func merge(intervals []interval) []interval {
+
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Start < intervals[j].Start
+	})
+
+	var res []interval
+	for _, iv := range intervals {
+
+		if n := len(res); n > 0 && iv.Start <= res[n-1].End {
+			if iv.End > res[n-1].End {
+				res[n-1].End = iv.End
+			}
+			continue
+		}
+		res = append(res, iv)
+	}
+	return res
+}
+
+// missing returns the parts of [start, end] not in covered (sorted and merged).
+func missing(covered []interval, start, end int64) []interval {
+
+	var res []interval
+
+	for _, iv := range covered {
+
+		if iv.End < start {
+			continue
+		}
+		if iv.Start > end {
+			break
+		}
+		if iv.Start > start {
+			res = append(res, interval{start, iv.Start})
+		}
+		start = iv.End
+	}
+
+	if start < end {
+		res = append(res, interval{start, end})
+	}
+
+	return res
+}
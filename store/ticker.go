@@ -0,0 +1,70 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+	bolt "go.etcd.io/bbolt"
+)
+
+type TickSnapshot struct {
+	Date int64 // Unix timestamp
+	*publicapi.Tick
+}
+
+// PutTicks stores a snapshot of the tickers (as returned by GetTickers) taken at date.
+func (s *Store) PutTicks(date time.Time, ticks publicapi.Ticks) error {
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+
+		for currencyPair, tick := range ticks {
+
+			b, err := bucket(tx, tickBucket, pairKey(currencyPair))
+			if err != nil {
+				return err
+			}
+
+			value, err := encode(tick)
+			if err != nil {
+				return err
+			}
+
+			if err := b.Put(key(date.Unix(), int64(date.Nanosecond())), value); err != nil {
+				return fmt.Errorf("bolt.Bucket.Put: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Ticks returns the stored ticker snapshots of currencyPair between start and end.
+func (s *Store) Ticks(currencyPair string, start, end time.Time) ([]*TickSnapshot, error) {
+
+	res := []*TickSnapshot{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+
+		b, err := bucket(tx, tickBucket, pairKey(currencyPair))
+		if err != nil {
+			return err
+		}
+
+		return scan(b, key(start.Unix(), 0), key(end.Unix(), math.MaxInt64), func(k, v []byte) error {
+
+			tick := publicapi.Tick{}
+			if err := decode(v, &tick); err != nil {
+				return err
+			}
+			res = append(res, &TickSnapshot{keyValue(k, 0), &tick})
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
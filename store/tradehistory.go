@@ -0,0 +1,66 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+	bolt "go.etcd.io/bbolt"
+)
+
+// PutTradeHistory stores public trades. Trades are keyed by date and trade id so
+// storing the same trade twice is harmless.
+func (s *Store) PutTradeHistory(currencyPair string, trades publicapi.TradeHistory) error {
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+
+		b, err := bucket(tx, tradeHistoryBucket, pairKey(currencyPair))
+		if err != nil {
+			return err
+		}
+
+		for _, t := range trades {
+
+			value, err := encode(t)
+			if err != nil {
+				return err
+			}
+
+			if err := b.Put(key(t.Date, t.TradeId), value); err != nil {
+				return fmt.Errorf("bolt.Bucket.Put: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// TradeHistory returns the stored trades between start and end, oldest first.
+func (s *Store) TradeHistory(currencyPair string, start, end time.Time) (publicapi.TradeHistory, error) {
+
+	res := publicapi.TradeHistory{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+
+		b, err := bucket(tx, tradeHistoryBucket, pairKey(currencyPair))
+		if err != nil {
+			return err
+		}
+
+		return scan(b, key(start.Unix(), 0), key(end.Unix(), math.MaxInt64), func(k, v []byte) error {
+
+			t := publicapi.Trade{}
+			if err := decode(v, &t); err != nil {
+				return err
+			}
+			res = append(res, &t)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,175 @@
+package tax
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+type Layout int
+
+const (
+	// IRS Form 8949 columns (a) to (h), short-term records first then long-term, values
+	// rounded to cents
+	Form8949 Layout = iota
+	// One row per disposal or income with full precision amounts and values
+	Generic
+)
+
+// WriteCSV writes the report to w in layout.
+func (r *Report) WriteCSV(w io.Writer, layout Layout) error {
+
+	var rows [][]string
+
+	switch layout {
+	case Form8949:
+		rows = r.form8949()
+	case Generic:
+		rows = r.generic()
+	default:
+		return fmt.Errorf("Wrong layout parameter: %d", layout)
+	}
+
+	cw := csv.NewWriter(w)
+
+	if err := cw.WriteAll(rows); err != nil {
+		return fmt.Errorf("csv.Writer.WriteAll: %v", err)
+	}
+
+	return nil
+}
+
+func (r *Report) form8949() [][]string {
+
+	rows := [][]string{{
+		"Term",
+		"Description of property",
+		"Date acquired",
+		"Date sold or disposed of",
+		"Proceeds",
+		"Cost or other basis",
+		"Code(s)",
+		"Amount of adjustment",
+		"Gain or (loss)",
+	}}
+
+	for _, longTerm := range []bool{false, true} {
+
+		term := "Short"
+		if longTerm {
+			term = "Long"
+		}
+
+		for _, rec := range r.Records {
+
+			if rec.LongTerm != longTerm {
+				continue
+			}
+
+			acquired := rec.Acquired.UTC().Format("01/02/2006")
+			if rec.Unmatched {
+				acquired = "VARIOUS"
+			}
+
+			rows = append(rows, []string{
+				term,
+				fmt.Sprintf("%s %s", formatAmount(rec.Amount), rec.Currency),
+				acquired,
+				rec.Disposed.UTC().Format("01/02/2006"),
+				formatCents(rec.Proceeds),
+				formatCents(rec.Cost),
+				"",
+				"",
+				// Gain of the rounded values so that (h) = (d) - (e)
+				formatCents(round(rec.Proceeds) - round(rec.Cost)),
+			})
+		}
+	}
+
+	return rows
+}
+
+func (r *Report) generic() [][]string {
+
+	rows := [][]string{{
+		"Type",
+		"Currency",
+		"Amount",
+		"Date acquired",
+		"Date disposed",
+		"Proceeds",
+		"Cost basis",
+		"Gain",
+		"Term",
+		"Fiat currency",
+		"Currency pair",
+		"Reference",
+	}}
+
+	for _, rec := range r.Records {
+
+		term := "Short"
+		if rec.LongTerm {
+			term = "Long"
+		}
+
+		acquired := formatDate(rec.Acquired)
+		if rec.Unmatched {
+			acquired = ""
+		}
+
+		rows = append(rows, []string{
+			"Sell",
+			rec.Currency,
+			formatAmount(rec.Amount),
+			acquired,
+			formatDate(rec.Disposed),
+			formatAmount(rec.Proceeds),
+			formatAmount(rec.Cost),
+			formatAmount(rec.Gain),
+			term,
+			r.Quote,
+			rec.CurrencyPair,
+			"trade:" + strconv.FormatInt(rec.TradeId, 10),
+		})
+	}
+
+	for _, in := range r.Income {
+		rows = append(rows, []string{
+			"Lending",
+			in.Currency,
+			formatAmount(in.Amount),
+			formatDate(in.Date),
+			"",
+			formatAmount(in.Value),
+			"",
+			"",
+			"",
+			r.Quote,
+			"",
+			"loan:" + strconv.FormatInt(in.LoanId, 10),
+		})
+	}
+
+	return rows
+}
+
+func formatDate(date time.Time) string {
+	return date.UTC().Format("2006-01-02 15:04:05")
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 8, 64)
+}
+
+// round rounds value to cents.
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func formatCents(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
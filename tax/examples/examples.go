@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/tax"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+var report *tax.Report
+
+func main() {
+
+	tradingClient, err := tradingapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	publicClient := publicapi.NewClient()
+
+	// Capital gains of 2017 in USDT, FIFO
+	report, err = tax.Generate(tradingClient, publicClient, tax.Params{Year: 2017})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	printSummary()
+
+	// writeCSV("form8949_2017.csv", tax.Form8949)
+
+	// writeCSV("gains_2017.csv", tax.Generic)
+}
+
+func printSummary() {
+
+	fmt.Printf("%d (%s, %s): %d disposals, proceeds %.2f, cost %.2f\n",
+		report.Year, report.Quote, report.Method, len(report.Records), report.Proceeds, report.Cost)
+	fmt.Printf("Short-term gain: %.2f\n", report.ShortTermGain)
+	fmt.Printf("Long-term gain: %.2f\n", report.LongTermGain)
+	fmt.Printf("Lending income: %.2f (%d loans)\n", report.IncomeValue, len(report.Income))
+
+	if report.Unmatched > 0 {
+		fmt.Printf("%d disposals without known acquisition (zero cost basis)\n", report.Unmatched)
+	}
+}
+
+func writeCSV(path string, layout tax.Layout) {
+
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := report.WriteCSV(f, layout); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,229 @@
+// Capital gains reports.
+//
+// A Report lists the disposals of a tax year with their acquisition date, cost basis
+// and proceeds, and the lending earnings as income, valued in a fiat proxy (USDT by
+// default). It is built on a pnl.Engine loaded with the whole account history, so the
+// cost basis of lots acquired before the year is known.
+//
+// Reports are exported to CSV in the layout of IRS Form 8949 or in a generic layout
+// accepted by most tax software (see csv.go).
+package tax
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/pnl"
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+)
+
+const (
+	defaultQuote = "USDT"
+	// Start of the history loaded for the cost basis (Poloniex opened in January 2014)
+	defaultSince = "2014-01-01"
+	// Rows requested per returnLendingHistory call, the range is split when reached
+	lendingHistoryLimit = 10000
+)
+
+type Params struct {
+	Year   int        // calendar year (UTC) of the report
+	Quote  string     // fiat proxy, USDT by default
+	Method pnl.Method // lot matching, FIFO by default
+	Since  time.Time  // start of the account history, 2014-01-01 by default
+}
+
+// A Record is the disposal of an amount of a currency acquired at once.
+type Record struct {
+	Currency     string
+	Amount       float64
+	Acquired     time.Time
+	Disposed     time.Time
+	Proceeds     float64
+	Cost         float64
+	Gain         float64 // negative for a loss
+	LongTerm     bool    // held more than a year
+	CurrencyPair string
+	TradeId      int64
+	Unmatched    bool // no known acquisition: zero cost basis
+}
+
+type Report struct {
+	Year   int
+	Quote  string
+	Method pnl.Method
+
+	Records []*Record     // in disposal date order
+	Income  []*pnl.Income // lending earnings
+
+	Proceeds      float64
+	Cost          float64
+	ShortTermGain float64
+	LongTermGain  float64
+	IncomeValue   float64
+	Unmatched     int // records with a zero cost basis, the history may be incomplete
+}
+
+// Generate loads the account history up to the end of the year and returns its
+// report. Prices come from the charts of the public API (pnl.ChartPricer).
+func Generate(client *tradingapi.Client, public *publicapi.Client, params Params) (*Report, error) {
+
+	if err := setDefaults(&params); err != nil {
+		return nil, err
+	}
+
+	_, end := yearRange(params.Year)
+
+	engine := pnl.NewEngine(params.Quote, params.Method, pnl.ChartPricer(public, params.Quote))
+
+	if err := engine.Load(client, params.Since, end); err != nil {
+		return nil, fmt.Errorf("pnl.Engine.Load: %v", err)
+	}
+
+	history, err := fetchLendingHistory(client, params.Since, end)
+	if err != nil {
+		return nil, fmt.Errorf("fetchLendingHistory: %v", err)
+	}
+	engine.AddLendingHistory(history)
+
+	return NewReport(engine, params.Year)
+}
+
+// NewReport returns the report of year from an engine already loaded with the
+// account history. The report is in the reporting currency of the engine.
+func NewReport(engine *pnl.Engine, year int) (*Report, error) {
+
+	if year <= 0 {
+		return nil, fmt.Errorf("Wrong year parameter: %d", year)
+	}
+
+	start, end := yearRange(year)
+
+	disposals, err := engine.Disposals()
+	if err != nil {
+		return nil, fmt.Errorf("pnl.Engine.Disposals: %v", err)
+	}
+
+	income, err := engine.Income()
+	if err != nil {
+		return nil, fmt.Errorf("pnl.Engine.Income: %v", err)
+	}
+
+	r := Report{
+		Year:   year,
+		Quote:  engine.Quote(),
+		Method: engine.Method(),
+	}
+
+	for _, d := range disposals {
+
+		if d.Disposed.Before(start) || !d.Disposed.Before(end) {
+			continue
+		}
+
+		rec := Record{
+			Currency:     d.Currency,
+			Amount:       d.Amount,
+			Acquired:     d.Acquired,
+			Disposed:     d.Disposed,
+			Proceeds:     d.Proceeds,
+			Cost:         d.Cost,
+			Gain:         d.Gain,
+			LongTerm:     d.Disposed.After(d.Acquired.AddDate(1, 0, 0)),
+			CurrencyPair: d.CurrencyPair,
+			TradeId:      d.TradeId,
+			Unmatched:    d.Unmatched,
+		}
+
+		r.Records = append(r.Records, &rec)
+		r.Proceeds += rec.Proceeds
+		r.Cost += rec.Cost
+
+		if rec.LongTerm {
+			r.LongTermGain += rec.Gain
+		} else {
+			r.ShortTermGain += rec.Gain
+		}
+
+		if rec.Unmatched {
+			r.Unmatched++
+		}
+	}
+
+	for _, in := range income {
+		if in.Date.Before(start) || !in.Date.Before(end) {
+			continue
+		}
+		r.Income = append(r.Income, in)
+		r.IncomeValue += in.Value
+	}
+
+	sort.SliceStable(r.Records, func(i, j int) bool {
+		return r.Records[i].Disposed.Before(r.Records[j].Disposed)
+	})
+
+	return &r, nil
+}
+
+// fetchLendingHistory splits [start, end] until each call returns less than
+// lendingHistoryLimit loans.
+func fetchLendingHistory(client *tradingapi.Client, start, end time.Time) (tradingapi.LendingHistory, error) {
+
+	history, err := client.GetLendingHistory(start, end, lendingHistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.GetLendingHistory: %v", err)
+	}
+
+	if len(history) < lendingHistoryLimit {
+		return history, nil
+	}
+
+	if end.Sub(start) <= time.Second {
+		return nil, fmt.Errorf("more than %d loans between %s and %s",
+			lendingHistoryLimit, start, end)
+	}
+
+	// Ranges are inclusive: the second half starts one second after the first
+	middle := start.Add(end.Sub(start) / 2).Truncate(time.Second)
+
+	first, err := fetchLendingHistory(client, start, middle)
+	if err != nil {
+		return nil, err
+	}
+
+	second, err := fetchLendingHistory(client, middle.Add(time.Second), end)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(first, second...), nil
+}
+
+func setDefaults(params *Params) error {
+
+	if params.Year <= 0 {
+		return fmt.Errorf("Wrong year parameter: %d", params.Year)
+	}
+
+	if params.Quote == "" {
+		params.Quote = defaultQuote
+	}
+
+	if params.Since.IsZero() {
+		params.Since, _ = time.Parse("2006-01-02", defaultSince)
+	}
+
+	if _, end := yearRange(params.Year); !params.Since.Before(end) {
+		return fmt.Errorf("Wrong since parameter: %s", params.Since.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// yearRange returns the start and the end (excluded) of year in UTC.
+func yearRange(year int) (time.Time, time.Time) {
+
+	start := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(1, 0, 0)
+}
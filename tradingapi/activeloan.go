@@ -0,0 +1,101 @@
+package tradingapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+type ActiveLoans struct {
+	Provided []*ActiveLoan `json:"provided"`
+	Used     []*ActiveLoan `json:"used"`
+}
+
+type ActiveLoan struct {
+	Id        int64   `json:"id"`
+	Currency  string  `json:"currency"`
+	Rate      float64 `json:"rate,string"`
+	Amount    float64 `json:"amount,string"`
+	Range     int     `json:"range"`
+	AutoRenew bool    `json:"autoRenew"` // Only for provided loans
+	Date      int64   // Unix timestamp
+	Fees      float64 `json:"fees,string"`
+}
+
+// Poloniex trading API implementation of returnActiveLoans command.
+//
+// API Doc:
+// Returns your active loans for each currency.
+//
+// Sample output:
+//
+//  {
+//    "provided": [
+//      {
+//        "id": 75073,
+//        "currency": "LTC",
+//        "rate": "0.00020000",
+//        "amount": "0.72234880",
+//        "range": 2,
+//        "autoRenew": 0,
+//        "date": "2015-05-10 23:45:05",
+//        "fees": "0.00006000"
+//      }, ...
+//    ],
+//    "used": [
+//      {
+//        "id": 75238,
+//        "currency": "BTC",
+//        "rate": "0.00020000",
+//        "amount": "0.04843834",
+//        "range": 2,
+//        "date": "2015-05-10 23:51:12",
+//        "fees": "-0.00000001"
+//      }
+//    ]
+//  }
+func (client *Client) GetActiveLoans() (*ActiveLoans, error) {
+
+	postParameters := url.Values{}
+	postParameters.Add("command", "returnActiveLoans")
+
+	resp, err := client.do(postParameters)
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.do: %v", err)
+	}
+
+	res := ActiveLoans{}
+
+	if err := json.Unmarshal(resp, &res); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	return &res, nil
+}
+
+func (l *ActiveLoan) UnmarshalJSON(data []byte) error {
+
+	type alias ActiveLoan
+	aux := struct {
+		Date      string `json:"date"`
+		AutoRenew int    `json:"autoRenew"`
+		*alias
+	}{
+		alias: (*alias)(l),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	l.AutoRenew = aux.AutoRenew == 1
+
+	if timestamp, err := time.Parse("2006-01-02 15:04:05", aux.Date); err != nil {
+		return fmt.Errorf("time.Parse: %v", err)
+	} else {
+		l.Date = int64(timestamp.Unix())
+	}
+
+	return nil
+}
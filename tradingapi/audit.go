@@ -0,0 +1,119 @@
+package tradingapi
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parameters replaced by "REDACTED" in audit entries (case insensitive)
+var AuditRedactedParams = []string{"key", "apikey", "secret", "sign", "password", "passphrase"}
+
+// Phases of the audit entries written for every command
+const (
+	AuditRequest  = "request"  // before sending, without response
+	AuditResponse = "response" // once the response is received or the request failed
+)
+
+// An AuditEntry describes a command sent to the trading API.
+type AuditEntry struct {
+	Time       time.Time         `json:"time"` // when the request was sent
+	Phase      string            `json:"phase"`
+	Command    string            `json:"command"`
+	Params     map[string]string `json:"params"` // without command and nonce, redacted
+	Nonce      int64             `json:"nonce"`
+	ApiKey     string            `json:"apiKey"` // last 4 characters only
+	Latency    time.Duration     `json:"latency"`
+	StatusCode int               `json:"statusCode"` // 0 when no response was received
+	Body       string            `json:"body"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// An AuditSink records the commands sent by a client (see Client.SetAuditSink and
+// package audit). Record is called twice per command: before sending it (the command
+// fails without being sent when the entry can't be recorded) and once the response
+// is received, successful or not (errors are then logged, the command being sent).
+type AuditSink interface {
+	Record(e *AuditEntry) error
+}
+
+// SetAuditSink sets the sink recording the commands sent by the client, nil to stop
+// recording. It must be called before the client is used.
+func (client *Client) SetAuditSink(sink AuditSink) {
+	client.audit = sink
+}
+
+// recordRequest records the command about to be sent. The command must not be sent
+// when it fails.
+func (client *Client) recordRequest(form url.Values, nonce int64, start time.Time) error {
+
+	if client.audit == nil {
+		return nil
+	}
+
+	e := client.auditEntry(form, nonce, start)
+	e.Phase = AuditRequest
+
+	if err := client.audit.Record(e); err != nil {
+		return fmt.Errorf("AuditSink.Record: %v (API command: %s)", err, e.Command)
+	}
+
+	return nil
+}
+
+// recordResponse records the result of a command sent.
+func (client *Client) recordResponse(form url.Values, nonce int64, start time.Time, statusCode int, body []byte, err error) {
+
+	if client.audit == nil {
+		return
+	}
+
+	e := client.auditEntry(form, nonce, start)
+	e.Phase = AuditResponse
+	e.Latency = time.Since(start)
+	e.StatusCode = statusCode
+	e.Body = string(body)
+
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	if err := client.audit.Record(e); err != nil {
+		logger.WithField("error", err).Errorf("AuditSink.Record (API command: %s)", e.Command)
+	}
+}
+
+func (client *Client) auditEntry(form url.Values, nonce int64, start time.Time) *AuditEntry {
+
+	e := AuditEntry{
+		Time:    start,
+		Command: form.Get("command"),
+		Params:  make(map[string]string),
+		Nonce:   nonce,
+		ApiKey:  maskKey(client.apiKey),
+	}
+
+	for name := range form {
+		if name == "command" || name == "nonce" {
+			continue
+		}
+		e.Params[name] = form.Get(name)
+		for _, redacted := range AuditRedactedParams {
+			if strings.EqualFold(name, redacted) {
+				e.Params[name] = "REDACTED"
+			}
+		}
+	}
+
+	return &e
+}
+
+func maskKey(key string) string {
+
+	if len(key) <= 4 {
+		return strings.Repeat("*", len(key))
+	}
+
+	return "..." + key[len(key)-4:]
+}
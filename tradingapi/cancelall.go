@@ -0,0 +1,209 @@
+package tradingapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+)
+
+// Number of attempts to cancel an order when the request fails for a transient
+// reason (network error, HTTP status other than 200, nonce error, rate limit)
+const cancelAttempts = 3
+
+// CancelFilter selects the open orders to cancel.
+type CancelFilter func(currencyPair string, order *OpenOrder) bool
+
+type CancelOutcome struct {
+	CurrencyPair string
+	Order        *OpenOrder
+	Canceled     *CanceledOrder // nil when Err is set or Closed
+	// Set when a retry found the order closed: the previous attempt most likely
+	// cancelled it but its response was lost (or the order was filled meanwhile)
+	Closed   bool
+	Attempts int
+	Err      error
+}
+
+type CancelReport struct {
+	Outcomes []*CancelOutcome   // sorted by currency pair and order number
+	Refunded map[string]float64 // amounts released from orders, by currency
+	Closed   int                // orders found closed on a retry, not failed
+	Failed   int
+}
+
+// Err returns the errors of the orders which could not be cancelled, nil if all were.
+func (r *CancelReport) Err() error {
+
+	var errs []string
+
+	for _, o := range r.Outcomes {
+		if o.Err != nil {
+			errs = append(errs, fmt.Sprintf("%s order %d: %v",
+				o.CurrencyPair, o.Order.OrderNumber, o.Err))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%d orders not cancelled: %s", len(errs), strings.Join(errs, "; "))
+}
+
+// CancelAll cancels the open orders of every market.
+func (client *Client) CancelAll() (*CancelReport, error) {
+	return client.CancelWhere(nil)
+}
+
+// CancelAllForPair cancels the open orders of a market.
+func (client *Client) CancelAllForPair(currencyPair string) (*CancelReport, error) {
+
+	orders, err := client.GetOpenOrders(currencyPair)
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.GetOpenOrders: %v", err)
+	}
+
+	return client.cancelOrders(AllOpenOrders{currencyPair: orders}, nil), nil
+}
+
+// CancelWhere cancels the open orders selected by filter (every order when nil).
+//
+// Orders are cancelled concurrently, within the request rate limit of the client.
+// Requests failing for a transient reason (network error, nonce error of
+// concurrent requests reaching the exchange out of order, rate limit) are retried;
+// other API errors (e.g. order already filled) are not. An order found closed by a
+// retry counts as closed rather than failed. The refunded amounts are the amounts of
+// the cancelled orders (CanceledOrder.Amount, or the listed OpenOrder.Amount for the
+// orders found closed) in the quote currency for sell orders, converted at the order
+// rate into the base currency for buy orders.
+func (client *Client) CancelWhere(filter CancelFilter) (*CancelReport, error) {
+
+	orders, err := client.GetAllOpenOrders()
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.GetAllOpenOrders: %v", err)
+	}
+
+	return client.cancelOrders(orders, filter), nil
+}
+
+func (client *Client) cancelOrders(orders AllOpenOrders, filter CancelFilter) *CancelReport {
+
+	var outcomes []*CancelOutcome
+
+	for pair, open := range orders {
+
+		if open == nil {
+			continue
+		}
+
+		for _, o := range *open {
+			if filter == nil || filter(pair, o) {
+				outcomes = append(outcomes, &CancelOutcome{CurrencyPair: pair, Order: o})
+			}
+		}
+	}
+
+	sort.Slice(outcomes, func(i, j int) bool {
+		if outcomes[i].CurrencyPair != outcomes[j].CurrencyPair {
+			return outcomes[i].CurrencyPair < outcomes[j].CurrencyPair
+		}
+		return outcomes[i].Order.OrderNumber < outcomes[j].Order.OrderNumber
+	})
+
+	// Requests are throttled by client.do, workers only keep them flowing
+	workers := conf.MaxRequestsSec
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan *CancelOutcome)
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for outcome := range jobs {
+				client.cancelWithRetries(outcome)
+			}
+		}()
+	}
+
+	for _, outcome := range outcomes {
+		jobs <- outcome
+	}
+	close(jobs)
+	wg.Wait()
+
+	report := CancelReport{
+		Outcomes: outcomes,
+		Refunded: make(map[string]float64),
+	}
+
+	for _, outcome := range outcomes {
+
+		if outcome.Err != nil {
+			report.Failed++
+			continue
+		}
+
+		amount := outcome.Order.Amount
+		if outcome.Closed {
+			report.Closed++
+		} else {
+			amount = outcome.Canceled.Amount
+		}
+
+		base, quote, ok := util.SplitPair(outcome.CurrencyPair)
+		if !ok {
+			continue
+		}
+
+		if outcome.Order.Type == "buy" {
+			report.Refunded[base] += amount * outcome.Order.Rate
+		} else {
+			report.Refunded[quote] += amount
+		}
+	}
+
+	return &report
+}
+
+func (client *Client) cancelWithRetries(outcome *CancelOutcome) {
+
+	for outcome.Attempts < cancelAttempts {
+
+		outcome.Attempts++
+
+		res, err := client.CancelOrder(outcome.Order.OrderNumber)
+
+		switch {
+		case err == nil && res.Success:
+			outcome.Canceled, outcome.Err = res, nil
+			return
+
+		case err == nil:
+			outcome.Err = fmt.Errorf("not canceled: %s", res.Message)
+			return
+
+		// The previous attempt may have reached the exchange
+		case outcome.Attempts > 1 && util.IsOrderClosed(err):
+			outcome.Closed, outcome.Err = true, nil
+			return
+
+		case !util.IsRetryable(err):
+			outcome.Err = err
+			return
+		}
+
+		outcome.Err = err
+		logger.WithField("error", err).Warnf("cancel order %d (attempt %d)",
+			outcome.Order.OrderNumber, outcome.Attempts)
+
+		time.Sleep(time.Duration(outcome.Attempts) * 500 * time.Millisecond)
+	}
+}
@@ -39,6 +39,8 @@ func main() {
 	// sellImmediateOrCancel()
 	// sellPostOnly()
 	// cancelOrder()
+	// cancelAllForPair()
+	// cancelWhere()
 	// moveOrder()
 	// moveOrderPostOnly()
 	// moveOrderImmediateOrCancel()
@@ -48,6 +50,11 @@ func main() {
 	// printAvailableAccountBalances()
 	// printAccountBalances()
 	// printTradableBalances()
+	// createLoanOffer()
+	// cancelLoanOffer()
+	// printOpenLoanOffers()
+	// printActiveLoans()
+	// printLendingHistory()
 
 	/*
 
@@ -60,11 +67,6 @@ func main() {
 	   marginSell
 	   getMarginPosition
 	   closeMarginPosition
-	   createLoanOffer
-	   cancelLoanOffer
-	   returnOpenLoanOffers
-	   returnActiveLoans
-	   returnLendingHistory
 	   toggleAutoRenew
 
 	*/
@@ -316,6 +318,40 @@ func cancelOrder() {
 	poloniex.PrettyPrintJson(res)
 }
 
+// Cancel all BTC_ETH open orders
+func cancelAllForPair() {
+
+	res, err := client.CancelAllForPair("BTC_ETH")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%d cancelled, %d failed, refunded: %v\n",
+		len(res.Outcomes)-res.Failed, res.Failed, res.Refunded)
+
+	if err := res.Err(); err != nil {
+		log.Println(err)
+	}
+}
+
+// Cancel all buy orders older than one day
+func cancelWhere() {
+
+	dayAgo := time.Now().Add(-24 * time.Hour).Unix()
+
+	res, err := client.CancelWhere(func(currencyPair string, o *tradingapi.OpenOrder) bool {
+		return o.Type == "buy" && o.Date < dayAgo
+	})
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%d cancelled, %d failed, refunded: %v\n",
+		len(res.Outcomes)-res.Failed, res.Failed, res.Refunded)
+}
+
 // Move order 258562801525 at rate 0.011 and amount 0.01
 func moveOrder() {
 
@@ -431,3 +467,66 @@ func printTradableBalances() {
 
 	poloniex.PrettyPrintJson(res)
 }
+
+// Offer 0.1 BTC for 2 days at 0.02% per day, without auto-renew
+func createLoanOffer() {
+
+	res, err := client.CreateLoanOffer("BTC", 0.1, 2, false, 0.0002)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	poloniex.PrettyPrintJson(res)
+}
+
+// Cancel loan offer 10590
+func cancelLoanOffer() {
+
+	res, err := client.CancelLoanOffer(10590)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	poloniex.PrettyPrintJson(res)
+}
+
+// Print open loan offers
+func printOpenLoanOffers() {
+
+	res, err := client.GetOpenLoanOffers()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	poloniex.PrettyPrintJson(res)
+}
+
+// Print active loans
+func printActiveLoans() {
+
+	res, err := client.GetActiveLoans()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	poloniex.PrettyPrintJson(res)
+}
+
+// Print lending history of the last 30 days
+func printLendingHistory() {
+
+	end := time.Now()
+	start := end.Add(-30 * 24 * time.Hour)
+
+	res, err := client.GetLendingHistory(start, end, 0)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	poloniex.PrettyPrintJson(res)
+}
@@ -0,0 +1,102 @@
+package tradingapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type LendingHistory []*Loan
+
+type Loan struct {
+	Id       int64   `json:"id"`
+	Currency string  `json:"currency"`
+	Rate     float64 `json:"rate,string"`
+	Amount   float64 `json:"amount,string"`
+	Duration float64 `json:"duration,string"` // days
+	Interest float64 `json:"interest,string"`
+	Fee      float64 `json:"fee,string"` // negative
+	Earned   float64 `json:"earned,string"`
+	Open     int64   // Unix timestamp
+	Close    int64   // Unix timestamp
+}
+
+// Poloniex trading API implementation of returnLendingHistory command.
+//
+// API Doc:
+// Returns your lending history within a time range specified by the "start" and "end" POST
+// parameters as UNIX timestamps. "limit" may also be specified to limit the number of rows
+// returned.
+//
+// Sample output:
+//
+//  [
+//    {
+//      "id": 175589553,
+//      "currency": "BTC",
+//      "rate": "0.00057400",
+//      "amount": "0.04374404",
+//      "duration": "0.47610000",
+//      "interest": "0.00001196",
+//      "fee": "-0.00000179",
+//      "earned": "0.00001017",
+//      "open": "2016-09-28 06:47:26",
+//      "close": "2016-09-28 18:13:03"
+//    }, ...
+//  ]
+func (client *Client) GetLendingHistory(start, end time.Time, limit int) (LendingHistory, error) {
+
+	postParameters := url.Values{}
+	postParameters.Add("command", "returnLendingHistory")
+	postParameters.Add("start", strconv.Itoa(int(start.Unix())))
+	postParameters.Add("end", strconv.Itoa(int(end.Unix())))
+
+	if limit > 0 {
+		postParameters.Add("limit", strconv.Itoa(limit))
+	}
+
+	resp, err := client.do(postParameters)
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.do: %v", err)
+	}
+
+	res := LendingHistory{}
+
+	if err := json.Unmarshal(resp, &res); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	return res, nil
+}
+
+func (l *Loan) UnmarshalJSON(data []byte) error {
+
+	type alias Loan
+	aux := struct {
+		Open  string `json:"open"`
+		Close string `json:"close"`
+		*alias
+	}{
+		alias: (*alias)(l),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	if timestamp, err := time.Parse("2006-01-02 15:04:05", aux.Open); err != nil {
+		return fmt.Errorf("time.Parse: %v", err)
+	} else {
+		l.Open = int64(timestamp.Unix())
+	}
+
+	if timestamp, err := time.Parse("2006-01-02 15:04:05", aux.Close); err != nil {
+		return fmt.Errorf("time.Parse: %v", err)
+	} else {
+		l.Close = int64(timestamp.Unix())
+	}
+
+	return nil
+}
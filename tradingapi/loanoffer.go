@@ -0,0 +1,131 @@
+package tradingapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+type LoanOffer struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	OrderId int64  `json:"orderID"`
+}
+
+type CanceledLoanOffer struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// Poloniex trading API implementation of createLoanOffer command.
+//
+// API Doc:
+// Creates a loan offer for a given currency. Required POST parameters are "currency", "amount",
+// "duration", "autoRenew" (0 or 1), and "lendingRate".
+//
+// Sample output:
+//
+//  {
+//    "success": 1,
+//    "message": "Loan order placed.",
+//    "orderID": 10590
+//  }
+func (client *Client) CreateLoanOffer(currency string, amount float64, duration int,
+	autoRenew bool, lendingRate float64) (*LoanOffer, error) {
+
+	postParameters := url.Values{}
+	postParameters.Add("command", "createLoanOffer")
+	postParameters.Add("currency", currency)
+	postParameters.Add("amount", strconv.FormatFloat(amount, 'f', -1, 64))
+	postParameters.Add("duration", strconv.Itoa(duration))
+	postParameters.Add("lendingRate", strconv.FormatFloat(lendingRate, 'f', -1, 64))
+
+	if autoRenew {
+		postParameters.Add("autoRenew", "1")
+	} else {
+		postParameters.Add("autoRenew", "0")
+	}
+
+	resp, err := client.do(postParameters)
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.do: %v", err)
+	}
+
+	res := LoanOffer{}
+
+	if err := json.Unmarshal(resp, &res); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	return &res, nil
+}
+
+// Poloniex trading API implementation of cancelLoanOffer command.
+//
+// API Doc:
+// Cancels a loan offer specified by the "orderNumber" POST parameter.
+//
+// Sample output:
+//
+//  {
+//    "success": 1,
+//    "message": "Loan offer canceled."
+//  }
+func (client *Client) CancelLoanOffer(orderNumber int64) (*CanceledLoanOffer, error) {
+
+	postParameters := url.Values{}
+	postParameters.Add("command", "cancelLoanOffer")
+	postParameters.Add("orderNumber", strconv.FormatInt(orderNumber, 10))
+
+	resp, err := client.do(postParameters)
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.do: %v", err)
+	}
+
+	res := CanceledLoanOffer{}
+
+	if err := json.Unmarshal(resp, &res); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	return &res, nil
+}
+
+func (l *LoanOffer) UnmarshalJSON(data []byte) error {
+
+	type alias LoanOffer
+	aux := struct {
+		Success int `json:"success"`
+		*alias
+	}{
+		alias: (*alias)(l),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	l.Success = aux.Success == 1
+
+	return nil
+}
+
+func (c *CanceledLoanOffer) UnmarshalJSON(data []byte) error {
+
+	type alias CanceledLoanOffer
+	aux := struct {
+		Success int `json:"success"`
+		*alias
+	}{
+		alias: (*alias)(c),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	c.Success = aux.Success == 1
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package tradingapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+type OpenLoanOffers map[string][]*OpenLoanOffer
+
+type OpenLoanOffer struct {
+	Id        int64   `json:"id"`
+	Rate      float64 `json:"rate,string"`
+	Amount    float64 `json:"amount,string"`
+	Duration  int     `json:"duration"`
+	AutoRenew bool    `json:"autoRenew"`
+	Date      int64   // Unix timestamp
+}
+
+// Poloniex trading API implementation of returnOpenLoanOffers command.
+//
+// API Doc:
+// Returns your open loan offers for each currency.
+//
+// Sample output:
+//
+//  {
+//    "BTC": [
+//      {
+//        "id": 10595,
+//        "rate": "0.00020000",
+//        "amount": "3.00000000",
+//        "duration": 2,
+//        "autoRenew": 1,
+//        "date": "2015-05-10 23:33:50"
+//      }
+//    ],
+//    "LTC": [
+//      {
+//        "id": 10598,
+//        "rate": "0.00002100",
+//        "amount": "10.00000000",
+//        "duration": 2,
+//        "autoRenew": 1,
+//        "date": "2015-05-10 23:34:35"
+//      }
+//    ]
+//  }
+func (client *Client) GetOpenLoanOffers() (OpenLoanOffers, error) {
+
+	postParameters := url.Values{}
+	postParameters.Add("command", "returnOpenLoanOffers")
+
+	resp, err := client.do(postParameters)
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.do: %v", err)
+	}
+
+	res := make(OpenLoanOffers)
+
+	// Empty array instead of an object when there is no offer
+	if bytes.Equal(bytes.TrimSpace(resp), []byte("[]")) {
+		return res, nil
+	}
+
+	if err := json.Unmarshal(resp, &res); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	return res, nil
+}
+
+func (o *OpenLoanOffer) UnmarshalJSON(data []byte) error {
+
+	type alias OpenLoanOffer
+	aux := struct {
+		Date      string `json:"date"`
+		AutoRenew int    `json:"autoRenew"`
+		*alias
+	}{
+		alias: (*alias)(o),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	o.AutoRenew = aux.AutoRenew == 1
+
+	if timestamp, err := time.Parse("2006-01-02 15:04:05", aux.Date); err != nil {
+		return fmt.Errorf("time.Parse: %v", err)
+	} else {
+		o.Date = int64(timestamp.Unix())
+	}
+
+	return nil
+}
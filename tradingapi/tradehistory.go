@@ -136,6 +136,81 @@ func (client *Client) GetAllTradeHistory(start, end time.Time) (AllTradeHistory,
 	return res, nil
 }
 
+// Maximum number of trades returned by returnTradeHistory ("limit" parameter)
+const maxTradeHistoryLimit = 10000
+
+// GetAllTradeHistoryLimit returns at most limit trades for all markets (500 when
+// limit is 0, 10000 at most), the most recent first.
+func (client *Client) GetAllTradeHistoryLimit(start, end time.Time, limit int) (AllTradeHistory, error) {
+
+	postParameters := url.Values{}
+	postParameters.Add("command", "returnTradeHistory")
+	postParameters.Add("currencyPair", "all")
+	postParameters.Add("start", strconv.Itoa(int(start.Unix())))
+	postParameters.Add("end", strconv.Itoa(int(end.Unix())))
+
+	if limit > 0 {
+		postParameters.Add("limit", strconv.Itoa(limit))
+	}
+
+	resp, err := client.do(postParameters)
+	if err != nil {
+		return nil, fmt.Errorf("TradingClient.do: %v", err)
+	}
+
+	res := make(AllTradeHistory, 0)
+
+	if err := json.Unmarshal(resp, &res); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	return res, nil
+}
+
+// GetFullTradeHistory returns every trade for all markets between start and end.
+// Unlike GetAllTradeHistory, limited to the 500 most recent trades, the range is
+// split in halves until each call returns less than the maximum number of trades.
+func (client *Client) GetFullTradeHistory(start, end time.Time) (AllTradeHistory, error) {
+
+	trades, err := client.GetAllTradeHistoryLimit(start, end, maxTradeHistoryLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	count := 0
+	for _, history := range trades {
+		count += len(history)
+	}
+
+	if count < maxTradeHistoryLimit {
+		return trades, nil
+	}
+
+	if end.Sub(start) <= time.Second {
+		return nil, fmt.Errorf("more than %d trades between %s and %s",
+			maxTradeHistoryLimit, start, end)
+	}
+
+	// Ranges are inclusive: the second half starts one second after the first
+	middle := start.Add(end.Sub(start) / 2).Truncate(time.Second)
+
+	res, err := client.GetFullTradeHistory(start, middle)
+	if err != nil {
+		return nil, err
+	}
+
+	second, err := client.GetFullTradeHistory(middle.Add(time.Second), end)
+	if err != nil {
+		return nil, err
+	}
+
+	for pair, history := range second {
+		res[pair] = append(history, res[pair]...) // most recent first
+	}
+
+	return res, nil
+}
+
 func (t *Trade) UnmarshalJSON(data []byte) error {
 
 	type alias Trade
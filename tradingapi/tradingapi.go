@@ -35,8 +35,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -53,6 +55,10 @@ type Client struct {
 	apiSecret  string
 	httpClient *http.Client
 	throttle   <-chan time.Time
+	audit      AuditSink
+
+	nonceMu sync.Mutex
+	nonce   int64 // last nonce sent
 }
 
 type APIError struct {
@@ -83,14 +89,24 @@ func init() {
 
 	logger = logrus.WithField("prefix", "[api:poloniex:tradingapi]")
 
+	// Default configuration, overridden by conf.json when present
+	conf = &configuration{apiConf{
+		APIUrl:               "https://poloniex.com/tradingApi",
+		HTTPClientTimeoutSec: 10,
+		MaxRequestsSec:       5,
+		LogLevel:             "warn",
+	}}
+
 	content, err := ioutil.ReadFile("conf.json")
 
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		logger.WithField("error", err).Fatal("loading configuration")
 	}
 
-	if err := json.Unmarshal(content, &conf); err != nil {
-		logger.WithField("error", err).Fatal("loading configuration")
+	if err == nil {
+		if err := json.Unmarshal(content, &conf); err != nil {
+			logger.WithField("error", err).Fatal("loading configuration")
+		}
 	}
 
 	switch conf.LogLevel {
@@ -113,6 +129,12 @@ func init() {
 
 // NewClient returns a newly configured client
 func NewClient() (*Client, error) {
+	return NewClientWithCredentials(conf.ApiKey, conf.ApiSecret)
+}
+
+// NewClientWithCredentials returns a newly configured client using the given
+// API key and secret instead of the ones from the configuration file.
+func NewClientWithCredentials(apiKey, apiSecret string) (*Client, error) {
 
 	reqInterval := 1000 * time.Millisecond / time.Duration(conf.MaxRequestsSec)
 
@@ -120,83 +142,155 @@ func NewClient() (*Client, error) {
 		Timeout: time.Duration(conf.HTTPClientTimeoutSec) * time.Second,
 	}
 
-	if len(conf.ApiKey) == 0 || len(conf.ApiSecret) == 0 {
+	if len(apiKey) == 0 || len(apiSecret) == 0 {
 
 		err := errors.New("new trading client: wrong apikey and/or apisecret")
 		return nil, err
 	}
 
 	tc := Client{
-		conf.ApiKey,
-		conf.ApiSecret,
-		&client,
-		time.Tick(reqInterval),
+		apiKey:     apiKey,
+		apiSecret:  apiSecret,
+		httpClient: &client,
+		throttle:   time.Tick(reqInterval),
 	}
 
 	return &tc, nil
 }
 
-// Do prepares and executes api call requests.
-func (c *Client) do(form url.Values) ([]byte, error) {
+// Credentials of an API key.
+type Credentials struct {
+	ApiKey    string `json:"api_key"`
+	ApiSecret string `json:"api_secret"`
+}
 
-	nonce := time.Now().UnixNano()
-	form.Add("nonce", strconv.Itoa(int(nonce)))
+// A CredentialProvider returns the API credentials, e.g. from the environment, an
+// encrypted keystore or an external command (see package credentials), so that
+// they never need to be stored in cleartext.
+type CredentialProvider interface {
+	Credentials() (*Credentials, error)
+}
 
-	req, err := http.NewRequest("POST",
-		conf.APIUrl,
-		strings.NewReader(form.Encode()))
+// NewClientWithProvider returns a newly configured client using the credentials
+// returned by provider.
+func NewClientWithProvider(provider CredentialProvider) (*Client, error) {
 
+	c, err := provider.Credentials()
 	if err != nil {
-		return nil, fmt.Errorf("http.NewRequest: %v (API command: %s)",
-			err, form.Get("command"))
+		return nil, fmt.Errorf("CredentialProvider.Credentials: %v", err)
 	}
 
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Key", c.apiKey)
+	return NewClientWithCredentials(c.ApiKey, c.ApiSecret)
+}
 
-	if sig, err := signForm(form, c.apiSecret); err != nil {
-		return nil, fmt.Errorf("signForm: %v", err)
-	} else {
-		req.Header.Add("Sign", sig)
-	}
+// Do prepares and executes api call requests.
+func (c *Client) do(form url.Values) ([]byte, error) {
 
 	type result struct {
-		resp *http.Response
-		err  error
+		resp  *http.Response
+		nonce int64
+		start time.Time
+		sent  bool
+		err   error
 	}
 
 	done := make(chan result)
 	go func() {
 		<-c.throttle
-		resp, err := c.httpClient.Do(req)
-		done <- result{resp, err}
+		// The nonce is taken once throttled, which reduces the reordering of
+		// concurrent commands without preventing it: they are sent concurrently and
+		// may still reach the exchange out of nonce order (see util.IsRetryable)
+		res := result{nonce: c.nextNonce(), start: time.Now()}
+		if res.err = c.recordRequest(form, res.nonce, res.start); res.err == nil {
+			res.resp, res.err = c.send(form, res.nonce)
+			res.sent = true
+		}
+		done <- res
 	}()
 	res := <-done
 
+	nonce, start := res.nonce, res.start
+
 	if res.err != nil {
-		return nil, fmt.Errorf("http.Client.Do: %v", res.err)
+		if res.sent {
+			c.recordResponse(form, nonce, start, 0, nil, res.err)
+		}
+		return nil, res.err
 	}
 
 	defer res.resp.Body.Close()
 
 	body, err := ioutil.ReadAll(res.resp.Body)
 	if err != nil {
-		return body, fmt.Errorf("ioutil.readAll: %v", err)
+		err = fmt.Errorf("ioutil.readAll: %v", err)
+		c.recordResponse(form, nonce, start, res.resp.StatusCode, body, err)
+		return body, err
 	}
 
 	if res.resp.StatusCode != 200 {
-		return body, fmt.Errorf("Status code: %s (API command: %s)",
+		err := fmt.Errorf("Status code: %s (API command: %s)",
 			res.resp.Status, form.Get("command"))
+		c.recordResponse(form, nonce, start, res.resp.StatusCode, body, err)
+		return body, err
 	}
 
 	if err := checkAPIError(body); err != nil {
+		c.recordResponse(form, nonce, start, res.resp.StatusCode, body, err)
 		return nil, err
 	}
 
+	c.recordResponse(form, nonce, start, res.resp.StatusCode, body, nil)
+
 	return body, nil
 }
 
+// send signs form with nonce and posts it.
+func (c *Client) send(form url.Values, nonce int64) (*http.Response, error) {
+
+	form.Set("nonce", strconv.FormatInt(nonce, 10))
+
+	req, err := http.NewRequest("POST",
+		conf.APIUrl,
+		strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequest: %v (API command: %s)",
+			err, form.Get("command"))
+	}
+
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Key", c.apiKey)
+
+	if sig, err := signForm(form, c.apiSecret); err != nil {
+		return nil, fmt.Errorf("signForm: %v", err)
+	} else {
+		req.Header.Add("Sign", sig)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http.Client.Do: %v", err)
+	}
+
+	return resp, nil
+}
+
+// nextNonce returns a nonce greater than the previous ones of the client.
+func (c *Client) nextNonce() int64 {
+
+	c.nonceMu.Lock()
+	defer c.nonceMu.Unlock()
+
+	nonce := time.Now().UnixNano()
+	if nonce <= c.nonce {
+		nonce = c.nonce + 1
+	}
+	c.nonce = nonce
+
+	return nonce
+}
+
 func checkAPIError(body []byte) error {
 
 	if !strings.Contains(string(body), "\"error\":") {
@@ -37,7 +37,6 @@ func (client *Client) Withdraw(currency string, amount float64, address string)
 		return nil, fmt.Errorf("TradingClient.do: %v", err)
 	}
 
-	fmt.Println(string(resp))
 	res := Withdrawal{}
 
 	if err := json.Unmarshal(resp, &res); err != nil {
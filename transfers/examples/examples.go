@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/joemocquant/poloniex-api/transfers"
+)
+
+func main() {
+
+	client, err := tradingapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	watcher, err := transfers.NewWatcher(client, "transfers.json", transfers.Params{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go watcher.Run(done)
+
+	for e := range watcher.Events() {
+
+		switch e.Kind {
+		case transfers.DepositConfirmation:
+			fmt.Printf("%s %s: %d -> %d confirmations\n",
+				e.Deposit.Currency, e.Deposit.TxId, e.PreviousConfirmations, e.Deposit.Confirmations)
+
+		case transfers.DepositCredited:
+			fmt.Printf("credited %.8f %s\n", e.Deposit.Amount, e.Deposit.Currency)
+
+		case transfers.WithdrawalNew, transfers.WithdrawalStatus:
+			fmt.Printf("withdrawal %d of %.8f %s: %s (%s)\n", e.Withdrawal.WithdrawalNumber,
+				e.Withdrawal.Amount, e.Withdrawal.Currency, e.Status(), e.Withdrawal.Status)
+
+		default:
+			fmt.Printf("%s: %.8f %s\n", e.Kind, e.Deposit.Amount, e.Deposit.Currency)
+		}
+	}
+}
@@ -0,0 +1,397 @@
+// Deposit and withdrawal watcher.
+//
+// A Watcher polls GetDepositsWithdrawals from a cursor and tracks every deposit (by
+// currency, txid and address) and withdrawal (by WithdrawalNumber) until it is final.
+// It emits an event when a deposit or withdrawal appears, when the confirmations of a
+// deposit increase, when a deposit is credited and when the status of a withdrawal
+// changes (pending, complete, error).
+//
+// The cursor and the tracked items are saved to a JSON file after every poll, so that
+// a restarted watcher resumes where it stopped. Events are emitted before the state
+// is saved: after a crash, the events of the last poll may be emitted again.
+package transfers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:transfers]")
+
+const (
+	defaultInterval = time.Minute
+	// Deposits and withdrawals may be listed after their timestamp: each poll starts
+	// overlap before the cursor
+	defaultOverlap = time.Hour
+	// History watched on the first run, without saved state
+	defaultLookback = 24 * time.Hour
+)
+
+type Kind int
+
+const (
+	DepositNew          Kind = iota // deposit listed for the first time
+	DepositConfirmation             // confirmations increased
+	DepositCredited                 // status COMPLETE
+	WithdrawalNew                   // withdrawal listed for the first time
+	WithdrawalStatus                // status changed
+)
+
+func (k Kind) String() string {
+
+	switch k {
+	case DepositNew:
+		return "deposit"
+	case DepositConfirmation:
+		return "deposit confirmation"
+	case DepositCredited:
+		return "deposit credited"
+	case WithdrawalNew:
+		return "withdrawal"
+	case WithdrawalStatus:
+		return "withdrawal status"
+	default:
+		return fmt.Sprintf("unknown kind %d", int(k))
+	}
+}
+
+// Withdrawal status classes
+type Status int
+
+const (
+	Pending Status = iota // e.g. PENDING, AWAITING APPROVAL
+	Complete
+	Error // e.g. COMPLETE: ERROR, CANCELED
+)
+
+func (s Status) String() string {
+
+	switch s {
+	case Pending:
+		return "pending"
+	case Complete:
+		return "complete"
+	case Error:
+		return "error"
+	default:
+		return fmt.Sprintf("unknown status %d", int(s))
+	}
+}
+
+// WithdrawalStatusOf classifies the status of a withdrawal ("COMPLETE: <txid>",
+// "PENDING", ...).
+func WithdrawalStatusOf(status string) Status {
+
+	switch util.WithdrawalStatusOf(status) {
+	case util.WithdrawalFailed:
+		return Error
+	case util.WithdrawalComplete:
+		return Complete
+	default:
+		return Pending
+	}
+}
+
+type Event struct {
+	Kind       Kind
+	Time       time.Time
+	Deposit    *tradingapi.DepositHistory    // deposit events
+	Withdrawal *tradingapi.WithdrawalHistory // withdrawal events
+
+	// Before the event: zero values for new deposits and withdrawals
+	PreviousStatus        string
+	PreviousConfirmations int
+}
+
+// Status returns the class of the withdrawal status of a withdrawal event.
+func (e *Event) Status() Status {
+
+	if e.Withdrawal == nil {
+		return Pending
+	}
+
+	return WithdrawalStatusOf(e.Withdrawal.Status)
+}
+
+type Events chan *Event
+
+type Params struct {
+	Since    time.Time     // start of the history on the first run, 24h ago by default
+	Interval time.Duration // poll interval, 1 minute by default
+	Overlap  time.Duration // polls start Overlap before the cursor, 1 hour by default
+}
+
+// tracked is the last known state of a deposit or withdrawal.
+type tracked struct {
+	Timestamp     int64  `json:"timestamp"`
+	Status        string `json:"status"`
+	Confirmations int    `json:"confirmations,omitempty"`
+	Final         bool   `json:"final"`
+}
+
+type state struct {
+	Cursor      int64               `json:"cursor"` // Unix timestamp of the last poll
+	Deposits    map[string]*tracked `json:"deposits"`
+	Withdrawals map[string]*tracked `json:"withdrawals"`
+}
+
+type Watcher struct {
+	client *tradingapi.Client
+	path   string
+	params Params
+
+	mu    sync.Mutex // held during a poll
+	state state
+
+	events Events
+}
+
+// NewWatcher returns a watcher polling with client and saving its state to path,
+// loading the state saved by a previous run.
+func NewWatcher(client *tradingapi.Client, path string, params Params) (*Watcher, error) {
+
+	if params.Interval <= 0 {
+		params.Interval = defaultInterval
+	}
+
+	if params.Overlap <= 0 {
+		params.Overlap = defaultOverlap
+	}
+
+	if params.Since.IsZero() {
+		params.Since = time.Now().Add(-defaultLookback)
+	}
+
+	w := Watcher{
+		client: client,
+		path:   path,
+		params: params,
+		state: state{
+			Cursor:      params.Since.Unix(),
+			Deposits:    make(map[string]*tracked),
+			Withdrawals: make(map[string]*tracked),
+		},
+		events: make(Events, 100),
+	}
+
+	content, err := ioutil.ReadFile(path)
+
+	switch {
+	case os.IsNotExist(err):
+		return &w, nil
+	case err != nil:
+		return nil, fmt.Errorf("ioutil.ReadFile: %v", err)
+	}
+
+	if err := json.Unmarshal(content, &w.state); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	if w.state.Deposits == nil {
+		w.state.Deposits = make(map[string]*tracked)
+	}
+
+	if w.state.Withdrawals == nil {
+		w.state.Withdrawals = make(map[string]*tracked)
+	}
+
+	return &w, nil
+}
+
+// Events returns the channel on which events are emitted. It must be consumed while
+// the watcher is running.
+func (w *Watcher) Events() Events {
+	return w.events
+}
+
+// Cursor returns the time of the last poll.
+func (w *Watcher) Cursor() time.Time {
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return time.Unix(w.state.Cursor, 0)
+}
+
+// Run polls every interval until done is closed. Errors are logged.
+func (w *Watcher) Run(done <-chan struct{}) {
+
+	t := time.NewTicker(w.params.Interval)
+	defer t.Stop()
+
+	for {
+		if err := w.Poll(); err != nil {
+			logger.WithField("error", err).Error("Watcher.Poll")
+		}
+
+		select {
+		case <-t.C:
+		case <-done:
+			return
+		}
+	}
+}
+
+// Poll loads the deposits and withdrawals since the cursor (minus the overlap, and
+// from the oldest item not final), emits the events and saves the state.
+func (w *Watcher) Poll() error {
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	start := w.state.Cursor - int64(w.params.Overlap/time.Second)
+
+	for _, items := range []map[string]*tracked{w.state.Deposits, w.state.Withdrawals} {
+		for _, t := range items {
+			if !t.Final && t.Timestamp < start {
+				start = t.Timestamp
+			}
+		}
+	}
+
+	dw, err := w.client.GetDepositsWithdrawals(time.Unix(start, 0), now)
+	if err != nil {
+		return fmt.Errorf("TradingClient.GetDepositsWithdrawals: %v", err)
+	}
+
+	var events []*Event
+
+	for _, d := range dw.Deposits {
+		events = append(events, w.deposit(d, now)...)
+	}
+
+	for _, wd := range dw.Withdrawals {
+		events = append(events, w.withdrawal(wd, now)...)
+	}
+
+	for _, e := range events {
+		w.events <- e
+	}
+
+	w.state.Cursor = now.Unix()
+	w.prune(now.Unix() - int64(w.params.Overlap/time.Second))
+
+	return w.save()
+}
+
+func depositKey(d *tradingapi.DepositHistory) string {
+	return d.Currency + ":" + d.TxId + ":" + d.Address
+}
+
+// deposit updates the state of d and returns its events. It must be called with w.mu
+// held.
+func (w *Watcher) deposit(d *tradingapi.DepositHistory, now time.Time) []*Event {
+
+	var events []*Event
+
+	event := func(kind Kind, prev *tracked) {
+		e := Event{Kind: kind, Time: now, Deposit: d}
+		if prev != nil {
+			e.PreviousStatus, e.PreviousConfirmations = prev.Status, prev.Confirmations
+		}
+		events = append(events, &e)
+		logger.Infof("%s: %.8f %s %s (%d confirmations, %s)",
+			kind, d.Amount, d.Currency, d.TxId, d.Confirmations, d.Status)
+	}
+
+	credited := d.Status == "COMPLETE"
+
+	key := depositKey(d)
+	prev, ok := w.state.Deposits[key]
+
+	switch {
+	case !ok:
+		event(DepositNew, nil)
+		if credited {
+			event(DepositCredited, nil)
+		}
+
+	case prev.Final:
+		return nil
+
+	default:
+		if d.Confirmations > prev.Confirmations {
+			event(DepositConfirmation, prev)
+		}
+		if credited {
+			event(DepositCredited, prev)
+		}
+	}
+
+	w.state.Deposits[key] = &tracked{
+		Timestamp:     d.Timestamp,
+		Status:        d.Status,
+		Confirmations: d.Confirmations,
+		Final:         credited,
+	}
+
+	return events
+}
+
+// withdrawal updates the state of wd and returns its events. It must be called with
+// w.mu held.
+func (w *Watcher) withdrawal(wd *tradingapi.WithdrawalHistory, now time.Time) []*Event {
+
+	var events []*Event
+
+	event := func(kind Kind, prev *tracked) {
+		e := Event{Kind: kind, Time: now, Withdrawal: wd}
+		if prev != nil {
+			e.PreviousStatus = prev.Status
+		}
+		events = append(events, &e)
+		logger.Infof("%s %d: %.8f %s (%s)", kind, wd.WithdrawalNumber, wd.Amount, wd.Currency, wd.Status)
+	}
+
+	key := strconv.FormatInt(wd.WithdrawalNumber, 10)
+	prev, ok := w.state.Withdrawals[key]
+
+	switch {
+	case !ok:
+		event(WithdrawalNew, nil)
+	case prev.Status != wd.Status:
+		event(WithdrawalStatus, prev)
+	default:
+		return nil
+	}
+
+	w.state.Withdrawals[key] = &tracked{
+		Timestamp: wd.Timestamp,
+		Status:    wd.Status,
+		Final:     WithdrawalStatusOf(wd.Status) != Pending,
+	}
+
+	return events
+}
+
+// prune removes the final items older than before, which are not polled anymore.
+func (w *Watcher) prune(before int64) {
+
+	for _, items := range []map[string]*tracked{w.state.Deposits, w.state.Withdrawals} {
+		for key, t := range items {
+			if t.Final && t.Timestamp < before {
+				delete(items, key)
+			}
+		}
+	}
+}
+
+func (w *Watcher) save() error {
+
+	content, err := json.MarshalIndent(&w.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %v", err)
+	}
+
+	return util.WriteFile(w.path, content)
+}
@@ -0,0 +1,406 @@
+// Client-side stop orders.
+//
+// The trading API has no stop orders. An Engine watches market rates (push API ticks
+// or trades) and sends Buy/Sell orders, optionally immediate-or-cancel at a limit
+// offset, when stop-loss, take-profit or trailing-stop conditions are met. Two
+// triggers can be linked as one-cancels-other. Triggers are saved to a JSON file
+// after every change so that they survive a restart.
+package triggers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/orderbook"
+	"github.com/joemocquant/poloniex-api/pushapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("prefix", "[api:poloniex:triggers]")
+
+type Event struct {
+	Trigger *Trigger // copy of the trigger after the event
+}
+
+type Events chan *Event
+
+type Engine struct {
+	client *tradingapi.Client
+	path   string
+
+	mu       sync.Mutex
+	triggers map[string]*Trigger
+	lastId   int64
+
+	events Events
+}
+
+// NewEngine returns an engine sending orders with client and saving its triggers to
+// path, loading the triggers saved by a previous run. Triggers interrupted while
+// firing are marked failed rather than fired again: their order may have been sent.
+func NewEngine(client *tradingapi.Client, path string) (*Engine, error) {
+
+	e := Engine{
+		client:   client,
+		path:     path,
+		triggers: make(map[string]*Trigger),
+		events:   make(Events, 100),
+	}
+
+	content, err := ioutil.ReadFile(path)
+
+	switch {
+	case os.IsNotExist(err):
+		return &e, nil
+	case err != nil:
+		return nil, fmt.Errorf("ioutil.ReadFile: %v", err)
+	}
+
+	var triggers []*Trigger
+	if err := json.Unmarshal(content, &triggers); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+
+	for _, t := range triggers {
+		if t.State == Firing {
+			t.State = Failed
+			t.Error = "interrupted while firing, order may have been sent"
+			logger.Warnf("trigger %s: %s", t.Id, t.Error)
+		}
+		e.triggers[t.Id] = t
+	}
+
+	return &e, nil
+}
+
+// Events returns the channel on which fired, failed and cancelled triggers are
+// emitted. It must be consumed while the engine is running.
+func (e *Engine) Events() Events {
+	return e.events
+}
+
+// Add adds a trigger and returns its id (generated when empty).
+func (e *Engine) Add(t Trigger) (string, error) {
+
+	if err := t.validate(); err != nil {
+		return "", err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.add(&t); err != nil {
+		return "", err
+	}
+
+	if err := e.save(); err != nil {
+		return "", err
+	}
+
+	return t.Id, nil
+}
+
+// AddOCO adds two triggers cancelling each other when one fires, e.g. the
+// stop-loss and the take-profit of a position.
+func (e *Engine) AddOCO(a, b Trigger) (string, string, error) {
+
+	if err := a.validate(); err != nil {
+		return "", "", err
+	}
+	if err := b.validate(); err != nil {
+		return "", "", err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.add(&a); err != nil {
+		return "", "", err
+	}
+
+	if err := e.add(&b); err != nil {
+		delete(e.triggers, a.Id)
+		return "", "", err
+	}
+
+	a.OCO, b.OCO = b.Id, a.Id
+
+	if err := e.save(); err != nil {
+		return "", "", err
+	}
+
+	return a.Id, b.Id, nil
+}
+
+// add must be called with e.mu held.
+func (e *Engine) add(t *Trigger) error {
+
+	if t.Id == "" {
+		id := time.Now().UnixNano()
+		if id <= e.lastId {
+			id = e.lastId + 1
+		}
+		e.lastId = id
+		t.Id = strconv.FormatInt(id, 10)
+	}
+
+	if _, ok := e.triggers[t.Id]; ok {
+		return fmt.Errorf("trigger %s already exists", t.Id)
+	}
+
+	t.State = Active
+	t.Created = time.Now()
+	t.Updated = t.Created
+	t.Extreme = 0
+	e.triggers[t.Id] = t
+
+	return nil
+}
+
+// Cancel cancels an active trigger.
+func (e *Engine) Cancel(id string) error {
+
+	e.mu.Lock()
+
+	t, ok := e.triggers[id]
+	if !ok || t.State != Active {
+		e.mu.Unlock()
+		return fmt.Errorf("no active trigger %s", id)
+	}
+
+	t.State = Cancelled
+	t.Updated = time.Now()
+	event := &Event{t.copy()}
+
+	err := e.save()
+
+	e.mu.Unlock()
+
+	e.events <- event
+
+	return err
+}
+
+// Remove forgets the triggers which are no longer active.
+func (e *Engine) Remove() error {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for id, t := range e.triggers {
+		if t.State != Active && t.State != Firing {
+			delete(e.triggers, id)
+		}
+	}
+
+	return e.save()
+}
+
+// Triggers returns a copy of every trigger sorted by creation date.
+func (e *Engine) Triggers() []*Trigger {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	res := make([]*Trigger, 0, len(e.triggers))
+	for _, t := range e.triggers {
+		res = append(res, t.copy())
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Created.Before(res[j].Created)
+	})
+
+	return res
+}
+
+// Update applies the rate of a market to its active triggers, firing those whose
+// condition is met.
+func (e *Engine) Update(currencyPair string, rate float64) {
+
+	if rate <= 0 {
+		return
+	}
+
+	var fired []*Trigger
+	changed := false
+
+	e.mu.Lock()
+
+	for _, t := range e.triggers {
+
+		if t.State != Active || t.CurrencyPair != currencyPair {
+			continue
+		}
+
+		fire, moved := t.update(rate)
+		changed = changed || moved
+
+		if !fire {
+			continue
+		}
+
+		t.State = Firing
+		t.FiredRate = rate
+		t.Updated = time.Now()
+		changed = true
+		fired = append(fired, t)
+
+		// One-cancels-other: the linked trigger can't fire anymore
+		if other, ok := e.triggers[t.OCO]; ok && other.State == Active {
+			other.State = Cancelled
+			other.Updated = t.Updated
+			defer func(c *Trigger) { e.events <- &Event{c} }(other.copy())
+		}
+	}
+
+	if changed {
+		if err := e.save(); err != nil {
+			logger.WithField("error", err).Error("Engine.save")
+		}
+	}
+
+	e.mu.Unlock()
+
+	for _, t := range fired {
+		e.fire(t)
+	}
+}
+
+// fire sends the order of a trigger in state Firing.
+func (e *Engine) fire(t *Trigger) {
+
+	e.mu.Lock()
+	rate := t.orderRate(t.FiredRate)
+	pair, typeOrder, amount, ioc := t.CurrencyPair, t.Type, t.Amount, t.ImmediateOrCancel
+	e.mu.Unlock()
+
+	var place func(string, float64, float64) (*tradingapi.BuyOrSellOrder, error)
+
+	switch {
+	case typeOrder == "buy" && ioc:
+		place = e.client.BuyImmediateOrCancel
+	case typeOrder == "buy":
+		place = e.client.Buy
+	case ioc:
+		place = e.client.SellImmediateOrCancel
+	default:
+		place = e.client.Sell
+	}
+
+	res, err := place(pair, rate, amount)
+
+	e.mu.Lock()
+
+	t.OrderRate = rate
+	t.Updated = time.Now()
+
+	if err != nil {
+		t.State = Failed
+		t.Error = err.Error()
+		logger.WithField("error", err).Errorf("trigger %s: %s %v %s at %v",
+			t.Id, typeOrder, amount, pair, rate)
+	} else {
+		t.State = Fired
+		t.OrderNumber = res.OrderNumber
+		logger.Infof("trigger %s: %s %v %s at %v, order %d",
+			t.Id, typeOrder, amount, pair, rate, res.OrderNumber)
+	}
+
+	if err := e.save(); err != nil {
+		logger.WithField("error", err).Error("Engine.save")
+	}
+
+	event := &Event{t.copy()}
+
+	e.mu.Unlock()
+
+	e.events <- event
+}
+
+// RunTicker applies the last rate of the pushed ticks until done is closed.
+func (e *Engine) RunTicker(ticker pushapi.Ticker, done <-chan struct{}) {
+
+	for {
+		select {
+		case tick := <-ticker:
+			if tick != nil {
+				e.Update(tick.CurrencyPair, tick.Last)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// RunMarket applies the rate of the pushed trades of currencyPair until updater is
+// unsubscribed (nil update received).
+func (e *Engine) RunMarket(currencyPair string, updater pushapi.MarketUpdater) {
+
+	for updates := range updater {
+
+		if updates == nil {
+			return
+		}
+
+		for _, update := range updates.Updates {
+			if trade, ok := update.Data.(*pushapi.NewTrade); ok {
+				e.Update(currencyPair, trade.Rate)
+			}
+		}
+	}
+}
+
+// RunBook applies the rate of the trades pushed to book (a running order book)
+// until done is closed. Unlike RunMarket, the market updater stays available to the
+// book and its other listeners.
+func (e *Engine) RunBook(book *orderbook.Book, done <-chan struct{}) {
+
+	updates := book.Updates()
+	defer book.StopUpdates(updates)
+
+	for {
+		select {
+		case u := <-updates:
+			for _, trade := range u.Trades {
+				e.Update(book.CurrencyPair(), trade.Rate)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// save writes the triggers to the state file. It must be called with e.mu held.
+func (e *Engine) save() error {
+
+	triggers := make([]*Trigger, 0, len(e.triggers))
+	for _, t := range e.triggers {
+		triggers = append(triggers, t)
+	}
+
+	sort.Slice(triggers, func(i, j int) bool {
+		return triggers[i].Id < triggers[j].Id
+	})
+
+	content, err := json.MarshalIndent(triggers, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %v", err)
+	}
+
+	return util.WriteFile(e.path, content)
+}
+
+func (t *Trigger) copy() *Trigger {
+
+	c := *t
+	return &c
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/joemocquant/poloniex-api/pushapi"
+	"github.com/joemocquant/poloniex-api/tradingapi"
+	"github.com/joemocquant/poloniex-api/triggers"
+)
+
+var (
+	tradingClient *tradingapi.Client
+	pushClient    *pushapi.Client
+)
+
+func main() {
+
+	var err error
+	tradingClient, err = tradingapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pushClient, err = pushapi.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	engine, err := triggers.NewEngine(tradingClient, "triggers.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stopLossTakeProfit(engine)
+
+	// trailingStop(engine)
+
+	listTriggers(engine)
+
+	run(engine)
+}
+
+// Sell 1 ETH if BTC_ETH falls to 0.03 or rises to 0.04, whichever comes first
+func stopLossTakeProfit(engine *triggers.Engine) {
+
+	stop := triggers.Trigger{
+		CurrencyPair:      "BTC_ETH",
+		Type:              "sell",
+		Kind:              triggers.StopLoss,
+		Amount:            1,
+		StopRate:          0.03,
+		LimitOffset:       0.005,
+		ImmediateOrCancel: true,
+	}
+
+	profit := stop
+	profit.Kind = triggers.TakeProfit
+	profit.StopRate = 0.04
+
+	stopId, profitId, err := engine.AddOCO(stop, profit)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("stop-loss %s, take-profit %s\n", stopId, profitId)
+}
+
+// Sell 1 ETH when BTC_ETH falls by 3% from its highest rate
+func trailingStop(engine *triggers.Engine) {
+
+	id, err := engine.Add(triggers.Trigger{
+		CurrencyPair:  "BTC_ETH",
+		Type:          "sell",
+		Kind:          triggers.TrailingStop,
+		Amount:        1,
+		TrailingDelta: 0.03,
+	})
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("trailing stop %s\n", id)
+}
+
+func listTriggers(engine *triggers.Engine) {
+
+	for _, t := range engine.Triggers() {
+		fmt.Printf("%s: %s %s %s %v (%s)\n",
+			t.Id, t.Kind, t.Type, t.CurrencyPair, t.Amount, t.State)
+	}
+}
+
+func run(engine *triggers.Engine) {
+
+	ticker, err := pushClient.SubscribeTicker()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go engine.RunTicker(ticker, nil)
+
+	for event := range engine.Events() {
+		t := event.Trigger
+		fmt.Printf("%s %s: %s at %.8f (order %d) %s\n",
+			t.Kind, t.Id, t.State, t.OrderRate, t.OrderNumber, t.Error)
+	}
+}
@@ -0,0 +1,148 @@
+package triggers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+)
+
+type Kind int
+
+const (
+	StopLoss     Kind = iota // fires when the rate moves against the position
+	TakeProfit               // fires when the rate moves in favour of the position
+	TrailingStop             // stop loss following the best rate seen
+)
+
+func (k Kind) String() string {
+
+	switch k {
+	case StopLoss:
+		return "stop-loss"
+	case TakeProfit:
+		return "take-profit"
+	case TrailingStop:
+		return "trailing-stop"
+	default:
+		return fmt.Sprintf("unknown kind %d", int(k))
+	}
+}
+
+type State int
+
+const (
+	Active State = iota
+	Firing       // order being sent
+	Fired
+	Cancelled
+	Failed
+)
+
+func (s State) String() string {
+
+	switch s {
+	case Active:
+		return "active"
+	case Firing:
+		return "firing"
+	case Fired:
+		return "fired"
+	case Cancelled:
+		return "cancelled"
+	case Failed:
+		return "failed"
+	default:
+		return fmt.Sprintf("unknown state %d", int(s))
+	}
+}
+
+// A Trigger sends an order when the rate of a market crosses a condition. A sell
+// stop-loss fires when the rate falls to StopRate, a sell take-profit when it rises
+// to StopRate, a sell trailing stop when it falls by TrailingDelta from the highest
+// rate seen; buy triggers fire on the opposite moves.
+type Trigger struct {
+	Id           string
+	CurrencyPair string
+	Type         string // order type sent: buy or sell
+	Kind         Kind
+	Amount       float64
+
+	StopRate      float64 // StopLoss and TakeProfit
+	TrailingDelta float64 // TrailingStop: relative distance from the extreme, e.g. 0.02
+	Extreme       float64 // TrailingStop: highest (sell) or lowest (buy) rate seen
+
+	// The order is sent at the triggering rate moved by LimitOffset (relative, e.g.
+	// 0.005) against the order: lower for a sell, higher for a buy
+	LimitOffset       float64
+	ImmediateOrCancel bool
+
+	OCO string // id of the trigger cancelled when this one fires (one-cancels-other)
+
+	State       State
+	Created     time.Time
+	Updated     time.Time
+	FiredRate   float64 // rate which fired the trigger
+	OrderRate   float64
+	OrderNumber int64
+	Error       string
+}
+
+func (t *Trigger) validate() error {
+
+	switch {
+	case t.CurrencyPair == "":
+		return fmt.Errorf("Wrong currency pair parameter")
+	case t.Type != "buy" && t.Type != "sell":
+		return fmt.Errorf("Wrong type parameter: %s", t.Type)
+	case t.Amount <= 0:
+		return fmt.Errorf("Wrong amount parameter: %v", t.Amount)
+	case t.Kind == TrailingStop && (t.TrailingDelta <= 0 || t.TrailingDelta >= 1):
+		return fmt.Errorf("Wrong trailing delta parameter: %v", t.TrailingDelta)
+	case t.Kind != TrailingStop && t.StopRate <= 0:
+		return fmt.Errorf("Wrong stop rate parameter: %v", t.StopRate)
+	case t.Kind < StopLoss || t.Kind > TrailingStop:
+		return fmt.Errorf("Wrong kind parameter: %d", t.Kind)
+	case t.LimitOffset < 0 || t.LimitOffset >= 1:
+		return fmt.Errorf("Wrong limit offset parameter: %v", t.LimitOffset)
+	}
+
+	return nil
+}
+
+// update applies a new rate and reports whether the trigger fires. It returns
+// true for the second value when the trailing extreme changed.
+func (t *Trigger) update(rate float64) (bool, bool) {
+
+	sell := t.Type == "sell"
+
+	switch t.Kind {
+	case StopLoss:
+		return sell && rate <= t.StopRate || !sell && rate >= t.StopRate, false
+
+	case TakeProfit:
+		return sell && rate >= t.StopRate || !sell && rate <= t.StopRate, false
+
+	default:
+		moved := false
+		if t.Extreme == 0 || sell && rate > t.Extreme || !sell && rate < t.Extreme {
+			t.Extreme = rate
+			moved = true
+		}
+
+		if sell {
+			return rate <= t.Extreme*(1-t.TrailingDelta), moved
+		}
+		return rate >= t.Extreme*(1+t.TrailingDelta), moved
+	}
+}
+
+// orderRate returns the rate of the order sent when the trigger fires at rate.
+func (t *Trigger) orderRate(rate float64) float64 {
+
+	// Rounded towards execution
+	if t.Type == "sell" {
+		return util.Floor(rate * (1 - t.LimitOffset))
+	}
+	return util.Ceil(rate * (1 + t.LimitOffset))
+}
@@ -0,0 +1,65 @@
+package validation
+
+import "fmt"
+
+// ErrInvalidValue is returned for a rate or an amount which is not a positive number.
+type ErrInvalidValue struct {
+	Field string // rate or amount
+	Value float64
+}
+
+func (e *ErrInvalidValue) Error() string {
+	return fmt.Sprintf("invalid %s: %v", e.Field, e.Value)
+}
+
+// ErrUnknownMarket is returned for a currency pair missing from the tickers.
+type ErrUnknownMarket struct {
+	CurrencyPair string
+}
+
+func (e *ErrUnknownMarket) Error() string {
+	return fmt.Sprintf("unknown market: %s", e.CurrencyPair)
+}
+
+// ErrMarketFrozen is returned when the ticker of the market is frozen.
+type ErrMarketFrozen struct {
+	CurrencyPair string
+}
+
+func (e *ErrMarketFrozen) Error() string {
+	return fmt.Sprintf("market frozen: %s", e.CurrencyPair)
+}
+
+// ErrCurrencyUnavailable is returned when a currency of the pair is disabled,
+// delisted, frozen or unknown.
+type ErrCurrencyUnavailable struct {
+	Currency string
+	Reason   string // disabled, delisted, frozen or unknown
+}
+
+func (e *ErrCurrencyUnavailable) Error() string {
+	return fmt.Sprintf("currency %s %s", e.Currency, e.Reason)
+}
+
+// ErrBelowMinTotal is returned when rate * amount is lower than the minimum total
+// of the base currency.
+type ErrBelowMinTotal struct {
+	CurrencyPair string
+	Total        float64
+	MinTotal     float64
+}
+
+func (e *ErrBelowMinTotal) Error() string {
+	return fmt.Sprintf("%s: total %.8f below minimum %.8f", e.CurrencyPair, e.Total, e.MinTotal)
+}
+
+// ErrPrecision is returned when a rate or an amount has too many decimals.
+type ErrPrecision struct {
+	Field    string // rate or amount
+	Value    float64
+	Decimals int
+}
+
+func (e *ErrPrecision) Error() string {
+	return fmt.Sprintf("%s %v has more than %d decimals", e.Field, e.Value, e.Decimals)
+}
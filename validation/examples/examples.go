@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/joemocquant/poloniex-api/publicapi"
+	"github.com/joemocquant/poloniex-api/validation"
+)
+
+var validator *validation.Validator
+
+func main() {
+
+	validator = validation.NewValidator(publicapi.NewClient(), validation.DefaultRules)
+
+	validateOrders()
+
+	// roundOrder()
+}
+
+// Print the validation result of a few BTC_ETH orders
+func validateOrders() {
+
+	orders := []struct {
+		rate, amount float64
+	}{
+		{0.03, 1},
+		{0.03, 0.001},
+		{0.030000001, 1},
+		{-1, 1},
+	}
+
+	for _, o := range orders {
+
+		err := validator.Validate("BTC_ETH", o.rate, o.amount)
+
+		switch err := err.(type) {
+		case nil:
+			fmt.Printf("%v @ %v: ok\n", o.amount, o.rate)
+		case *validation.ErrBelowMinTotal:
+			fmt.Printf("%v @ %v: total below %v\n", o.amount, o.rate, err.MinTotal)
+		default:
+			fmt.Printf("%v @ %v: %v\n", o.amount, o.rate, err)
+		}
+	}
+}
+
+// Round a BTC_ETH sell order
+func roundOrder() {
+
+	rate, amount, err := validator.Round("BTC_ETH", "sell", 0.0312345678, 1.123456789)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("sell %v @ %v\n", amount, rate)
+}
@@ -0,0 +1,201 @@
+// Client-side order validation.
+//
+// The trading API accepts any rate and amount and reports frozen markets, delisted
+// currencies, totals below the minimum or too many decimals as errors after a round
+// trip. A Validator checks orders locally against the currencies (GetCurrencies),
+// the tickers (GetTickers) and the market rules, returning typed errors, and can round
+// rates and amounts to the allowed precision.
+package validation
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/joemocquant/poloniex-api/internal/util"
+	"github.com/joemocquant/poloniex-api/publicapi"
+)
+
+type Rules struct {
+	MinTotal        map[string]float64 // minimum rate * amount by base currency
+	RateDecimals    int
+	AmountDecimals  int
+	MaxAge          time.Duration // refresh period of currencies and tickers
+	DefaultMinTotal float64       // base currencies missing from MinTotal
+}
+
+// DefaultRules are the rules of Poloniex exchange markets.
+var DefaultRules = Rules{
+	MinTotal: map[string]float64{
+		"BTC":  0.0001,
+		"ETH":  0.0001,
+		"XMR":  0.0001,
+		"USDT": 1,
+	},
+	RateDecimals:    8,
+	AmountDecimals:  8,
+	MaxAge:          time.Minute,
+	DefaultMinTotal: 0.0001,
+}
+
+type Validator struct {
+	client *publicapi.Client
+	rules  Rules
+
+	mu         sync.Mutex
+	currencies publicapi.Currencies
+	ticks      publicapi.Ticks
+	updated    time.Time
+}
+
+// NewValidator returns a validator using client to load currencies and tickers.
+func NewValidator(client *publicapi.Client, rules Rules) *Validator {
+	return &Validator{client: client, rules: rules}
+}
+
+// Refresh reloads the currencies and tickers.
+func (v *Validator) Refresh() error {
+
+	currencies, err := v.client.GetCurrencies()
+	if err != nil {
+		return fmt.Errorf("PublicClient.GetCurrencies: %v", err)
+	}
+
+	ticks, err := v.client.GetTickers()
+	if err != nil {
+		return fmt.Errorf("PublicClient.GetTickers: %v", err)
+	}
+
+	v.mu.Lock()
+	v.currencies = currencies
+	v.ticks = ticks
+	v.updated = time.Now()
+	v.mu.Unlock()
+
+	return nil
+}
+
+// Validate checks an order, refreshing currencies and tickers when older than
+// Rules.MaxAge. Rule violations are returned as *Err* types.
+func (v *Validator) Validate(currencyPair string, rate, amount float64) error {
+
+	if err := checkValue("rate", rate); err != nil {
+		return err
+	}
+	if err := checkValue("amount", amount); err != nil {
+		return err
+	}
+
+	if !util.HasDecimals(rate, v.rules.RateDecimals) {
+		return &ErrPrecision{"rate", rate, v.rules.RateDecimals}
+	}
+	if !util.HasDecimals(amount, v.rules.AmountDecimals) {
+		return &ErrPrecision{"amount", amount, v.rules.AmountDecimals}
+	}
+
+	base, quote, ok := util.SplitPair(currencyPair)
+	if !ok {
+		return &ErrUnknownMarket{currencyPair}
+	}
+
+	if err := v.refreshIfStale(); err != nil {
+		return err
+	}
+
+	if err := v.checkMarket(currencyPair, base, quote); err != nil {
+		return err
+	}
+
+	minTotal, ok := v.rules.MinTotal[base]
+	if !ok {
+		minTotal = v.rules.DefaultMinTotal
+	}
+
+	if total := rate * amount; total < minTotal-1e-12 {
+		return &ErrBelowMinTotal{currencyPair, total, minTotal}
+	}
+
+	return nil
+}
+
+// Round rounds the rate and amount of an order to the allowed precision, in favour
+// of the user (buy rates down, sell rates up, amounts down), then validates it.
+// typeOrder is "buy" or "sell".
+func (v *Validator) Round(currencyPair, typeOrder string, rate, amount float64) (float64, float64, error) {
+
+	switch typeOrder {
+	case "buy":
+		rate = util.FloorDecimals(rate, v.rules.RateDecimals)
+	case "sell":
+		rate = util.CeilDecimals(rate, v.rules.RateDecimals)
+	default:
+		return 0, 0, fmt.Errorf("wrong order type: %s", typeOrder)
+	}
+
+	amount = util.FloorDecimals(amount, v.rules.AmountDecimals)
+
+	if err := v.Validate(currencyPair, rate, amount); err != nil {
+		return rate, amount, err
+	}
+
+	return rate, amount, nil
+}
+
+func (v *Validator) refreshIfStale() error {
+
+	v.mu.Lock()
+	stale := v.ticks == nil || time.Since(v.updated) > v.rules.MaxAge
+	v.mu.Unlock()
+
+	if !stale {
+		return nil
+	}
+
+	if err := v.Refresh(); err != nil {
+		return fmt.Errorf("Validator.Refresh: %v", err)
+	}
+
+	return nil
+}
+
+func (v *Validator) checkMarket(currencyPair, base, quote string) error {
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	tick, ok := v.ticks[currencyPair]
+	if !ok {
+		return &ErrUnknownMarket{currencyPair}
+	}
+
+	if tick.IsFrozen {
+		return &ErrMarketFrozen{currencyPair}
+	}
+
+	for _, currency := range []string{base, quote} {
+
+		c, ok := v.currencies[currency]
+
+		switch {
+		case !ok:
+			return &ErrCurrencyUnavailable{currency, "unknown"}
+		case c.Delisted:
+			return &ErrCurrencyUnavailable{currency, "delisted"}
+		case c.Disabled:
+			return &ErrCurrencyUnavailable{currency, "disabled"}
+		case c.Frozen:
+			return &ErrCurrencyUnavailable{currency, "frozen"}
+		}
+	}
+
+	return nil
+}
+
+func checkValue(field string, value float64) error {
+
+	if math.IsNaN(value) || math.IsInf(value, 0) || value <= 0 {
+		return &ErrInvalidValue{field, value}
+	}
+	return nil
+}